@@ -0,0 +1,512 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// Default settings of FailureRateCircuitBreaker.
+const (
+	defaultWindow               = time.Minute
+	defaultWindowBuckets        = 10
+	defaultFailureRateThreshold = 0.5
+	defaultMinimumRequests      = 20
+	defaultOpenTimeout          = 30 * time.Second
+	defaultHalfOpenProbes       = 1
+)
+
+// StateListener is called after a breaker changed state. It runs synchronously
+// outside the breaker's lock, so it may call back into the breaker but should not block.
+type StateListener func(name string, from, to CircuitState)
+
+// Stats is a snapshot of a FailureRateCircuitBreaker.
+type Stats struct {
+	Name  string       `json:"name"`
+	State CircuitState `json:"state"`
+	// Requests, Failures and SlowCalls count the calls recorded in the current window.
+	Requests  int `json:"requests"`
+	Failures  int `json:"failures"`
+	SlowCalls int `json:"slow_calls"`
+	// FailureRate and SlowCallRate are relative to Requests; zero without requests.
+	FailureRate  float64 `json:"failure_rate"`
+	SlowCallRate float64 `json:"slow_call_rate"`
+	// HalfOpenProbes is the number of probes in flight while half-open.
+	HalfOpenProbes int `json:"half_open_probes"`
+	// OpenedAt is when the breaker last opened, and NextProbeAt when it will let
+	// probes through again. Both are zero while closed.
+	OpenedAt    time.Time `json:"opened_at"`
+	NextProbeAt time.Time `json:"next_probe_at"`
+}
+
+// FailureRateOption configures a FailureRateCircuitBreaker.
+type FailureRateOption func(*FailureRateCircuitBreaker)
+
+// WithWindow sets the length of the sliding window and the number of buckets it
+// is divided into. Calls leave the window one bucket at a time. Defaults to one
+// minute in 10 buckets.
+func WithWindow(size time.Duration, buckets int) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if size > 0 && buckets > 0 {
+			cb.window, cb.buckets = size, make([]bucket, buckets)
+		}
+	}
+}
+
+// WithFailureRateThreshold sets the failure rate, between 0 and 1, at which the
+// breaker opens. Defaults to 0.5.
+func WithFailureRateThreshold(rate float64) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if rate > 0 && rate <= 1 {
+			cb.failureRate = rate
+		}
+	}
+}
+
+// WithMinimumRequests sets how many calls the window must hold before the rates
+// are evaluated, so that a single failure after a quiet period does not open the
+// breaker. Defaults to 20.
+func WithMinimumRequests(n int) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if n > 0 {
+			cb.minRequests = n
+		}
+	}
+}
+
+// WithOpenTimeout sets how long the breaker stays open before it lets probes
+// through. Defaults to 30 seconds.
+func WithOpenTimeout(d time.Duration) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if d > 0 {
+			cb.openTimeout = d
+		}
+	}
+}
+
+// WithHalfOpenProbes sets how many calls may run concurrently while half-open.
+// The breaker closes once that many probes succeeded and reopens on the first
+// failed probe; other calls are rejected meanwhile. Defaults to 1.
+func WithHalfOpenProbes(n int) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if n > 0 {
+			cb.halfOpenProbes = n
+		}
+	}
+}
+
+// WithSlowCallThreshold treats calls taking at least threshold as slow, and opens
+// the breaker when the share of slow calls in the window reaches rate, even if
+// they succeeded. Disabled by default.
+func WithSlowCallThreshold(threshold time.Duration, rate float64) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if threshold > 0 && rate > 0 && rate <= 1 {
+			cb.slowThreshold, cb.slowRate = threshold, rate
+		}
+	}
+}
+
+// WithErrorClassifier sets the classifier deciding which errors count as
+// failures. Errors it considers retryable count, as do provider errors
+// (ErrCodeProviderUnavailable, ErrCodeProviderSendFailed, ErrCodeProviderTimeout
+// and ErrCodeProviderRateLimited); all other errors, such as parameter and
+// validation errors, are not recorded at all. Defaults to core.NewDefaultErrorClassifier.
+func WithErrorClassifier(classifier core.ErrorClassifier) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if classifier != nil {
+			cb.classifier = classifier
+		}
+	}
+}
+
+// WithStateListener adds a listener called on every state change.
+func WithStateListener(listener StateListener) FailureRateOption {
+	return func(cb *FailureRateCircuitBreaker) {
+		if listener != nil {
+			cb.listeners = append(cb.listeners, listener)
+		}
+	}
+}
+
+// bucket counts the calls of one slice of the window.
+type bucket struct {
+	epoch     int64
+	requests  int
+	failures  int
+	slowCalls int
+}
+
+// outcome is how a call is recorded.
+type outcome int
+
+const (
+	outcomeIgnored outcome = iota
+	outcomeSuccess
+	outcomeFailure
+)
+
+// FailureRateCircuitBreaker opens when the failure rate, or the slow-call rate,
+// of the calls in a sliding time window reaches a threshold. Unlike
+// MemoryCircuitBreaker it only counts errors that say something about the
+// provider (see WithErrorClassifier), waits for a minimum number of calls, and
+// limits the probes let through while half-open.
+type FailureRateCircuitBreaker struct {
+	name           string
+	window         time.Duration
+	buckets        []bucket
+	failureRate    float64
+	minRequests    int
+	openTimeout    time.Duration
+	halfOpenProbes int
+	slowThreshold  time.Duration
+	slowRate       float64
+	classifier     core.ErrorClassifier
+	listeners      []StateListener
+	logger         core.Logger
+	now            func() time.Time
+
+	mu             sync.Mutex
+	state          CircuitState
+	openedAt       time.Time
+	nextProbeAt    time.Time
+	probesInFlight int
+	probeSuccesses int
+}
+
+var (
+	_ core.CircuitBreaker       = (*FailureRateCircuitBreaker)(nil)
+	_ core.CircuitStateReporter = (*FailureRateCircuitBreaker)(nil)
+	_ core.HealthChecker        = (*FailureRateCircuitBreaker)(nil)
+)
+
+// NewFailureRateCircuitBreaker creates a FailureRateCircuitBreaker.
+func NewFailureRateCircuitBreaker(name string, opts ...FailureRateOption) *FailureRateCircuitBreaker {
+	cb := &FailureRateCircuitBreaker{
+		name:           name,
+		window:         defaultWindow,
+		buckets:        make([]bucket, defaultWindowBuckets),
+		failureRate:    defaultFailureRateThreshold,
+		minRequests:    defaultMinimumRequests,
+		openTimeout:    defaultOpenTimeout,
+		halfOpenProbes: defaultHalfOpenProbes,
+		classifier:     core.NewDefaultErrorClassifier(),
+		logger:         &core.NoOpLogger{},
+		now:            time.Now,
+		state:          StateClosed,
+	}
+	for _, opt := range opts {
+		opt(cb)
+	}
+	return cb
+}
+
+// SetLogger sets the logger for the circuit breaker.
+func (cb *FailureRateCircuitBreaker) SetLogger(logger core.Logger) {
+	cb.logger = logger
+}
+
+// Execute runs fn unless the breaker is open, or half-open with all probes in
+// flight, in which case it returns an ErrCodeCircuitBreakerOpen error. It also
+// returns without calling fn when ctx is already done.
+func (cb *FailureRateCircuitBreaker) Execute(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	probe, err := cb.acquire()
+	if err != nil {
+		return err
+	}
+
+	start := cb.now()
+	completed := false
+	defer func() {
+		// A panicking fn counts as a failure, so that its probe slot is released
+		// and the breaker cannot stay half-open forever; the panic propagates.
+		if !completed {
+			cb.record(probe, outcomeFailure, false)
+		}
+	}()
+	err = fn()
+	completed = true
+	elapsed := cb.now().Sub(start)
+
+	cb.record(probe, cb.classify(ctx, err), cb.slowThreshold > 0 && elapsed >= cb.slowThreshold)
+	return err
+}
+
+// classify decides how the result of a call is recorded.
+func (cb *FailureRateCircuitBreaker) classify(ctx context.Context, err error) outcome {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case ctx.Err() != nil && errors.Is(err, ctx.Err()):
+		// The caller gave up; that says nothing about the provider.
+		return outcomeIgnored
+	case cb.classifier.IsRetryableError(err):
+		return outcomeFailure
+	}
+	//nolint:exhaustive // only provider errors count
+	switch core.GetSenderErrorCode(err) {
+	case core.ErrCodeProviderUnavailable, core.ErrCodeProviderSendFailed,
+		core.ErrCodeProviderTimeout, core.ErrCodeProviderRateLimited:
+		return outcomeFailure
+	default:
+		return outcomeIgnored
+	}
+}
+
+// acquire admits a call, reporting whether it is a half-open probe.
+func (cb *FailureRateCircuitBreaker) acquire() (bool, error) {
+	cb.mu.Lock()
+	var change func()
+	defer func() {
+		cb.mu.Unlock()
+		if change != nil {
+			change()
+		}
+	}()
+
+	if cb.state == StateOpen && !cb.now().Before(cb.nextProbeAt) {
+		change = cb.transition(StateHalfOpen)
+	}
+	switch cb.state {
+	case StateOpen:
+		return false, core.NewSenderErrorf(core.ErrCodeCircuitBreakerOpen, "circuit breaker %s is OPEN", cb.name)
+	case StateHalfOpen:
+		if cb.probesInFlight >= cb.halfOpenProbes {
+			return false, core.NewSenderErrorf(core.ErrCodeCircuitBreakerOpen,
+				"circuit breaker %s is HALF_OPEN and all probes are in flight", cb.name)
+		}
+		cb.probesInFlight++
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// record records the outcome of a call admitted by acquire.
+func (cb *FailureRateCircuitBreaker) record(probe bool, result outcome, slow bool) {
+	cb.mu.Lock()
+	var change func()
+	defer func() {
+		cb.mu.Unlock()
+		if change != nil {
+			change()
+		}
+	}()
+
+	if probe {
+		cb.probesInFlight--
+		if cb.state != StateHalfOpen {
+			// Reset or another probe already decided.
+			return
+		}
+		switch {
+		case result == outcomeFailure || (result == outcomeSuccess && slow):
+			change = cb.transition(StateOpen)
+		case result == outcomeSuccess:
+			cb.probeSuccesses++
+			if cb.probeSuccesses >= cb.halfOpenProbes {
+				change = cb.transition(StateClosed)
+			}
+		}
+		return
+	}
+
+	if result == outcomeIgnored || cb.state != StateClosed {
+		return
+	}
+	b := cb.currentBucket()
+	b.requests++
+	if result == outcomeFailure {
+		b.failures++
+	}
+	if slow {
+		b.slowCalls++
+	}
+	if cb.shouldOpen() {
+		change = cb.transition(StateOpen)
+	}
+}
+
+// currentBucket returns the bucket for now, clearing it if it held an older slice.
+func (cb *FailureRateCircuitBreaker) currentBucket() *bucket {
+	epoch := cb.epoch()
+	b := &cb.buckets[epoch%int64(len(cb.buckets))]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+	return b
+}
+
+// epoch returns the index of the current bucket-sized slice of time.
+func (cb *FailureRateCircuitBreaker) epoch() int64 {
+	width := cb.window / time.Duration(len(cb.buckets))
+	return cb.now().UnixNano() / max(int64(width), 1)
+}
+
+// totals sums the buckets still inside the window.
+func (cb *FailureRateCircuitBreaker) totals() (int, int, int) {
+	epoch := cb.epoch()
+	var requests, failures, slowCalls int
+	for _, b := range cb.buckets {
+		if epoch-b.epoch < int64(len(cb.buckets)) {
+			requests += b.requests
+			failures += b.failures
+			slowCalls += b.slowCalls
+		}
+	}
+	return requests, failures, slowCalls
+}
+
+func (cb *FailureRateCircuitBreaker) shouldOpen() bool {
+	requests, failures, slowCalls := cb.totals()
+	if requests < cb.minRequests {
+		return false
+	}
+	if float64(failures)/float64(requests) >= cb.failureRate {
+		return true
+	}
+	return cb.slowRate > 0 && float64(slowCalls)/float64(requests) >= cb.slowRate
+}
+
+// transition moves to state and returns a function notifying the listeners, to
+// be called once the lock is released.
+func (cb *FailureRateCircuitBreaker) transition(state CircuitState) func() {
+	from := cb.state
+	if from == state {
+		return nil
+	}
+	cb.state = state
+	cb.probesInFlight, cb.probeSuccesses = 0, 0
+	switch state {
+	case StateOpen:
+		cb.openedAt = cb.now()
+		cb.nextProbeAt = cb.openedAt.Add(cb.openTimeout)
+	case StateClosed:
+		cb.openedAt, cb.nextProbeAt = time.Time{}, time.Time{}
+		clear(cb.buckets)
+	case StateHalfOpen:
+	}
+
+	requests, failures, slowCalls := cb.totals()
+	level := core.LevelInfo
+	if state == StateOpen {
+		level = core.LevelWarn
+	}
+	_ = cb.logger.Log(
+		level,
+		"message",
+		"circuit breaker state changed",
+		"circuit_breaker",
+		cb.name,
+		"state",
+		from.String()+" -> "+state.String(),
+		"requests",
+		requests,
+		"failures",
+		failures,
+		"slow_calls",
+		slowCalls,
+	)
+
+	listeners := cb.listeners
+	return func() {
+		for _, listener := range listeners {
+			listener(cb.name, from, state)
+		}
+	}
+}
+
+// GetState returns the current state. An open breaker whose timeout has elapsed
+// is reported as open until the next call moves it to half-open.
+func (cb *FailureRateCircuitBreaker) GetState() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// IsOpen reports whether the breaker currently rejects calls. It implements
+// core.CircuitStateReporter; an open breaker whose timeout has elapsed is
+// reported as closed so that it can be probed, and a half-open breaker as open
+// while all its probes are in flight.
+func (cb *FailureRateCircuitBreaker) IsOpen() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case StateOpen:
+		return cb.now().Before(cb.nextProbeAt)
+	case StateHalfOpen:
+		return cb.probesInFlight >= cb.halfOpenProbes
+	default:
+		return false
+	}
+}
+
+// Stats returns a snapshot of the breaker.
+func (cb *FailureRateCircuitBreaker) Stats() Stats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	requests, failures, slowCalls := cb.totals()
+	stats := Stats{
+		Name:           cb.name,
+		State:          cb.state,
+		Requests:       requests,
+		Failures:       failures,
+		SlowCalls:      slowCalls,
+		HalfOpenProbes: cb.probesInFlight,
+		OpenedAt:       cb.openedAt,
+		NextProbeAt:    cb.nextProbeAt,
+	}
+	if requests > 0 {
+		stats.FailureRate = float64(failures) / float64(requests)
+		stats.SlowCallRate = float64(slowCalls) / float64(requests)
+	}
+	return stats
+}
+
+// HealthCheck implements core.HealthChecker: the breaker is unhealthy while
+// open and degraded while half-open.
+func (cb *FailureRateCircuitBreaker) HealthCheck(_ context.Context) *core.HealthCheck {
+	stats := cb.Stats()
+	status := core.HealthStatusHealthy
+	//nolint:exhaustive // closed is healthy
+	switch stats.State {
+	case StateOpen:
+		status = core.HealthStatusUnhealthy
+	case StateHalfOpen:
+		status = core.HealthStatusDegraded
+	}
+	return &core.HealthCheck{
+		Status:  status,
+		Message: "circuit breaker " + cb.name + " is " + stats.State.String(),
+		Details: map[string]interface{}{
+			"requests":       stats.Requests,
+			"failures":       stats.Failures,
+			"slow_calls":     stats.SlowCalls,
+			"failure_rate":   stats.FailureRate,
+			"slow_call_rate": stats.SlowCallRate,
+		},
+		Timestamp: cb.now(),
+	}
+}
+
+// Reset closes the breaker and clears the window.
+func (cb *FailureRateCircuitBreaker) Reset() {
+	cb.mu.Lock()
+	change := cb.transition(StateClosed)
+	clear(cb.buckets)
+	cb.mu.Unlock()
+	if change != nil {
+		change()
+	}
+}
+
+// Close implements core.CircuitBreaker.
+func (cb *FailureRateCircuitBreaker) Close() error {
+	return nil
+}
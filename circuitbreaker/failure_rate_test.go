@@ -0,0 +1,190 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/circuitbreaker"
+	"github.com/shellvon/go-sender/core"
+)
+
+var errProvider = core.NewSenderError(core.ErrCodeProviderSendFailed, "send failed", nil)
+
+func TestFailureRateCircuitBreaker_OpensAtFailureRate(t *testing.T) {
+	cb := circuitbreaker.NewFailureRateCircuitBreaker("rate",
+		circuitbreaker.WithMinimumRequests(4),
+		circuitbreaker.WithFailureRateThreshold(0.5),
+	)
+	ctx := context.Background()
+
+	// Below the minimum volume nothing is evaluated.
+	for range 3 {
+		_ = cb.Execute(ctx, func() error { return errProvider })
+	}
+	if cb.GetState() != circuitbreaker.StateClosed {
+		t.Fatalf("expected CLOSED below minimum requests, got %v", cb.GetState())
+	}
+
+	_ = cb.Execute(ctx, func() error { return nil })
+	if cb.GetState() != circuitbreaker.StateOpen {
+		t.Fatalf("expected OPEN at 75%% failures, got %v", cb.GetState())
+	}
+	err := cb.Execute(ctx, func() error { return nil })
+	if core.GetSenderErrorCode(err) != core.ErrCodeCircuitBreakerOpen {
+		t.Errorf("expected ErrCodeCircuitBreakerOpen, got %v", err)
+	}
+	if !cb.IsOpen() {
+		t.Error("IsOpen should report an open breaker")
+	}
+}
+
+func TestFailureRateCircuitBreaker_IgnoresNonProviderErrors(t *testing.T) {
+	cb := circuitbreaker.NewFailureRateCircuitBreaker("ignore", circuitbreaker.WithMinimumRequests(2))
+	ctx := context.Background()
+
+	for range 5 {
+		_ = cb.Execute(ctx, func() error { return core.NewParamError("bad mobile") })
+		_ = cb.Execute(ctx, func() error { return core.ValidationError{Err: errors.New("empty content")} })
+	}
+	if stats := cb.Stats(); stats.State != circuitbreaker.StateClosed || stats.Requests != 0 {
+		t.Errorf("parameter errors must not be recorded: %+v", stats)
+	}
+
+	_ = cb.Execute(ctx, func() error { return core.NetworkError{Err: errors.New("reset")} })
+	_ = cb.Execute(ctx, func() error { return core.NetworkError{Err: errors.New("reset")} })
+	if cb.GetState() != circuitbreaker.StateOpen {
+		t.Errorf("retryable errors should open the breaker, got %v", cb.GetState())
+	}
+}
+
+func TestFailureRateCircuitBreaker_HalfOpenProbeLimit(t *testing.T) {
+	var mu sync.Mutex
+	var transitions []string
+	cb := circuitbreaker.NewFailureRateCircuitBreaker("probe",
+		circuitbreaker.WithMinimumRequests(1),
+		circuitbreaker.WithOpenTimeout(20*time.Millisecond),
+		circuitbreaker.WithHalfOpenProbes(1),
+		circuitbreaker.WithStateListener(func(_ string, from, to circuitbreaker.CircuitState) {
+			mu.Lock()
+			transitions = append(transitions, from.String()+"->"+to.String())
+			mu.Unlock()
+		}),
+	)
+	ctx := context.Background()
+	_ = cb.Execute(ctx, func() error { return errProvider })
+	time.Sleep(30 * time.Millisecond)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- cb.Execute(ctx, func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	called := false
+	err := cb.Execute(ctx, func() error { called = true; return nil })
+	if called || core.GetSenderErrorCode(err) != core.ErrCodeCircuitBreakerOpen {
+		t.Errorf("a second probe must be rejected, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if cb.GetState() != circuitbreaker.StateClosed {
+		t.Errorf("expected CLOSED after a successful probe, got %v", cb.GetState())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"CLOSED->OPEN", "OPEN->HALF_OPEN", "HALF_OPEN->CLOSED"}
+	if !slices.Equal(transitions, want) {
+		t.Errorf("transitions = %v, want %v", transitions, want)
+	}
+}
+
+func TestFailureRateCircuitBreaker_PanickingProbe(t *testing.T) {
+	cb := circuitbreaker.NewFailureRateCircuitBreaker("panic",
+		circuitbreaker.WithMinimumRequests(1),
+		circuitbreaker.WithOpenTimeout(20*time.Millisecond),
+		circuitbreaker.WithHalfOpenProbes(1),
+	)
+	ctx := context.Background()
+	_ = cb.Execute(ctx, func() error { return errProvider })
+	time.Sleep(30 * time.Millisecond)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to propagate")
+			}
+		}()
+		_ = cb.Execute(ctx, func() error { panic("boom") })
+	}()
+	if cb.GetState() != circuitbreaker.StateOpen {
+		t.Fatalf("a panicking probe should reopen the breaker, got %v", cb.GetState())
+	}
+
+	// The probe slot was released, so the next probe is admitted.
+	time.Sleep(30 * time.Millisecond)
+	if err := cb.Execute(ctx, func() error { return nil }); err != nil {
+		t.Fatalf("probe rejected after a panic: %v", err)
+	}
+	if cb.GetState() != circuitbreaker.StateClosed {
+		t.Errorf("expected CLOSED after a successful probe, got %v", cb.GetState())
+	}
+}
+
+func TestFailureRateCircuitBreaker_SlowCalls(t *testing.T) {
+	cb := circuitbreaker.NewFailureRateCircuitBreaker("slow",
+		circuitbreaker.WithMinimumRequests(2),
+		circuitbreaker.WithSlowCallThreshold(10*time.Millisecond, 0.5),
+	)
+	ctx := context.Background()
+	_ = cb.Execute(ctx, func() error { return nil })
+	_ = cb.Execute(ctx, func() error { time.Sleep(15 * time.Millisecond); return nil })
+
+	if cb.GetState() != circuitbreaker.StateOpen {
+		t.Errorf("expected OPEN at 50%% slow calls, got %v", cb.GetState())
+	}
+	if check := cb.HealthCheck(ctx); check.Status != core.HealthStatusUnhealthy {
+		t.Errorf("an open breaker should be unhealthy, got %s", check.Status)
+	}
+}
+
+func TestFailureRateCircuitBreaker_ContextDone(t *testing.T) {
+	cb := circuitbreaker.NewFailureRateCircuitBreaker("ctx")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	if err := cb.Execute(ctx, func() error { called = true; return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if called {
+		t.Error("fn must not run with a done context")
+	}
+}
+
+func TestFailureRateCircuitBreaker_WindowSlides(t *testing.T) {
+	cb := circuitbreaker.NewFailureRateCircuitBreaker("window",
+		circuitbreaker.WithWindow(40*time.Millisecond, 4),
+		circuitbreaker.WithMinimumRequests(2),
+	)
+	ctx := context.Background()
+	_ = cb.Execute(ctx, func() error { return errProvider })
+	time.Sleep(60 * time.Millisecond)
+	_ = cb.Execute(ctx, func() error { return errProvider })
+
+	if stats := cb.Stats(); stats.Requests != 1 || stats.State != circuitbreaker.StateClosed {
+		t.Errorf("the first failure should have left the window: %+v", stats)
+	}
+}
@@ -48,6 +48,13 @@ func (s CircuitState) String() string {
 	}
 }
 
+// MarshalText encodes the state by name, e.g. in JSON stats.
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+var _ core.CircuitStateReporter = (*MemoryCircuitBreaker)(nil)
+
 // NewMemoryCircuitBreaker creates a new in-memory circuit breaker.
 func NewMemoryCircuitBreaker(name string, maxFailures int64, resetTimeout time.Duration) *MemoryCircuitBreaker {
 	return &MemoryCircuitBreaker{
@@ -179,6 +186,15 @@ func (cb *MemoryCircuitBreaker) GetState() CircuitState {
 	return cb.state
 }
 
+// IsOpen reports whether the breaker currently rejects calls. It implements
+// core.CircuitStateReporter; an open breaker whose reset timeout has elapsed
+// is reported as closed so that it can be probed.
+func (cb *MemoryCircuitBreaker) IsOpen() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.state == StateOpen && time.Now().Before(cb.nextRetryTime)
+}
+
 // GetFailureCount returns the failure count.
 func (cb *MemoryCircuitBreaker) GetFailureCount() int64 {
 	cb.mu.RLock()
@@ -29,6 +29,7 @@ require (
 	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
+	golang.org/x/time v0.12.0 // indirect
 )
 
 replace github.com/shellvon/go-sender => ../../
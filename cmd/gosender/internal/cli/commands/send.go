@@ -77,6 +77,12 @@ configurations without actually sending messages.`,
 				return errors.New("either --content or --template-id is required")
 			}
 
+			if flags.Strategy != "" {
+				if _, ok := core.GlobalStrategyRegistry.Get(core.StrategyType(flags.Strategy)); !ok {
+					return fmt.Errorf("unknown --strategy %q", flags.Strategy)
+				}
+			}
+
 			// Handle dry-run mode early to avoid creating real providers
 			if flags.DryRun {
 				return handleDryRun(&flags, config)
@@ -96,7 +102,7 @@ configurations without actually sending messages.`,
 	cmd.Flags().StringVar(&flags.SubProvider, "sub-provider", "", "sub-provider type (aliyun, tencent, resend, etc.)")
 	cmd.Flags().StringVarP(&flags.Account, "account", "a", "", "specific account name to use")
 	cmd.Flags().
-		StringVar(&flags.Strategy, "strategy", "", "provider selection strategy (round_robin, random, weighted, health_based)")
+		StringVar(&flags.Strategy, "strategy", "", "provider selection strategy (round_robin, random, weighted, adaptive, consistent_hash, cheapest)")
 	cmd.Flags().StringVar(&flags.TemplateID, "template-id", "", "template ID for SMS and applicable providers")
 	cmd.Flags().
 		StringToStringVar(&flags.TemplateParams, "template-params", map[string]string{}, "template parameters as key=value pairs")
@@ -143,7 +149,10 @@ func handleDryRun(flags *cli.CLIFlags, conf *cli.RootConfig) error {
 	}
 
 	// 6. Handle different provider types for dry-run
-	var httpRequest *cli.HTTPRequestCapture
+	var (
+		httpRequest   *cli.HTTPRequestCapture
+		estimatedCost *cli.CostEstimate
+	)
 
 	if providerType == core.ProviderTypeEmail {
 		// For email provider, simulate SMTP connection details instead of actual sending
@@ -157,6 +166,11 @@ func handleDryRun(flags *cli.CLIFlags, conf *cli.RootConfig) error {
 			sendOpts = append(sendOpts, core.WithSendAccount(flags.Account))
 		}
 
+		// Add strategy override if specified
+		if flags.Strategy != "" {
+			sendOpts = append(sendOpts, core.WithSendStrategy(core.StrategyType(flags.Strategy)))
+		}
+
 		// Add timeout if specified
 		if flags.Timeout > 0 {
 			sendOpts = append(sendOpts, core.WithSendTimeout(flags.Timeout))
@@ -167,9 +181,16 @@ func handleDryRun(flags *cli.CLIFlags, conf *cli.RootConfig) error {
 			sendOpts = append(sendOpts, core.WithSendPriority(flags.Priority))
 		}
 
-		_, err = sender.SendWithResult(context.Background(), msg, sendOpts...)
-		if err != nil {
-			return fmt.Errorf("dry-run send failed: %w", err)
+		sendResult, sendErr := sender.SendWithResult(context.Background(), msg, sendOpts...)
+		if sendErr != nil {
+			return fmt.Errorf("dry-run send failed: %w", sendErr)
+		}
+		if sendResult != nil && sendResult.Cost != nil {
+			estimatedCost = &cli.CostEstimate{
+				Currency: sendResult.Cost.Currency,
+				Amount:   sendResult.Cost.Amount,
+				Unit:     fmt.Sprintf("%d %s(s)", sendResult.Cost.Units, sendResult.Cost.Unit),
+			}
 		}
 
 		// Capture the HTTP request details
@@ -187,6 +208,7 @@ func handleDryRun(flags *cli.CLIFlags, conf *cli.RootConfig) error {
 		ValidatedMessage: msg,
 		HTTPRequest:      httpRequest,
 		ValidationErrors: []string{}, // No validation errors if we got this far
+		EstimatedCost:    estimatedCost,
 	}
 
 	// Set default message type if not specified
@@ -339,6 +361,11 @@ func handleRealSend(flags *cli.CLIFlags, conf *cli.RootConfig) error {
 		sendOpts = append(sendOpts, core.WithSendAccount(flags.Account))
 	}
 
+	// Add strategy override if specified
+	if flags.Strategy != "" {
+		sendOpts = append(sendOpts, core.WithSendStrategy(core.StrategyType(flags.Strategy)))
+	}
+
 	// Add timeout if specified
 	if flags.Timeout > 0 {
 		sendOpts = append(sendOpts, core.WithSendTimeout(flags.Timeout))
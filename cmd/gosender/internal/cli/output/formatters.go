@@ -93,6 +93,9 @@ func (f *ConsoleFormatter) Format(result *cli.FormattedResult) (string, error) {
 		output.WriteString(fmt.Sprintf("Provider: %s\n", result.DryRun.Provider))
 		output.WriteString(fmt.Sprintf("Account: %s\n", result.DryRun.Account))
 		output.WriteString(fmt.Sprintf("Message Type: %s\n", result.DryRun.MessageType))
+		if cost := result.DryRun.EstimatedCost; cost != nil {
+			output.WriteString(fmt.Sprintf("Estimated Cost: %.4f %s (%s)\n", cost.Amount, cost.Currency, cost.Unit))
+		}
 
 		if len(result.DryRun.ValidationErrors) > 0 {
 			output.WriteString("Validation Errors:\n")
@@ -16,6 +16,8 @@ import "errors"
 type ProviderMeta struct {
 	Disabled bool         `json:"disabled" yaml:"disabled"` // When true the provider is globally disabled
 	Strategy StrategyType `json:"strategy" yaml:"strategy"` // Load-balancing strategy
+	// DisableQuotas turns off the built-in vendor quotas applied to every account.
+	DisableQuotas bool `json:"disable_quotas,omitempty" yaml:"disable_quotas,omitempty"`
 }
 
 // GetStrategy returns the configured strategy, falling back to round-robin if
@@ -40,6 +42,8 @@ type AccountMeta struct {
 	Weight int `json:"weight"             yaml:"weight"`
 	// Disabled is whether the account is disabled.
 	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	// Quota overrides the built-in rate limits of the provider for this account.
+	Quota *QuotaProfile `json:"quota,omitempty"    yaml:"quota,omitempty"`
 }
 
 // GetName returns the account name.
@@ -59,6 +63,9 @@ func (m *AccountMeta) IsEnabled() bool { return !m.Disabled }
 // GetType returns the subtype string for filtering.
 func (m *AccountMeta) GetType() string { return m.SubType }
 
+// GetQuota returns the account's quota override, or nil.
+func (m *AccountMeta) GetQuota() *QuotaProfile { return m.Quota }
+
 // Credentials groups the common 3-tuple used by almost every SaaS.
 // AppID can represent smsAccount / domain / AppId, etc.
 // APIKey can represent username / accessKey / appKey, etc.
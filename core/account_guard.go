@@ -0,0 +1,411 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CircuitStateReporter is implemented by circuit breakers that can tell, without
+// executing anything, whether they currently reject calls. Account selection
+// uses it to skip accounts whose breaker is open.
+type CircuitStateReporter interface {
+	IsOpen() bool
+}
+
+// GuardScope decides which accounts share a circuit breaker and rate limiter.
+type GuardScope int
+
+const (
+	// GuardPerAccount keys guards by AccountMeta.Name. This is the default.
+	GuardPerAccount GuardScope = iota
+	// GuardPerSubType keys guards by sub-provider (AccountMeta.SubType), so that
+	// all aliyun accounts share one breaker, for example.
+	GuardPerSubType
+)
+
+// AccountGuard holds circuit breakers and rate limiters for the individual
+// accounts of a multi-account provider. Attach it to a [BaseConfig] with
+// SetAccountGuard: selection then skips accounts whose breaker is open or whose
+// limiter is exhausted, and [BaseConfig.Execute] runs sends through the breaker
+// of the selected account.
+//
+// Guards are either registered explicitly with SetBreaker / SetLimiter, or created
+// lazily for each key by the factories given to [WithAccountBreakers] and
+// [WithAccountLimiters].
+type AccountGuard struct {
+	scope               GuardScope
+	newBreaker          func(key string) CircuitBreaker
+	newLimiter          func(key string) RateLimiter
+	newItemLimiter      func(item Selectable) RateLimiter
+	newRecipientLimiter func(item Selectable) RateLimiter
+	recipientIdleTTL    time.Duration
+
+	mu             sync.Mutex
+	breakers       map[string]CircuitBreaker
+	limiters       map[string]RateLimiter
+	recipients     map[string]*recipientLimiter
+	lastRecipSweep time.Time
+}
+
+// DefaultRecipientIdleTTL is how long a recipient limiter of an AccountGuard may
+// go unused before it is evicted.
+const DefaultRecipientIdleTTL = 10 * time.Minute
+
+// recipientLimiter is a recipient limiter with the time it was last used.
+type recipientLimiter struct {
+	limiter  RateLimiter
+	lastUsed time.Time
+}
+
+// AccountGuardOption configures an AccountGuard.
+type AccountGuardOption func(*AccountGuard)
+
+// WithGuardScope sets whether guards are shared per account or per sub-provider.
+func WithGuardScope(scope GuardScope) AccountGuardOption {
+	return func(g *AccountGuard) {
+		g.scope = scope
+	}
+}
+
+// WithAccountBreakers creates a circuit breaker for every key on first use.
+func WithAccountBreakers(factory func(key string) CircuitBreaker) AccountGuardOption {
+	return func(g *AccountGuard) {
+		g.newBreaker = factory
+	}
+}
+
+// WithAccountLimiters creates a rate limiter for every key on first use.
+func WithAccountLimiters(factory func(key string) RateLimiter) AccountGuardOption {
+	return func(g *AccountGuard) {
+		g.newLimiter = factory
+	}
+}
+
+// WithItemLimiters is like WithAccountLimiters, but passes the account itself so
+// that its limiter can depend on its sub-provider or settings.
+func WithItemLimiters(factory func(item Selectable) RateLimiter) AccountGuardOption {
+	return func(g *AccountGuard) {
+		g.newItemLimiter = factory
+	}
+}
+
+// WithRecipientLimiters limits the messages each account sends to one recipient,
+// as identified by [WithRecipient] or [WithRecipients]. factory is called once per account and
+// recipient; it may return nil for accounts without such a limit.
+func WithRecipientLimiters(factory func(item Selectable) RateLimiter) AccountGuardOption {
+	return func(g *AccountGuard) {
+		g.newRecipientLimiter = factory
+	}
+}
+
+// WithRecipientIdleTTL sets how long recipient limiters may go unused before they
+// are evicted, DefaultRecipientIdleTTL by default. It should exceed the period of
+// the limiters, or a recipient could start afresh while still limited.
+func WithRecipientIdleTTL(ttl time.Duration) AccountGuardOption {
+	return func(g *AccountGuard) {
+		if ttl > 0 {
+			g.recipientIdleTTL = ttl
+		}
+	}
+}
+
+// NewAccountGuard creates an AccountGuard.
+func NewAccountGuard(opts ...AccountGuardOption) *AccountGuard {
+	g := &AccountGuard{
+		recipientIdleTTL: DefaultRecipientIdleTTL,
+		breakers:         make(map[string]CircuitBreaker),
+		limiters:         make(map[string]RateLimiter),
+		recipients:       make(map[string]*recipientLimiter),
+		lastRecipSweep:   time.Now(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// SetBreaker registers the circuit breaker for key (an account name or sub-provider, depending on the scope).
+func (g *AccountGuard) SetBreaker(key string, breaker CircuitBreaker) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.breakers[key] = breaker
+}
+
+// SetLimiter registers the rate limiter for key (an account name or sub-provider, depending on the scope).
+func (g *AccountGuard) SetLimiter(key string, limiter RateLimiter) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limiters[key] = limiter
+}
+
+// Key returns the guard key of item under the configured scope.
+func (g *AccountGuard) Key(item Selectable) string {
+	if g.scope == GuardPerSubType && item.GetType() != "" {
+		return item.GetType()
+	}
+	return item.GetName()
+}
+
+// Breaker returns the circuit breaker guarding item, or nil.
+func (g *AccountGuard) Breaker(item Selectable) CircuitBreaker {
+	key := g.Key(item)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if cb, ok := g.breakers[key]; ok {
+		return cb
+	}
+	if g.newBreaker == nil {
+		return nil
+	}
+	cb := g.newBreaker(key)
+	g.breakers[key] = cb
+	return cb
+}
+
+// Limiter returns the rate limiter guarding item, or nil.
+func (g *AccountGuard) Limiter(item Selectable) RateLimiter {
+	key := g.Key(item)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if rl, ok := g.limiters[key]; ok {
+		return rl
+	}
+	var rl RateLimiter
+	switch {
+	case g.newItemLimiter != nil:
+		rl = g.newItemLimiter(item)
+	case g.newLimiter != nil:
+		rl = g.newLimiter(key)
+	default:
+		return nil
+	}
+	g.limiters[key] = rl
+	return rl
+}
+
+// RecipientLimiter returns the rate limiter for messages from item to recipient, or nil.
+func (g *AccountGuard) RecipientLimiter(item Selectable, recipient string) RateLimiter {
+	if recipient == "" || g.newRecipientLimiter == nil {
+		return nil
+	}
+	key := g.Key(item) + "\x00" + recipient
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.sweepRecipients(now)
+	if entry, ok := g.recipients[key]; ok {
+		entry.lastUsed = now
+		return entry.limiter
+	}
+	rl := g.newRecipientLimiter(item)
+	if rl != nil {
+		g.recipients[key] = &recipientLimiter{limiter: rl, lastUsed: now}
+	}
+	return rl
+}
+
+// sweepRecipients evicts idle recipient limiters, at most twice per idle TTL.
+// The caller must hold g.mu.
+func (g *AccountGuard) sweepRecipients(now time.Time) {
+	if now.Sub(g.lastRecipSweep) < g.recipientIdleTTL/2 {
+		return
+	}
+	for key, entry := range g.recipients {
+		if now.Sub(entry.lastUsed) > g.recipientIdleTTL {
+			_ = entry.limiter.Close()
+			delete(g.recipients, key)
+		}
+	}
+	g.lastRecipSweep = now
+}
+
+// Available reports whether item's circuit breaker currently accepts calls.
+// Breakers that do not implement [CircuitStateReporter] are assumed closed.
+func (g *AccountGuard) Available(item Selectable) bool {
+	if reporter, ok := g.Breaker(item).(CircuitStateReporter); ok {
+		return !reporter.IsOpen()
+	}
+	return true
+}
+
+// Acquire takes a token from item's rate limiter and reports whether one was available.
+func (g *AccountGuard) Acquire(item Selectable) bool {
+	return g.AcquireFor(item, "")
+}
+
+// AcquireFor takes a token from item's rate limiter and from its limiter for each
+// recipient, and reports whether all were available. When the limiters implement
+// [ReservingRateLimiter], no token is taken unless all are.
+func (g *AccountGuard) AcquireFor(item Selectable, recipients ...string) bool {
+	return allowAll(g.limitersFor(item, recipients))
+}
+
+// limitersFor returns item's rate limiter and its limiters for recipients.
+func (g *AccountGuard) limitersFor(item Selectable, recipients []string) []RateLimiter {
+	var limiters []RateLimiter
+	if rl := g.Limiter(item); rl != nil {
+		limiters = append(limiters, rl)
+	}
+	for _, recipient := range recipients {
+		if rl := g.RecipientLimiter(item, recipient); rl != nil {
+			limiters = append(limiters, rl)
+		}
+	}
+	return limiters
+}
+
+// reserveFor reserves a token from item's rate limiter and from its limiter for
+// each recipient, however long they take to become available. It fails when a
+// limiter cannot reserve, or can never grant the token.
+func (g *AccountGuard) reserveFor(item Selectable, recipients []string) (Reservation, bool) {
+	all := &multiReservation{}
+	for _, rl := range g.limitersFor(item, recipients) {
+		reserver, ok := rl.(ReservingRateLimiter)
+		if !ok {
+			all.Cancel()
+			return nil, false
+		}
+		r := reserver.ReserveN(1)
+		if !r.OK() {
+			all.Cancel()
+			return nil, false
+		}
+		all.parts = append(all.parts, r)
+	}
+	return all, true
+}
+
+// allowAll takes one token from every limiter and reports whether all had one.
+func allowAll(limiters []RateLimiter) bool {
+	reservations := make([]Reservation, 0, len(limiters))
+	for _, rl := range limiters {
+		reserver, ok := rl.(ReservingRateLimiter)
+		if !ok {
+			break
+		}
+		r := reserver.ReserveN(1)
+		reservations = append(reservations, r)
+		if !r.OK() || r.Delay() > 0 {
+			for _, r := range reservations {
+				r.Cancel()
+			}
+			return false
+		}
+	}
+	// Limiters that cannot reserve are asked last, one after the other.
+	for _, rl := range limiters[len(reservations):] {
+		if !rl.Allow() {
+			return false
+		}
+	}
+	return true
+}
+
+// multiReservation is a reservation in several limiters at once; its tokens may
+// be used once all of them are available.
+type multiReservation struct {
+	parts []Reservation
+}
+
+func (m *multiReservation) OK() bool { return true }
+
+func (m *multiReservation) Delay() time.Duration {
+	var delay time.Duration
+	for _, r := range m.parts {
+		delay = max(delay, r.Delay())
+	}
+	return delay
+}
+
+func (m *multiReservation) Cancel() {
+	for _, r := range m.parts {
+		r.Cancel()
+	}
+	m.parts = nil
+}
+
+type recipientKey struct{}
+
+// WithRecipient stores the recipient of the message being sent into ctx, for
+// the recipient limiters of an [AccountGuard].
+func WithRecipient(ctx context.Context, recipient string) context.Context {
+	if recipient == "" {
+		return ctx
+	}
+	return WithRecipients(ctx, recipient)
+}
+
+// WithRecipients is like WithRecipient for a message sent to several recipients
+// at once: each of them must be let through by its recipient limiter.
+func WithRecipients(ctx context.Context, recipients ...string) context.Context {
+	recipients = slices.DeleteFunc(slices.Clone(recipients), func(r string) bool { return r == "" })
+	if len(recipients) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, recipientKey{}, recipients)
+}
+
+// GetRecipient extracts the recipient stored by WithRecipient, or the first one
+// stored by WithRecipients. Returns "" when absent.
+func GetRecipient(ctx context.Context) string {
+	if recipients := GetRecipients(ctx); len(recipients) > 0 {
+		return recipients[0]
+	}
+	return ""
+}
+
+// GetRecipients extracts the recipients stored by WithRecipient or WithRecipients.
+func GetRecipients(ctx context.Context) []string {
+	recipients, _ := ctx.Value(recipientKey{}).([]string)
+	return recipients
+}
+
+// RecipientLister is implemented by messages sent to several recipients at once,
+// such as an SMS to several numbers. Every recipient is checked against the
+// recipient limiters of an [AccountGuard].
+type RecipientLister interface {
+	Recipients() []string
+}
+
+// Execute runs fn through item's circuit breaker, if any, and reports the outcome
+// to item's limiter if it is an [AdaptiveRateLimiter].
+func (g *AccountGuard) Execute(ctx context.Context, item Selectable, fn func() error) error {
+	run := fn
+	if rl := g.Limiter(item); rl != nil {
+		run = func() error {
+			err := fn()
+			observeRateLimit(rl, err)
+			return err
+		}
+	}
+	if cb := g.Breaker(item); cb != nil {
+		return cb.Execute(ctx, run)
+	}
+	return run()
+}
+
+// Close closes every breaker and limiter held by the guard.
+func (g *AccountGuard) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var errs []error
+	for _, cb := range g.breakers {
+		if cb != nil {
+			errs = append(errs, cb.Close())
+		}
+	}
+	for _, rl := range g.limiters {
+		if rl != nil {
+			errs = append(errs, rl.Close())
+		}
+	}
+	for _, entry := range g.recipients {
+		errs = append(errs, entry.limiter.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,198 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/circuitbreaker"
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/ratelimiter"
+)
+
+type countingLimiter struct{ tokens int }
+
+func (l *countingLimiter) Allow() bool {
+	if l.tokens <= 0 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+func (l *countingLimiter) Wait(context.Context) error { return nil }
+func (l *countingLimiter) Close() error               { return nil }
+
+func newGuardedConfig(guard *core.AccountGuard) *core.BaseConfig[*mockSelectable] {
+	config := &core.BaseConfig[*mockSelectable]{
+		ProviderMeta: core.ProviderMeta{Strategy: core.StrategyRoundRobin},
+		Items: []*mockSelectable{
+			{name: "aliyun-main", weight: 1, enabled: true, subType: "aliyun"},
+			{name: "tencent-backup", weight: 1, enabled: true, subType: "tencent"},
+		},
+	}
+	config.SetAccountGuard(guard)
+	return config
+}
+
+func TestAccountGuard_SkipsOpenBreaker(t *testing.T) {
+	guard := core.NewAccountGuard(core.WithAccountBreakers(func(key string) core.CircuitBreaker {
+		return circuitbreaker.NewMemoryCircuitBreaker(key, 1, time.Minute)
+	}))
+	config := newGuardedConfig(guard)
+	ctx := context.Background()
+
+	// Trip the breaker of aliyun-main with one failure.
+	main := config.Items[0]
+	_ = config.Execute(ctx, main, func() error { return errors.New("vendor down") })
+
+	for range 4 {
+		item, err := config.Select(ctx, nil)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		if item.GetName() != "tencent-backup" {
+			t.Errorf("expected tencent-backup while aliyun-main is open, got %s", item.GetName())
+		}
+	}
+
+	_, err := config.Select(core.WithRoute(ctx, &core.RouteInfo{AccountName: "aliyun-main"}), nil)
+	if core.GetSenderErrorCode(err) != core.ErrCodeCircuitBreakerOpen {
+		t.Errorf("expected circuit open error for explicit account, got %v", err)
+	}
+}
+
+func TestAccountGuard_SkipsExhaustedLimiter(t *testing.T) {
+	guard := core.NewAccountGuard()
+	guard.SetLimiter("aliyun-main", &countingLimiter{tokens: 1})
+	guard.SetLimiter("tencent-backup", &countingLimiter{tokens: 1})
+	config := newGuardedConfig(guard)
+	ctx := context.Background()
+
+	seen := map[string]bool{}
+	for range 2 {
+		item, err := config.Select(ctx, nil)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		seen[item.GetName()] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected both accounts to be used once, got %v", seen)
+	}
+
+	if _, err := config.Select(ctx, nil); core.GetSenderErrorCode(err) != core.ErrCodeProviderUnavailable {
+		t.Errorf("expected unavailable once all limiters are exhausted, got %v", err)
+	}
+}
+
+func TestAccountGuard_PerSubTypeScope(t *testing.T) {
+	created := map[string]int{}
+	guard := core.NewAccountGuard(
+		core.WithGuardScope(core.GuardPerSubType),
+		core.WithAccountLimiters(func(key string) core.RateLimiter {
+			created[key]++
+			return &countingLimiter{tokens: 10}
+		}),
+	)
+	config := newGuardedConfig(guard)
+	config.Items = append(config.Items, &mockSelectable{name: "aliyun-2", weight: 1, enabled: true, subType: "aliyun"})
+
+	for range 6 {
+		if _, err := config.Select(context.Background(), nil); err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+	}
+	if len(created) != 2 || created["aliyun"] != 1 || created["tencent"] != 1 {
+		t.Errorf("expected one limiter per sub-provider, got %v", created)
+	}
+}
+
+func TestAccountGuard_EvictsIdleRecipientLimiters(t *testing.T) {
+	created := 0
+	guard := core.NewAccountGuard(
+		core.WithRecipientLimiters(func(core.Selectable) core.RateLimiter {
+			created++
+			return &countingLimiter{tokens: 1}
+		}),
+		core.WithRecipientIdleTTL(20*time.Millisecond),
+	)
+	item := &mockSelectable{name: "aliyun-main", weight: 1, enabled: true}
+
+	first := guard.RecipientLimiter(item, "alice")
+	if guard.RecipientLimiter(item, "alice") != first {
+		t.Fatal("a recipient should keep its limiter while in use")
+	}
+	time.Sleep(30 * time.Millisecond)
+	guard.RecipientLimiter(item, "bob")
+	if guard.RecipientLimiter(item, "alice") == first || created != 3 {
+		t.Errorf("the idle limiter of alice should have been evicted, %d created", created)
+	}
+}
+
+func TestAccountGuard_HonoursRateLimitWait(t *testing.T) {
+	guard := core.NewAccountGuard(core.WithAccountLimiters(func(string) core.RateLimiter {
+		return ratelimiter.NewTokenBucketRateLimiter(20, 1)
+	}))
+	newDecorator := func(wait *core.RateLimitWait) (*core.ProviderDecorator, *accountProvider) {
+		p := newConfigProvider(newGuardedConfig(guard))
+		return core.NewProviderDecorator(p, &core.SenderMiddleware{RateLimitWait: wait}, &core.NoOpLogger{}), p
+	}
+	ctx := context.Background()
+
+	// Without RateLimitWait, the send fails once both accounts are exhausted.
+	pd, _ := newDecorator(nil)
+	for range 2 {
+		if _, err := pd.Send(ctx, &fakeMessage{}); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+	if _, err := pd.Send(ctx, &fakeMessage{}); core.GetSenderErrorCode(err) != core.ErrCodeProviderUnavailable {
+		t.Fatalf("expected the limits to reject the send, got %v", err)
+	}
+
+	// With it, the send waits for the first account to get a token again.
+	pd, p := newDecorator(&core.RateLimitWait{MaxWait: time.Second})
+	start := time.Now()
+	for range 3 {
+		if _, err := pd.Send(ctx, &fakeMessage{}); err != nil {
+			t.Fatalf("send should have waited for a token: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected the sends to wait, took %v", elapsed)
+	}
+	if selected, _ := p.sends(); len(selected) != 3 {
+		t.Errorf("expected three sends, got %v", selected)
+	}
+
+	// A token further away than MaxWait still fails at once.
+	pd, _ = newDecorator(&core.RateLimitWait{MaxWait: time.Millisecond})
+	_, _ = pd.Send(ctx, &fakeMessage{})
+	_, _ = pd.Send(ctx, &fakeMessage{})
+	if _, err := pd.Send(ctx, &fakeMessage{}); err == nil {
+		t.Error("expected a send exceeding MaxWait to fail")
+	}
+}
+
+func TestAccountGuard_LimitsEveryRecipient(t *testing.T) {
+	guard := core.NewAccountGuard(core.WithRecipientLimiters(func(core.Selectable) core.RateLimiter {
+		return ratelimiter.NewTokenBucketRateLimiter(0.0001, 1)
+	}))
+	config := newGuardedConfig(guard)
+	ctx := context.Background()
+
+	route := core.WithRoute(ctx, &core.RouteInfo{AccountName: "aliyun-main"})
+	if _, err := config.Select(core.WithRecipients(route, "13800000001", "13800000002"), nil); err != nil {
+		t.Fatalf("first message should pass: %v", err)
+	}
+	if _, err := config.Select(core.WithRecipient(route, "13800000002"), nil); err == nil {
+		t.Error("the second recipient of the batch should have used its quota")
+	}
+	if _, err := config.Select(core.WithRecipients(route, "13800000003", "13800000001"), nil); err == nil {
+		t.Error("a batch including a limited recipient should be rejected")
+	}
+	if _, err := config.Select(core.WithRecipient(route, "13800000003"), nil); err != nil {
+		t.Errorf("the rejected batch should not have used the quota of its other recipients: %v", err)
+	}
+}
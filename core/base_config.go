@@ -3,8 +3,10 @@ package core
 import (
 	"context"
 	"errors"
+	"slices"
 	"strconv"
 	"sync"
+	"time"
 )
 
 // Validatable is implemented by types that can validate their own fields.
@@ -28,6 +30,13 @@ type BaseConfig[T Selectable] struct {
 	// Items holds all accounts / endpoints / whatever a provider selects from.
 	Items []T `json:"items" yaml:"items"`
 
+	// guard holds optional per-account circuit breakers and rate limiters.
+	guard *AccountGuard
+	// unhealthy holds the error of the accounts whose last probe failed.
+	unhealthy map[string]error
+	// scopes holds the config's own scope of each registered ScopedStrategy.
+	scopes map[SelectionStrategy]SelectionStrategy
+
 	mu sync.RWMutex
 }
 
@@ -164,15 +173,135 @@ func (c *BaseConfig[T]) Update(item T) error {
 	return errors.New("item not found: " + item.GetName())
 }
 
+// SetAccountGuard attaches per-account circuit breakers and rate limiters.
+// Pass nil to remove them.
+func (c *BaseConfig[T]) SetAccountGuard(guard *AccountGuard) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.guard = guard
+}
+
+// AccountGuard returns the attached AccountGuard, or nil.
+func (c *BaseConfig[T]) AccountGuard() *AccountGuard {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.guard
+}
+
+// Execute runs fn, the send using item, through item's circuit breaker when an
+// AccountGuard is attached. The outcome is reported to the selection strategy in
+// use if it implements [OutcomeObserver]; failures are only reported when they
+// are the account's, not the message's.
+func (c *BaseConfig[T]) Execute(ctx context.Context, item T, fn func() error) error {
+	var (
+		ran   bool
+		start time.Time
+	)
+	run := func() error {
+		ran, start = true, time.Now()
+		return fn()
+	}
+
+	var err error
+	if guard := c.AccountGuard(); guard != nil {
+		err = guard.Execute(ctx, item, run)
+	} else {
+		err = run()
+	}
+
+	// Sends rejected by the breaker or cancelled by the caller say nothing about
+	// the account, and neither do failures caused by the message itself.
+	if ran && (err == nil || accountFailure(err)) {
+		if observer, ok := c.strategyFor(ctx).(OutcomeObserver); ok {
+			observer.Observe(item, err, time.Since(start))
+		}
+	}
+	return err
+}
+
+// accountFailure reports whether err is a failure of the account or the vendor
+// behind it, as opposed to one caused by the message, e.g. an invalid recipient
+// or a rejected template, or by the caller cancelling the send.
+func accountFailure(err error) bool {
+	switch VendorErrorKind(err) {
+	case ErrorKindAuthentication, ErrorKindQuotaExhausted, ErrorKindRateLimited, ErrorKindTransient:
+		return true
+	case ErrorKindValidation, ErrorKindRecipientBlocked, ErrorKindTemplateRejected:
+		return false
+	}
+
+	switch GetSenderErrorCode(err) {
+	case ErrCodeProviderUnavailable, ErrCodeProviderTimeout, ErrCodeProviderRateLimited,
+		ErrCodeProviderAuthFailed, ErrCodeProviderQuotaExhausted:
+		return true
+	case ErrCodeValidationFailed, ErrCodeRecipientBlocked, ErrCodeTemplateRejected:
+		return false
+	}
+
+	var authErr AuthenticationError
+	if errors.As(err, &authErr) {
+		return true
+	}
+	// What remains counts when retrying may help: network failures, timeouts,
+	// 5xx responses and throttling.
+	return NewDefaultErrorClassifier().IsRetryableError(err)
+}
+
+// strategyFor returns the selection strategy used for ctx, or nil.
+func (c *BaseConfig[T]) strategyFor(ctx context.Context) SelectionStrategy {
+	stType := c.GetStrategy()
+	if ri := GetRoute(ctx); ri != nil && ri.StrategyType != "" {
+		stType = ri.StrategyType
+	}
+	return c.StrategyScope(stType)
+}
+
+// StrategyScope returns the strategy the config selects with for stType: the one
+// registered in GlobalStrategyRegistry or, for a [ScopedStrategy], the config's
+// own scope of it. It returns nil for an unknown strategy.
+func (c *BaseConfig[T]) StrategyScope(stType StrategyType) SelectionStrategy {
+	strategy, _ := GlobalStrategyRegistry.Get(stType)
+	scoped, ok := strategy.(ScopedStrategy)
+	if !ok {
+		return strategy
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if scope, found := c.scopes[scoped]; found {
+		return scope
+	}
+	if c.scopes == nil {
+		c.scopes = make(map[SelectionStrategy]SelectionStrategy)
+	}
+	c.scopes[scoped] = scoped.NewScope()
+	return c.scopes[scoped]
+}
+
 // Select selects an item from the config based on the following priority:
 // 0. Filter items by filter function to get a subset of items
 // 1. Item name from context (if specified)
 // 2. Strategy from context (if specified)
 // 3. Default strategy.
+//
+// The SubType and ExcludeAccounts of the route in ctx narrow the candidates first,
+// and strategies implementing [KeyedSelectionStrategy] select by its RoutingKey.
+// With an AccountGuard attached, items whose circuit breaker is open are skipped,
+// and the selected item must obtain a token from its rate limiter (and from its
+// limiters for the recipients in ctx, see [WithRecipients]); otherwise it is
+// dropped and selection is repeated among the remaining items. Once every item
+// is rate limited, selection waits for the first to get its tokens if the send
+// may wait for rate limits (see [RateLimitWait]). Items whose last probe
+// failed (see [BaseConfig.RecordProbe]) are only selected when no other item is
+// left or when requested by name. All of these move a routing key to another
+// item, except with a strict [StrictKeyedStrategy] (see [WithStrictStickiness]),
+// for which selection fails when the item owning the key may not be used.
 func (c *BaseConfig[T]) Select(ctx context.Context, filter func(T) bool) (T, error) {
 	c.mu.RLock()
 	itemsCopy := make([]T, len(c.Items))
 	copy(itemsCopy, c.Items)
+	guard := c.guard
+	unhealthy := c.unhealthy
 	c.mu.RUnlock()
 
 	var filtered []T
@@ -182,38 +311,239 @@ func (c *BaseConfig[T]) Select(ctx context.Context, filter func(T) bool) (T, err
 		}
 	}
 	var zero T
-	if len(filtered) == 0 {
+	ri := GetRoute(ctx)
+	owner, sticky := c.stickyOwner(ctx, filtered)
+	filtered = narrowByRoute(filtered, ri)
+	switch {
+	case sticky:
+		if err := ownerUsable(owner, filtered, unhealthy, guard, ri); err != nil {
+			return zero, err
+		}
+		filtered = []T{owner}
+	case len(filtered) == 0:
+		if ri != nil && ri.StrictExclude {
+			return zero, errNoOtherAccount
+		}
 		return zero, errors.New("no available config after filtering")
+	case ri == nil || ri.AccountName == "":
+		filtered = preferHealthy(filtered, unhealthy)
+	}
+	item, err := c.selectGuarded(ctx, filtered, guard)
+	if err != nil {
+		return zero, err
 	}
+	recordSelection(ctx, item)
+	return item, nil
+}
+
+// stickyOwner returns the item of items owning the routing key of ctx, when the
+// send is routed by a [StrictKeyedStrategy] in strict mode and not pinned to an
+// account. The owner is looked up before exclusions, breakers and probes narrow
+// the items, so that it cannot move.
+func (c *BaseConfig[T]) stickyOwner(ctx context.Context, items []T) (T, bool) {
+	var zero T
+	ri := GetRoute(ctx)
+	if ri == nil || ri.RoutingKey == "" || ri.AccountName != "" {
+		return zero, false
+	}
+	strict, ok := c.strategyFor(ctx).(StrictKeyedStrategy)
+	if !ok || !strict.Strict() {
+		return zero, false
+	}
+	items = narrowByRoute(items, &RouteInfo{SubType: ri.SubType})
+	selected := strict.SelectByKey(ri.RoutingKey, toSelectables(items))
+	if selected == nil {
+		return zero, false
+	}
+	owner, err := c.findEnabledByName(selected.GetName(), items)
+	return owner, err == nil
+}
+
+// ownerUsable reports why owner, the item owning the routing key of a strictly
+// sticky send, may not serve it, or nil when it may.
+func ownerUsable[T Selectable](
+	owner T,
+	filtered []T,
+	unhealthy map[string]error,
+	guard *AccountGuard,
+	ri *RouteInfo,
+) error {
+	name := owner.GetName()
+	switch {
+	case !slices.ContainsFunc(filtered, func(it T) bool { return it.GetName() == name }):
+		if ri.StrictExclude {
+			return errNoOtherAccount
+		}
+		return NewSenderErrorf(ErrCodeProviderUnavailable, "account %s owning the routing key is excluded", name)
+	case unhealthy[name] != nil:
+		return NewSenderErrorf(ErrCodeProviderUnavailable,
+			"account %s owning the routing key failed its probe: %v", name, unhealthy[name])
+	case guard != nil && !guard.Available(owner):
+		return NewSenderErrorf(ErrCodeCircuitBreakerOpen, "circuit breaker of %s is open", name)
+	}
+	return nil
+}
+
+// selectGuarded picks one of filtered, skipping items rejected by guard. When
+// every item is rate limited and the send may wait (see [RateLimitWait]), it waits
+// for the item whose tokens arrive first.
+func (c *BaseConfig[T]) selectGuarded(ctx context.Context, filtered []T, guard *AccountGuard) (T, error) {
+	var zero T
+	if guard == nil {
+		return c.selectFrom(ctx, filtered)
+	}
+
+	recipients := GetRecipients(ctx)
+	maxWait := rateLimitWaitFrom(ctx)
+
+	// An explicitly requested account is never swapped for another one.
+	if ri := GetRoute(ctx); ri != nil && ri.AccountName != "" {
+		item, err := c.findEnabledByName(ri.AccountName, filtered)
+		if err != nil {
+			return zero, err
+		}
+		if !guard.Available(item) {
+			return zero, NewSenderErrorf(ErrCodeCircuitBreakerOpen, "circuit breaker of %s is open", item.GetName())
+		}
+		if !guard.AcquireFor(item, recipients...) {
+			if _, ok, err := waitGuarded(ctx, guard, []T{item}, recipients, maxWait); !ok {
+				return zero, err
+			}
+		}
+		return item, nil
+	}
+
+	candidates := make([]T, 0, len(filtered))
+	for _, item := range filtered {
+		if item.IsEnabled() && guard.Available(item) {
+			candidates = append(candidates, item)
+		}
+	}
+	if len(candidates) == 0 {
+		if ri := GetRoute(ctx); ri != nil && ri.StrictExclude {
+			return zero, errNoOtherAccount
+		}
+	}
+	limited := make([]T, 0, len(candidates))
+	for len(candidates) > 0 {
+		item, err := c.selectFrom(ctx, candidates)
+		if err != nil {
+			return zero, err
+		}
+		if guard.AcquireFor(item, recipients...) {
+			return item, nil
+		}
+		limited = append(limited, item)
+		candidates = slices.DeleteFunc(candidates, func(it T) bool { return it.GetName() == item.GetName() })
+	}
+	if item, ok, err := waitGuarded(ctx, guard, limited, recipients, maxWait); ok {
+		return item, nil
+	} else if err != nil && !IsSenderError(err) {
+		return zero, err
+	}
+	return zero, NewSenderError(
+		ErrCodeProviderUnavailable,
+		"no available config: circuit breakers open or rate limits exceeded",
+		nil,
+	)
+}
+
+// waitGuarded waits for the rate limit tokens of whichever of items gets them
+// first, provided that takes at most maxWait (without limit when negative), and
+// returns that item. It fails at once when maxWait is zero.
+func waitGuarded[T Selectable](
+	ctx context.Context,
+	guard *AccountGuard,
+	items []T,
+	recipients []string,
+	maxWait time.Duration,
+) (T, bool, error) {
+	var (
+		zero   T
+		chosen = -1
+		best   Reservation
+	)
+	if maxWait != 0 {
+		for i, item := range items {
+			r, ok := guard.reserveFor(item, recipients)
+			if !ok {
+				continue
+			}
+			if best == nil || r.Delay() < best.Delay() {
+				if best != nil {
+					best.Cancel()
+				}
+				chosen, best = i, r
+			} else {
+				r.Cancel()
+			}
+		}
+	}
+	if best == nil {
+		return zero, false, rateLimitedError(items)
+	}
+	if delay := best.Delay(); maxWait > 0 && delay > maxWait {
+		best.Cancel()
+		return zero, false, NewSenderErrorf(ErrCodeRateLimitExceeded,
+			"rate limit of %s exceeded: tokens available in %s, more than %s", items[chosen].GetName(), delay, maxWait)
+	}
+	if err := waitReservation(ctx, best, best.Delay()); err != nil {
+		return zero, false, err
+	}
+	return items[chosen], true, nil
+}
+
+// rateLimitedError reports that the rate limits of items were exceeded.
+func rateLimitedError[T Selectable](items []T) error {
+	if len(items) == 1 {
+		return NewSenderErrorf(ErrCodeRateLimitExceeded, "rate limit of %s exceeded", items[0].GetName())
+	}
+	return NewSenderError(ErrCodeRateLimitExceeded, "rate limits exceeded", nil)
+}
+
+// selectFrom picks one of items by route and strategy.
+func (c *BaseConfig[T]) selectFrom(ctx context.Context, filtered []T) (T, error) {
+	var zero T
 	if len(filtered) == 1 {
 		if !filtered[0].IsEnabled() {
 			return zero, errors.New("selected item is disabled")
 		}
 		return filtered[0], nil
 	}
-	ri := GetRoute(ctx)
-	stType := c.GetStrategy()
 	// 1. 指定账号
-	if ri != nil && ri.AccountName != "" {
+	if ri := GetRoute(ctx); ri != nil && ri.AccountName != "" {
 		return c.findEnabledByName(ri.AccountName, filtered)
 	}
-	if ri != nil && ri.StrategyType != "" {
-		stType = ri.StrategyType
-	}
 
 	// 3. 必须有策略
-	strategy, _ := GlobalStrategyRegistry.Get(stType)
+	strategy := c.strategyFor(ctx)
 	if strategy == nil {
 		return zero, errors.New("no strategy specified or unknown strategy")
 	}
 
-	selected := strategy.Select(toSelectables(filtered))
+	selected := selectWith(ctx, strategy, toSelectables(filtered))
 	if selected != nil {
 		return c.findEnabledByName(selected.GetName(), filtered)
 	}
 	return zero, errors.New("no config selected by strategy")
 }
 
+// selectWith lets strategy pick one of items, passing it the routing key or cost
+// estimator from ctx when it can use them.
+func selectWith(ctx context.Context, strategy SelectionStrategy, items []Selectable) Selectable {
+	if keyed, ok := strategy.(KeyedSelectionStrategy); ok {
+		if ri := GetRoute(ctx); ri != nil && ri.RoutingKey != "" {
+			return keyed.SelectByKey(ri.RoutingKey, items)
+		}
+	}
+	if costAware, ok := strategy.(CostAwareSelectionStrategy); ok {
+		if estimator := GetCostEstimator(ctx); estimator != nil {
+			return costAware.SelectByCost(items, estimator)
+		}
+	}
+	return strategy.Select(items)
+}
+
 // findEnabledByName returns the first enabled item with matching name.
 func (c *BaseConfig[T]) findEnabledByName(name string, items []T) (T, error) {
 	var zero T
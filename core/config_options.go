@@ -50,3 +50,22 @@ func WithProviderDisabled[T ProviderMetaAccessor]() func(T) {
 		config.GetProviderMeta().Disabled = true
 	}
 }
+
+// WithQuotasDisabled 关闭内置的厂商配额限流 - 使用接口约束，类型安全.
+func WithQuotasDisabled[T ProviderMetaAccessor]() func(T) {
+	return func(config T) {
+		config.GetProviderMeta().DisableQuotas = true
+	}
+}
+
+// AccountGuardSetter 定义设置账号级熔断器/限流器的接口.
+type AccountGuardSetter interface {
+	SetAccountGuard(guard *AccountGuard)
+}
+
+// WithAccountGuard 设置账号级熔断器和限流器 - 使用接口约束，类型安全.
+func WithAccountGuard[T AccountGuardSetter](guard *AccountGuard) func(T) {
+	return func(config T) {
+		config.SetAccountGuard(guard)
+	}
+}
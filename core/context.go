@@ -2,11 +2,13 @@ package core
 
 import (
 	"context"
+	"slices"
 )
 
 type (
-	metadataKey struct{}
-	routeKey    struct{}
+	metadataKey       struct{}
+	routeKey          struct{}
+	idempotencyKeyKey struct{}
 )
 
 // RouteInfo carries per-send routing preferences such as explicit account
@@ -14,6 +16,18 @@ type (
 type RouteInfo struct {
 	AccountName  string
 	StrategyType StrategyType
+	// ExcludeAccounts lists accounts that must not be selected, e.g. because they
+	// already failed for this send. It is ignored when it would exclude every
+	// candidate, unless StrictExclude is set.
+	ExcludeAccounts []string
+	// StrictExclude makes selection fail rather than fall back on an excluded
+	// account, e.g. for a hedge that must run on another account than the first attempt.
+	StrictExclude bool
+	// SubType restricts selection to accounts of this sub-provider.
+	SubType string
+	// RoutingKey identifies the recipient, tenant, etc. for strategies that keep a
+	// key on the same account, such as [ConsistentHashStrategy].
+	RoutingKey string
 }
 
 // WithRoute stores the provided RouteInfo into context and returns the derived
@@ -25,6 +39,16 @@ func WithRoute(ctx context.Context, info *RouteInfo) context.Context {
 	return context.WithValue(ctx, routeKey{}, info)
 }
 
+// cloneRoute returns a copy of ri, or an empty RouteInfo when ri is nil.
+func cloneRoute(ri *RouteInfo) *RouteInfo {
+	if ri == nil {
+		return &RouteInfo{}
+	}
+	clone := *ri
+	clone.ExcludeAccounts = slices.Clone(ri.ExcludeAccounts)
+	return &clone
+}
+
 // GetRoute extracts RouteInfo from context. Returns nil when absent.
 func GetRoute(ctx context.Context) *RouteInfo {
 	if ri, ok := ctx.Value(routeKey{}).(*RouteInfo); ok {
@@ -40,3 +64,16 @@ func GetSendMetadataFromCtx(ctx context.Context) map[string]interface{} {
 	}
 	return nil
 }
+
+// withIdempotencyKey stores the idempotency key shared by the attempts of a send.
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// GetIdempotencyKey returns the key shared by the concurrent attempts of a hedged
+// send, or "" when the send is not hedged. Providers whose vendor deduplicates
+// requests should pass it on, so that at most one of the attempts is delivered.
+func GetIdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// CostEstimate is the estimated price of sending a message with one account.
+type CostEstimate struct {
+	// Account is the name of the account the estimate is for.
+	Account string `json:"account,omitempty"`
+	// Currency is the currency of Amount, e.g. "CNY".
+	Currency string `json:"currency"`
+	// Amount is the total estimated price.
+	Amount float64 `json:"amount"`
+	// Units is the number of billed units, e.g. segments × recipients for SMS.
+	Units int `json:"units"`
+	// Unit names what Units counts, e.g. "segment".
+	Unit string `json:"unit"`
+}
+
+// CostEstimator is implemented by messages whose price depends on the account
+// sending them. EstimateCost reports false when item has no pricing for the message.
+type CostEstimator interface {
+	EstimateCost(item Selectable) (*CostEstimate, bool)
+}
+
+// CostAwareSelectionStrategy is implemented by strategies that pick items by the
+// price of the message being sent. BaseConfig.Select calls SelectByCost instead
+// of Select when a CostEstimator is stored in the context.
+type CostAwareSelectionStrategy interface {
+	SelectionStrategy
+	SelectByCost(items []Selectable, estimator CostEstimator) Selectable
+}
+
+type costEstimatorKey struct{}
+
+// WithCostEstimator stores the estimator of the message being sent into ctx.
+func WithCostEstimator(ctx context.Context, estimator CostEstimator) context.Context {
+	if estimator == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, costEstimatorKey{}, estimator)
+}
+
+// GetCostEstimator extracts the CostEstimator from ctx. Returns nil when absent.
+func GetCostEstimator(ctx context.Context) CostEstimator {
+	if e, ok := ctx.Value(costEstimatorKey{}).(CostEstimator); ok {
+		return e
+	}
+	return nil
+}
+
+// CheapestStrategy selects the healthy item with the lowest estimated price for
+// the message. Items whose health check reports them unhealthy are skipped (as are
+// items with an open breaker when an AccountGuard is attached), items without
+// pricing are only used when no priced item is available, and items of equal price
+// are used in turn.
+//
+// Without a CostEstimator it falls back to round robin.
+type CheapestStrategy struct {
+	counter atomic.Uint32
+}
+
+// NewCheapestStrategy creates a new cheapest-first selection strategy.
+func NewCheapestStrategy() *CheapestStrategy {
+	return &CheapestStrategy{}
+}
+
+// Name returns the name of the cheapest strategy.
+func (s *CheapestStrategy) Name() StrategyType {
+	return StrategyCheapest
+}
+
+// Select picks the enabled items in turn; it is used when the price is unknown.
+func (s *CheapestStrategy) Select(items []Selectable) Selectable {
+	return s.next(enabledItems(items))
+}
+
+// SelectByCost picks the cheapest healthy item.
+func (s *CheapestStrategy) SelectByCost(items []Selectable, estimator CostEstimator) Selectable {
+	candidates := healthyItems(enabledItems(items))
+	if len(candidates) == 0 {
+		return s.next(enabledItems(items))
+	}
+
+	var cheapest []Selectable
+	lowest := 0.0
+	for _, item := range candidates {
+		est, ok := estimator.EstimateCost(item)
+		if !ok {
+			continue
+		}
+		switch {
+		case len(cheapest) == 0 || est.Amount < lowest:
+			cheapest, lowest = []Selectable{item}, est.Amount
+		case est.Amount == lowest:
+			cheapest = append(cheapest, item)
+		}
+	}
+	if len(cheapest) == 0 {
+		return s.next(candidates)
+	}
+	return s.next(cheapest)
+}
+
+// next returns the items in turn.
+func (s *CheapestStrategy) next(items []Selectable) Selectable {
+	if len(items) == 0 {
+		return nil
+	}
+	return items[int((s.counter.Add(1)-1)%uint32(len(items)))] //nolint:gosec // len is small and positive
+}
+
+// healthyItems drops the items whose health check reports them as not healthy.
+func healthyItems(items []Selectable) []Selectable {
+	healthy := make([]Selectable, 0, len(items))
+	for _, item := range items {
+		if hc, ok := item.(HealthCheckable); ok {
+			if h := hc.HealthCheck(context.Background()); h != nil && h.Status != HealthStatusHealthy {
+				continue
+			}
+		}
+		healthy = append(healthy, item)
+	}
+	return healthy
+}
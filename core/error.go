@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // RetryableError interface for retryable errors.
@@ -93,6 +94,59 @@ func (e AuthenticationError) IsRetryable() bool {
 	return false
 }
 
+// ThrottledError means the vendor refused a request because it was sent too fast,
+// e.g. with HTTP 429 or a "rate limited" error code (retryable).
+type ThrottledError struct {
+	Err error
+}
+
+func (e ThrottledError) Error() string {
+	return fmt.Sprintf("throttled: %v", e.Err)
+}
+
+// Unwrap returns the vendor error.
+func (e ThrottledError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable returns whether the throttling error is retryable.
+func (e ThrottledError) IsRetryable() bool {
+	return true
+}
+
+// RetryAfterError is a failure for which the vendor suggested how long to wait
+// before retrying, e.g. with a Retry-After header. RetryPolicy.NextDelay honours it.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the vendor error.
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the delay suggested by err, or an error it wraps, through a
+// RetryAfterError.
+func RetryAfter(err error) (time.Duration, bool) {
+	var hinted *RetryAfterError
+	if errors.As(err, &hinted) && hinted.Delay > 0 {
+		return hinted.Delay, true
+	}
+	return 0, false
+}
+
+// IsThrottled reports whether err means the vendor throttled the request: it is
+// or wraps a ThrottledError, or a SenderError with ErrCodeProviderRateLimited.
+func IsThrottled(err error) bool {
+	var throttled ThrottledError
+	return errors.As(err, &throttled) || GetSenderErrorCode(err) == ErrCodeProviderRateLimited
+}
+
 // =============================================================================
 // Smart Error Classifier
 // =============================================================================
@@ -330,6 +384,32 @@ const (
 	ErrCodeValidationFailed
 )
 
+// Codes added after the initial release use explicit values so that the iota-based
+// codes above never shift.
+const (
+	// ErrCodeQueueItemEvicted means an accepted message was evicted from a full queue.
+	ErrCodeQueueItemEvicted ErrorCode = 3100
+	// ErrCodeQueueItemNotFound means no queued or scheduled message has the requested ID.
+	ErrCodeQueueItemNotFound ErrorCode = 3101
+	// ErrCodeQueueItemCancelled means a queued or scheduled message was cancelled before it was sent.
+	ErrCodeQueueItemCancelled ErrorCode = 3102
+	// ErrCodeMessageExpired means a message passed its ExpireAt deadline before it could be sent.
+	ErrCodeMessageExpired ErrorCode = 3103
+	// ErrCodeSendPending means the outcome of an async send is not known yet.
+	ErrCodeSendPending ErrorCode = 3104
+	// ErrCodeProviderAuthFailed means the vendor refused the account's credentials.
+	ErrCodeProviderAuthFailed ErrorCode = 2100
+	// ErrCodeProviderQuotaExhausted means the account's balance or daily quota is used up.
+	ErrCodeProviderQuotaExhausted ErrorCode = 2101
+	// ErrCodeRecipientBlocked means the recipient refuses messages.
+	ErrCodeRecipientBlocked ErrorCode = 2102
+	// ErrCodeTemplateRejected means the vendor rejected the template, signature or content.
+	ErrCodeTemplateRejected ErrorCode = 2103
+	// ErrCodeRetryBudgetExhausted means a failed send was not retried because the
+	// provider's RetryBudget was used up.
+	ErrCodeRetryBudgetExhausted ErrorCode = 4100
+)
+
 // SenderError represents a structured error with code, message, and cause.
 type SenderError struct {
 	Code    ErrorCode `json:"code"`
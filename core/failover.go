@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// FailoverPolicy decides which accounts of a multi-account provider the retries
+// of a send use. The zero value excludes every account after its first failure,
+// so that each retry lands on an account that has not failed yet.
+type FailoverPolicy struct {
+	// SameAccountAttempts is the number of attempts made on an account before it is
+	// excluded and later attempts fail over to another one. Values below 1 mean 1.
+	SameAccountAttempts int
+	// SameSubType restricts failover to accounts of the sub-provider (e.g. "aliyun")
+	// that served the first attempt.
+	SameSubType bool
+	// FailoverPinned lets sends with an explicit AccountName fail over too; the named
+	// account is then only used for the first SameAccountAttempts attempts. By default
+	// such sends never change account.
+	FailoverPinned bool
+	// Disabled turns failover off: every attempt selects an account as the first one did.
+	Disabled bool
+}
+
+// SendAttempt records one attempt of a send.
+type SendAttempt struct {
+	// Account is the name of the account used. It is empty when no account was
+	// selected or the provider does not select through BaseConfig.
+	Account string
+	// SubType is the sub-provider of the account.
+	SubType string
+	// Err is the error of the attempt, nil on success.
+	Err error
+	// Latency is the duration of the attempt.
+	Latency time.Duration
+	// Hedge reports whether the attempt was started by a HedgePolicy alongside a
+	// slow one, rather than after it.
+	Hedge bool
+}
+
+type selectionKey struct{}
+
+// errNoOtherAccount is returned by BaseConfig.Select when a route with
+// StrictExclude leaves no account to select.
+var errNoOtherAccount = errors.New("no other account available")
+
+// accountSelection receives the account chosen by BaseConfig.Select during one
+// attempt, or during a whole send when it is the parent of the attempts' ones.
+type accountSelection struct {
+	mu      sync.Mutex
+	name    string
+	subType string
+	parent  *accountSelection
+}
+
+// get returns the account selected so far; hedges read it while the attempt runs.
+func (sel *accountSelection) get() (string, string) {
+	sel.mu.Lock()
+	defer sel.mu.Unlock()
+	return sel.name, sel.subType
+}
+
+// withSelectionRecorder returns a context in which BaseConfig.Select reports the
+// account it selects to the returned accountSelection, and to the recorders of
+// ctx.
+func withSelectionRecorder(ctx context.Context) (context.Context, *accountSelection) {
+	sel := &accountSelection{}
+	sel.parent, _ = ctx.Value(selectionKey{}).(*accountSelection)
+	return context.WithValue(ctx, selectionKey{}, sel), sel
+}
+
+// recordSelection reports item to the recorders in ctx, if any.
+func recordSelection(ctx context.Context, item Selectable) {
+	sel, _ := ctx.Value(selectionKey{}).(*accountSelection)
+	for ; sel != nil; sel = sel.parent {
+		sel.mu.Lock()
+		sel.name, sel.subType = item.GetName(), item.GetType()
+		sel.mu.Unlock()
+	}
+}
+
+// narrowByRoute applies the SubType and ExcludeAccounts restrictions of ri to items.
+func narrowByRoute[T Selectable](items []T, ri *RouteInfo) []T {
+	if ri == nil {
+		return items
+	}
+	if ri.SubType != "" {
+		items = slices.DeleteFunc(slices.Clone(items), func(it T) bool { return it.GetType() != ri.SubType })
+	}
+	if len(ri.ExcludeAccounts) > 0 {
+		rest := slices.DeleteFunc(slices.Clone(items), func(it T) bool {
+			return slices.Contains(ri.ExcludeAccounts, it.GetName())
+		})
+		if len(rest) > 0 || ri.StrictExclude {
+			items = rest
+		}
+	}
+	return items
+}
+
+// failover tracks the accounts tried by one send across its retry attempts.
+type failover struct {
+	policy   FailoverPolicy
+	pinned   string
+	strategy StrategyType
+	key      string
+	subType  string
+	sticky   string
+	excluded []string
+	failures map[string]int
+	attempts []SendAttempt
+	winner   int
+}
+
+// newFailover starts tracking a send whose route comes from ctx and opts.
+func newFailover(ctx context.Context, policy *FailoverPolicy, opts *SendOptions) *failover {
+	f := &failover{failures: make(map[string]int), winner: -1}
+	if policy != nil {
+		f.policy = *policy
+	}
+	if ri := GetRoute(ctx); ri != nil {
+		f.pinned, f.strategy, f.key, f.subType = ri.AccountName, ri.StrategyType, ri.RoutingKey, ri.SubType
+		f.excluded = slices.Clone(ri.ExcludeAccounts)
+	}
+	if opts.AccountName != "" {
+		f.pinned = opts.AccountName
+	}
+	if opts.StrategyName != "" {
+		f.strategy = StrategyType(opts.StrategyName)
+	}
+	return f
+}
+
+// route returns the routing of the next attempt.
+func (f *failover) route() *RouteInfo {
+	ri := &RouteInfo{StrategyType: f.strategy, SubType: f.subType, RoutingKey: f.key}
+	switch {
+	case f.pinned != "":
+		ri.AccountName = f.pinned
+	case f.sticky != "":
+		ri.AccountName = f.sticky
+	default:
+		ri.ExcludeAccounts = slices.Clone(f.excluded)
+	}
+	return ri
+}
+
+// hedgeRoute returns the routing of a hedge started alongside an attempt on the
+// account first: any other account the send may use, as failover would pick. It
+// returns nil when the hedge could only use first: first is not known yet, or
+// the send is pinned to an account.
+func (f *failover) hedgeRoute(first string) *RouteInfo {
+	if first == "" || (f.pinned != "" && !f.policy.FailoverPinned) {
+		return nil
+	}
+	ri := &RouteInfo{StrategyType: f.strategy, SubType: f.subType, RoutingKey: f.key, StrictExclude: true}
+	ri.ExcludeAccounts = slices.Clone(f.excluded)
+	if !slices.Contains(ri.ExcludeAccounts, first) {
+		ri.ExcludeAccounts = append(ri.ExcludeAccounts, first)
+	}
+	return ri
+}
+
+// record adds an attempt to the history and decides the account of the next one.
+func (f *failover) record(sel *accountSelection, err error, latency time.Duration, hedge bool) {
+	name, subType := sel.get()
+	f.attempts = append(f.attempts, SendAttempt{
+		Account: name,
+		SubType: subType,
+		Err:     err,
+		Latency: latency,
+		Hedge:   hedge,
+	})
+	if err == nil && f.winner < 0 {
+		f.winner = len(f.attempts) - 1
+	}
+	if f.policy.Disabled || err == nil || name == "" {
+		return
+	}
+	if f.policy.SameSubType && f.subType == "" {
+		f.subType = subType
+	}
+
+	f.failures[name]++
+	if f.failures[name] < max(f.policy.SameAccountAttempts, 1) {
+		f.sticky = name
+		return
+	}
+	f.sticky = ""
+	if !slices.Contains(f.excluded, name) {
+		f.excluded = append(f.excluded, name)
+	}
+	if f.policy.FailoverPinned && f.pinned == name {
+		f.pinned = ""
+	}
+}
+
+// attach sets the attempt history on result, creating one if needed.
+func (f *failover) attach(result *SendResult) *SendResult {
+	if result == nil {
+		result = &SendResult{}
+	}
+	result.Attempts = slices.Clone(f.attempts)
+	result.Winner = f.winner
+	return result
+}
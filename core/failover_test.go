@@ -0,0 +1,287 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/queue"
+)
+
+// accountProvider is a multi-account provider selecting through BaseConfig. Its
+// sends fail on the accounts in failing and its probes on those in down, while
+// the accounts in unprobed cannot be probed. Each send waits selectDelay before
+// selecting an account, then the n-th one takes latency[n] unless canceled.
+type accountProvider struct {
+	config      *core.BaseConfig[*mockSelectable]
+	failing     map[string]bool
+	down        map[string]bool
+	unprobed    map[string]bool
+	selectDelay time.Duration
+	latency     []time.Duration
+
+	canceled atomic.Int32
+	probes   atomic.Int32
+
+	mu       sync.Mutex
+	selected []string
+	keys     []string
+}
+
+// account returns an enabled account of weight 1.
+func account(name, subType string) *mockSelectable {
+	return &mockSelectable{name: name, weight: 1, enabled: true, subType: subType}
+}
+
+// newAccountProvider returns an accountProvider with accounts, by default
+// aliyun-1, tencent-1 and aliyun-2, selected round-robin.
+func newAccountProvider(accounts ...*mockSelectable) *accountProvider {
+	if len(accounts) == 0 {
+		accounts = []*mockSelectable{
+			account("aliyun-1", "aliyun"),
+			account("tencent-1", "tencent"),
+			account("aliyun-2", "aliyun"),
+		}
+	}
+	return newConfigProvider(&core.BaseConfig[*mockSelectable]{
+		ProviderMeta: core.ProviderMeta{Strategy: core.StrategyRoundRobin},
+		Items:        accounts,
+	})
+}
+
+// newConfigProvider returns an accountProvider selecting from config.
+func newConfigProvider(config *core.BaseConfig[*mockSelectable]) *accountProvider {
+	return &accountProvider{
+		config:   config,
+		failing:  make(map[string]bool),
+		down:     make(map[string]bool),
+		unprobed: make(map[string]bool),
+	}
+}
+
+// failOn makes the sends on the named accounts fail.
+func (p *accountProvider) failOn(names ...string) *accountProvider {
+	for _, name := range names {
+		p.failing[name] = true
+	}
+	return p
+}
+
+// probeDown makes the probes of the named accounts fail.
+func (p *accountProvider) probeDown(names ...string) *accountProvider {
+	for _, name := range names {
+		p.down[name] = true
+	}
+	return p
+}
+
+func (p *accountProvider) Send(ctx context.Context, _ core.Message, _ *core.ProviderSendOptions) (*core.SendResult, error) {
+	time.Sleep(p.selectDelay)
+	item, err := p.config.Select(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	n := len(p.selected)
+	p.selected = append(p.selected, item.name)
+	p.keys = append(p.keys, core.GetIdempotencyKey(ctx))
+	p.mu.Unlock()
+
+	if p.failing[item.name] {
+		return nil, errors.New(item.name + " is down")
+	}
+	if n < len(p.latency) {
+		select {
+		case <-time.After(p.latency[n]):
+		case <-ctx.Done():
+			p.canceled.Add(1)
+			return nil, ctx.Err()
+		}
+	}
+	return &core.SendResult{Config: item}, nil
+}
+
+func (p *accountProvider) Name() string { return "accounts" }
+
+func (p *accountProvider) Probe(ctx context.Context, _ *core.ProviderSendOptions) []core.ProbeResult {
+	p.probes.Add(1)
+	return p.config.ProbeItems(ctx, func(_ context.Context, item *mockSelectable) (map[string]interface{}, error) {
+		switch {
+		case p.unprobed[item.name]:
+			return nil, core.ErrProbeUnsupported
+		case p.down[item.name]:
+			return nil, errors.New("login failed")
+		}
+		return map[string]interface{}{"balance": 10}, nil
+	})
+}
+
+// sends returns the accounts selected by the sends so far, and their idempotency keys.
+func (p *accountProvider) sends() ([]string, []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.selected), slices.Clone(p.keys)
+}
+
+func sendWithFailover(
+	t *testing.T,
+	p core.Provider,
+	failover core.FailoverPolicy,
+	opts ...core.SendOption,
+) (*core.SendResult, error) {
+	t.Helper()
+	retry := core.NewRetryPolicy(
+		core.WithRetryMaxAttempts(3),
+		core.WithRetryInitialDelay(time.Millisecond),
+		core.WithRetryFilter(func(int, error) bool { return true }),
+		core.WithRetryFailover(failover),
+	)
+	pd := core.NewProviderDecorator(p, &core.SenderMiddleware{Retry: retry}, &core.NoOpLogger{})
+	defer pd.Close()
+	return pd.Send(context.Background(), &fakeMessage{}, opts...)
+}
+
+func attemptedAccounts(result *core.SendResult) []string {
+	var names []string
+	for _, a := range result.Attempts {
+		names = append(names, a.Account)
+	}
+	return names
+}
+
+func TestFailover_ExcludesFailedAccounts(t *testing.T) {
+	p := newAccountProvider().failOn("aliyun-1", "tencent-1")
+	result, err := sendWithFailover(t, p, core.FailoverPolicy{}, core.WithSendStrategy(core.StrategyRoundRobin))
+	if err != nil {
+		t.Fatalf("expected success on the remaining account, got %v", err)
+	}
+	names := attemptedAccounts(result)
+	if len(names) < 2 || names[len(names)-1] != "aliyun-2" {
+		t.Fatalf("expected to end on aliyun-2, got %v", names)
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			t.Errorf("account %s attempted twice: %v", name, names)
+		}
+		seen[name] = true
+	}
+	for i, a := range result.Attempts {
+		if failed := i < len(result.Attempts)-1; (a.Err != nil) != failed {
+			t.Errorf("attempt %d: unexpected error %v", i, a.Err)
+		}
+	}
+}
+
+func TestFailover_PinnedAccountStays(t *testing.T) {
+	p := newAccountProvider().failOn("aliyun-1")
+	result, err := sendWithFailover(t, p, core.FailoverPolicy{}, core.WithSendAccount("aliyun-1"))
+	if err == nil {
+		t.Fatal("expected failure on the pinned account")
+	}
+	want := []string{"aliyun-1", "aliyun-1", "aliyun-1", "aliyun-1"}
+	if got := attemptedAccounts(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestFailover_SameAccountThenSameSubType(t *testing.T) {
+	p := newAccountProvider().failOn("aliyun-1")
+	policy := core.FailoverPolicy{SameAccountAttempts: 2, SameSubType: true, FailoverPinned: true}
+	result, err := sendWithFailover(t, p, policy, core.WithSendAccount("aliyun-1"))
+	if err != nil {
+		t.Fatalf("expected success after failover, got %v", err)
+	}
+	want := []string{"aliyun-1", "aliyun-1", "aliyun-2"}
+	if got := attemptedAccounts(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	for _, a := range result.Attempts {
+		if a.SubType != "aliyun" {
+			t.Errorf("expected failover within aliyun, got %+v", a)
+		}
+	}
+}
+
+// metricsRecorder keeps the metrics it is given.
+type metricsRecorder struct {
+	mu   sync.Mutex
+	data []core.MetricsData
+}
+
+func (m *metricsRecorder) RecordSendResult(data core.MetricsData) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = append(m.data, data)
+}
+
+// operation returns the metrics recorded for op.
+func (m *metricsRecorder) operation(op string) []core.MetricsData {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var data []core.MetricsData
+	for _, d := range m.data {
+		if d.Operation == op {
+			data = append(data, d)
+		}
+	}
+	return data
+}
+
+func TestFailover_MetricsReportServingAccount(t *testing.T) {
+	recorder := &metricsRecorder{}
+	pd := core.NewProviderDecorator(newAccountProvider(), &core.SenderMiddleware{Metrics: recorder}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	if _, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendAccount("tencent-1")); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.data) != 1 {
+		t.Fatalf("expected one metric, got %+v", recorder.data)
+	}
+	if got := recorder.data[0]; got.Account != "tencent-1" || got.SubType != "tencent" || got.Operation != core.OperationSent {
+		t.Errorf("expected the sent operation on tencent-1, got %+v", got)
+	}
+}
+
+func TestFailover_MetricsReportAsyncSends(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue core.Queue
+	}{
+		{name: "queue", queue: queue.NewMemoryQueue[*core.QueueItem](0)},
+		{name: "in-process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &metricsRecorder{}
+			pd := core.NewProviderDecorator(newAccountProvider(),
+				&core.SenderMiddleware{Queue: tt.queue, Metrics: recorder}, &core.NoOpLogger{})
+			defer pd.Close()
+
+			_, err := pd.Send(context.Background(), &fakeMessage{},
+				core.WithSendAsync(), core.WithSendAccount("tencent-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			deadline := time.Now().Add(2 * time.Second)
+			sent := recorder.operation(core.OperationSent)
+			for len(sent) == 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+				sent = recorder.operation(core.OperationSent)
+			}
+			if len(sent) != 1 {
+				t.Fatalf("expected one sent metric, got %+v", sent)
+			}
+			if got := sent[0]; got.Account != "tencent-1" || !got.Success {
+				t.Errorf("expected a successful send on tencent-1, got %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// futurePollInterval is how often a pending SendFuture re-reads its ResultStore while waiting.
+const futurePollInterval = 100 * time.Millisecond
+
+// SendStatus is the state of an async send as seen through a [SendFuture] or [ResultStore].
+type SendStatus string
+
+const (
+	// SendStatusPending means the message is queued or being sent.
+	SendStatusPending SendStatus = "pending"
+	// SendStatusSucceeded means the message was sent.
+	SendStatusSucceeded SendStatus = "succeeded"
+	// SendStatusFailed means the send failed after all retries.
+	SendStatusFailed SendStatus = "failed"
+	// SendStatusCancelled means the message was cancelled or evicted before it was sent.
+	SendStatusCancelled SendStatus = "cancelled"
+	// SendStatusExpired means the message passed its ExpireAt deadline before it was sent.
+	SendStatusExpired SendStatus = "expired"
+)
+
+// statusOf maps the outcome of a send to its SendStatus.
+func statusOf(err error) SendStatus {
+	switch {
+	case err == nil:
+		return SendStatusSucceeded
+	case errors.Is(err, context.Canceled):
+		return SendStatusCancelled
+	}
+	//nolint:exhaustive // every other code is a plain failure
+	switch GetSenderErrorCode(err) {
+	case ErrCodeQueueItemCancelled, ErrCodeQueueItemEvicted:
+		return SendStatusCancelled
+	case ErrCodeMessageExpired:
+		return SendStatusExpired
+	default:
+		return SendStatusFailed
+	}
+}
+
+// SendRecord is the outcome of an async send kept in a [ResultStore].
+type SendRecord struct {
+	MsgID     string      `json:"msg_id"`
+	Provider  string      `json:"provider"`
+	Status    SendStatus  `json:"status"`
+	Result    *SendResult `json:"result,omitempty"`
+	ErrorCode ErrorCode   `json:"error_code,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// newSendRecord builds the record stored for a finished send.
+func newSendRecord(msgID, provider string, result *SendResult, err error) *SendRecord {
+	record := &SendRecord{
+		MsgID:     msgID,
+		Provider:  provider,
+		Status:    statusOf(err),
+		Result:    result,
+		UpdatedAt: time.Now(),
+	}
+	if err != nil {
+		record.ErrorCode = GetSenderErrorCode(err)
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// Err rebuilds the send error from the record, or nil if the send succeeded.
+func (r *SendRecord) Err() error {
+	if r.Status == SendStatusSucceeded {
+		return nil
+	}
+	if r.ErrorCode != ErrCodeUnknown {
+		return NewSenderError(r.ErrorCode, r.Error, nil)
+	}
+	return errors.New(r.Error)
+}
+
+// ResultStore keeps the outcome of async sends by message ID so that a [SendFuture]
+// can resolve when the message is processed elsewhere, e.g. by workers consuming a
+// distributed queue. Workers and producers must be configured with the same store.
+type ResultStore interface {
+	// Save stores the final record of a send.
+	Save(ctx context.Context, record *SendRecord) error
+	// Load returns the record of msgID, or (nil, nil) if it is not available yet.
+	Load(ctx context.Context, msgID string) (*SendRecord, error)
+}
+
+// CacheResultStore is a ResultStore backed by a [Cache].
+type CacheResultStore struct {
+	cache Cache[*SendRecord]
+	ttl   *time.Duration
+}
+
+// NewCacheResultStore creates a ResultStore on top of cache. Records are kept for ttl;
+// zero keeps them until the cache evicts them.
+func NewCacheResultStore(cache Cache[*SendRecord], ttl time.Duration) *CacheResultStore {
+	store := &CacheResultStore{cache: cache}
+	if ttl > 0 {
+		store.ttl = &ttl
+	}
+	return store
+}
+
+// NewMemoryResultStore creates an in-process ResultStore whose records expire after ttl.
+func NewMemoryResultStore(ttl time.Duration) *CacheResultStore {
+	return NewCacheResultStore(NewMemoryCache[*SendRecord](), ttl)
+}
+
+// Save implements ResultStore.
+func (s *CacheResultStore) Save(_ context.Context, record *SendRecord) error {
+	return s.cache.Set(record.MsgID, record, s.ttl)
+}
+
+// Load implements ResultStore.
+func (s *CacheResultStore) Load(_ context.Context, msgID string) (*SendRecord, error) {
+	record, ok := s.cache.Get(msgID)
+	if !ok {
+		return nil, nil //nolint:nilnil // not available yet
+	}
+	return record, nil
+}
+
+// SendFuture is a handle to the outcome of an async send.
+//
+// It resolves in-process when the message is handled by a local queue consumer or
+// goroutine. When the message is processed by another process, it resolves by
+// polling the configured [ResultStore].
+type SendFuture struct {
+	msgID string
+	store ResultStore
+	done  chan struct{}
+	once  sync.Once
+
+	mu     sync.RWMutex
+	status SendStatus
+	result *SendResult
+	err    error
+}
+
+// NewSendFuture returns a pending future for msgID. A non-nil store lets the future
+// resolve from records saved by other processes, e.g. to track a message by ID
+// after a restart.
+func NewSendFuture(msgID string, store ResultStore) *SendFuture {
+	return &SendFuture{
+		msgID:  msgID,
+		store:  store,
+		done:   make(chan struct{}),
+		status: SendStatusPending,
+	}
+}
+
+// MsgID returns the ID of the message the future tracks.
+func (f *SendFuture) MsgID() string {
+	return f.msgID
+}
+
+// Done returns a channel that is closed once the outcome is known in-process.
+// Futures that resolve through a ResultStore only close it from Wait or Status.
+func (f *SendFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Status returns the current status, consulting the ResultStore while pending.
+func (f *SendFuture) Status() SendStatus {
+	f.poll(context.Background())
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.status
+}
+
+// Result returns the outcome of the send. It returns an ErrCodeSendPending error
+// while the send has not finished; use Wait to block until it has.
+func (f *SendFuture) Result() (*SendResult, error) {
+	if f.Status() == SendStatusPending {
+		return nil, NewSenderErrorf(ErrCodeSendPending, "message %s is still pending", f.msgID)
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.result, f.err
+}
+
+// Wait blocks until the send finishes or ctx is done and returns its outcome.
+func (f *SendFuture) Wait(ctx context.Context) (*SendResult, error) {
+	var tick <-chan time.Time
+	if f.store != nil {
+		ticker := time.NewTicker(futurePollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if f.poll(ctx) {
+			return f.Result()
+		}
+		select {
+		case <-f.done:
+			return f.Result()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-tick:
+		}
+	}
+}
+
+// resolve records the outcome. Only the first call has an effect.
+func (f *SendFuture) resolve(status SendStatus, result *SendResult, err error) {
+	f.once.Do(func() {
+		f.mu.Lock()
+		f.status, f.result, f.err = status, result, err
+		f.mu.Unlock()
+		close(f.done)
+	})
+}
+
+// poll resolves the future from the ResultStore if a record is available and
+// reports whether the future is resolved.
+func (f *SendFuture) poll(ctx context.Context) bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+	}
+	if f.store == nil {
+		return false
+	}
+	record, err := f.store.Load(ctx, f.msgID)
+	if err != nil || record == nil || record.Status == SendStatusPending {
+		return false
+	}
+	f.resolve(record.Status, record.Result, record.Err())
+	return true
+}
@@ -0,0 +1,183 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/queue"
+	"github.com/shellvon/go-sender/ratelimiter"
+)
+
+func TestSendFuture_ResolvesInProcess(t *testing.T) {
+	pd := core.NewProviderDecorator(&fakeProvider{name: "f1"}, nil, &core.NoOpLogger{})
+	defer pd.Close()
+
+	called := make(chan struct{}, 1)
+	future, err := pd.SendAsync(context.Background(), &fakeMessage{},
+		core.WithSendCallback(func(*core.SendResult, error) { called <- struct{}{} }))
+	if err != nil {
+		t.Fatalf("SendAsync failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = future.Wait(ctx); err != nil {
+		t.Errorf("Wait returned error: %v", err)
+	}
+	if future.Status() != core.SendStatusSucceeded {
+		t.Errorf("expected succeeded, got %s", future.Status())
+	}
+	select {
+	case <-future.Done():
+	default:
+		t.Error("Done channel not closed")
+	}
+	select {
+	case <-called:
+	default:
+		t.Error("user callback not invoked")
+	}
+}
+
+func TestSendFuture_QueueFailure(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	pd := core.NewProviderDecorator(&fakeProvider{name: "f2", sendErr: errors.New("boom")},
+		&core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	future, err := pd.SendAsync(context.Background(), &fakeMessage{})
+	if err != nil {
+		t.Fatalf("SendAsync failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = future.Wait(ctx); err == nil {
+		t.Error("expected send error from Wait")
+	}
+	if future.Status() != core.SendStatusFailed {
+		t.Errorf("expected failed, got %s", future.Status())
+	}
+}
+
+func TestSendFuture_EarlyFailures(t *testing.T) {
+	drained := ratelimiter.NewTokenBucketRateLimiter(0.0001, 1)
+	drained.Allow()
+	rejectHook := func(context.Context, core.Message, *core.SendOptions) error { return errors.New("rejected") }
+
+	cases := []struct {
+		name       string
+		middleware func() *core.SenderMiddleware
+	}{
+		{"rate limited in queue", func() *core.SenderMiddleware {
+			return &core.SenderMiddleware{Queue: queue.NewMemoryQueue[*core.QueueItem](0), RateLimiter: drained}
+		}},
+		{"rate limited in goroutine", func() *core.SenderMiddleware {
+			return &core.SenderMiddleware{RateLimiter: drained}
+		}},
+		{"hook rejected", func() *core.SenderMiddleware {
+			m := &core.SenderMiddleware{}
+			m.UseBeforeHook(rejectHook)
+			return m
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pd := core.NewProviderDecorator(&fakeProvider{name: "f-early"}, tc.middleware(), &core.NoOpLogger{})
+			defer pd.Close()
+
+			called := make(chan error, 1)
+			future, err := pd.SendAsync(context.Background(), &fakeMessage{},
+				core.WithSendCallback(func(_ *core.SendResult, err error) { called <- err }))
+			if err != nil {
+				t.Fatalf("SendAsync failed: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			if _, err = future.Wait(ctx); err == nil || errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected the send error from Wait, got %v", err)
+			}
+			if future.Status() != core.SendStatusFailed {
+				t.Errorf("expected failed, got %s", future.Status())
+			}
+			select {
+			case err = <-called:
+				if err == nil {
+					t.Error("callback received no error")
+				}
+			default:
+				t.Error("callback not invoked")
+			}
+		})
+	}
+}
+
+func TestSendFuture_PendingAndCancelled(t *testing.T) {
+	pd := core.NewProviderDecorator(&fakeProvider{name: "f3"}, nil, &core.NoOpLogger{})
+	defer pd.Close()
+
+	future, _ := pd.SendAsync(context.Background(), &fakeMessage{}, core.WithSendDelay(time.Hour))
+	if future.Status() != core.SendStatusPending {
+		t.Errorf("expected pending, got %s", future.Status())
+	}
+	if _, err := future.Result(); core.GetSenderErrorCode(err) != core.ErrCodeSendPending {
+		t.Errorf("expected pending error, got %v", err)
+	}
+
+	_ = pd.Cancel(context.Background(), future.MsgID())
+	if future.Status() != core.SendStatusCancelled {
+		t.Errorf("expected cancelled, got %s", future.Status())
+	}
+}
+
+func TestSendFuture_ResultStore(t *testing.T) {
+	store := core.NewMemoryResultStore(time.Minute)
+
+	// A worker in another process saves the outcome; the producer only knows the ID.
+	future := core.NewSendFuture("remote-1", store)
+	if future.Status() != core.SendStatusPending {
+		t.Fatalf("expected pending, got %s", future.Status())
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = store.Save(context.Background(), &core.SendRecord{
+			MsgID:     "remote-1",
+			Status:    core.SendStatusExpired,
+			ErrorCode: core.ErrCodeMessageExpired,
+			Error:     "expired",
+		})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := future.Wait(ctx)
+	if core.GetSenderErrorCode(err) != core.ErrCodeMessageExpired {
+		t.Errorf("expected expired error, got %v", err)
+	}
+	if future.Status() != core.SendStatusExpired {
+		t.Errorf("expected expired, got %s", future.Status())
+	}
+}
+
+func TestProviderDecorator_SavesToResultStore(t *testing.T) {
+	store := core.NewMemoryResultStore(0)
+	pd := core.NewProviderDecorator(&fakeProvider{name: "f4"},
+		&core.SenderMiddleware{ResultStore: store}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	future, _ := pd.SendAsync(context.Background(), &fakeMessage{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _ = future.Wait(ctx)
+
+	record, err := store.Load(context.Background(), "id")
+	if err != nil || record == nil {
+		t.Fatalf("expected stored record, got %v, %v", record, err)
+	}
+	if record.Status != core.SendStatusSucceeded || record.Provider != "f4" {
+		t.Errorf("unexpected record: %+v", record)
+	}
+}
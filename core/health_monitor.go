@@ -0,0 +1,191 @@
+package core
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of a HealthMonitor.
+const (
+	DefaultProbeInterval = time.Minute
+	DefaultProbeTimeout  = 10 * time.Second
+)
+
+// HealthMonitor probes the accounts of the registered [Prober] providers in the
+// background and caches the results. Probing records the health of each account
+// in its provider's BaseConfig, so selection avoids the failing accounts between
+// sends, not only after a send failed.
+type HealthMonitor struct {
+	interval   time.Duration
+	timeout    time.Duration
+	httpClient *http.Client
+
+	mu      sync.RWMutex
+	probers map[string]Prober
+	results map[string]*HealthCheck
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// HealthMonitorOption configures a HealthMonitor.
+type HealthMonitorOption func(*HealthMonitor)
+
+// WithProbeInterval sets how often providers are probed, DefaultProbeInterval by default.
+func WithProbeInterval(interval time.Duration) HealthMonitorOption {
+	return func(m *HealthMonitor) {
+		if interval > 0 {
+			m.interval = interval
+		}
+	}
+}
+
+// WithProbeTimeout bounds each round of probes of a provider, DefaultProbeTimeout by default.
+func WithProbeTimeout(timeout time.Duration) HealthMonitorOption {
+	return func(m *HealthMonitor) {
+		if timeout > 0 {
+			m.timeout = timeout
+		}
+	}
+}
+
+// WithProbeHTTPClient sets the HTTP client of the probes of HTTP providers.
+func WithProbeHTTPClient(client *http.Client) HealthMonitorOption {
+	return func(m *HealthMonitor) {
+		m.httpClient = client
+	}
+}
+
+// NewHealthMonitor creates a HealthMonitor. Register providers and call Start.
+func NewHealthMonitor(opts ...HealthMonitorOption) *HealthMonitor {
+	m := &HealthMonitor{
+		interval: DefaultProbeInterval,
+		timeout:  DefaultProbeTimeout,
+		probers:  make(map[string]Prober),
+		results:  make(map[string]*HealthCheck),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Register adds a provider to probe under name, replacing any with the same name.
+func (m *HealthMonitor) Register(name string, prober Prober) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.probers[name] = prober
+	delete(m.results, name)
+}
+
+// Unregister stops probing the provider registered under name.
+func (m *HealthMonitor) Unregister(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.probers, name)
+	delete(m.results, name)
+}
+
+// Start probes every provider right away, then every interval until Close.
+// Calling it again has no effect.
+func (m *HealthMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started || m.closed() {
+		return
+	}
+	m.started = true
+	go m.run()
+}
+
+func (m *HealthMonitor) run() {
+	defer close(m.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-m.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.CheckNow(ctx)
+		select {
+		case <-ticker.C:
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// CheckNow probes every provider concurrently and caches the results.
+func (m *HealthMonitor) CheckNow(ctx context.Context) {
+	m.mu.RLock()
+	probers := maps.Clone(m.probers)
+	m.mu.RUnlock()
+
+	opts := &ProviderSendOptions{HTTPClient: m.httpClient}
+	var wg sync.WaitGroup
+	for name, prober := range probers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, m.timeout)
+			defer cancel()
+			check := HealthFromProbes(prober.Probe(probeCtx, opts))
+			if ctx.Err() != nil {
+				return // stopped: the probes were cut short
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if _, registered := m.probers[name]; registered {
+				m.results[name] = check
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Health returns the cached result of the last probes of the provider
+// registered under name, or false if it was not probed yet.
+func (m *HealthMonitor) Health(name string) (*HealthCheck, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	check, ok := m.results[name]
+	return check, ok
+}
+
+// closed reports whether Close was called.
+func (m *HealthMonitor) closed() bool {
+	select {
+	case <-m.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the background probes and waits for the running ones to return.
+func (m *HealthMonitor) Close() error {
+	m.mu.Lock()
+	if m.closed() {
+		m.mu.Unlock()
+		return nil
+	}
+	close(m.stop)
+	started := m.started
+	m.mu.Unlock()
+	if started {
+		<-m.done
+	}
+	return nil
+}
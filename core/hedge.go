@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Defaults of HedgePolicy.
+const (
+	DefaultHedgeMaxRatio   = 0.1 // share of sends that may be hedged
+	DefaultHedgeMinSamples = 20  // latencies needed before Percentile applies
+)
+
+const hedgeLatencySamples = 128
+
+// HedgePolicy trades cost for tail latency, e.g. for verification codes: when an
+// attempt has not finished after a delay, a second one is started in parallel on
+// another account of the provider, the first to succeed wins and the other is
+// canceled. A send is hedged at most once per attempt; the retries of a
+// RetryPolicy are hedged separately. SendResult.Attempts lists both attempts and
+// SendResult.Winner tells which one succeeded.
+//
+// Both attempts may reach the vendor, so a message is delivered twice unless
+// the vendor deduplicates it. Sends are therefore only hedged when they opt in
+// with WithSendHedge. The attempts of a send share an idempotency key, which
+// providers whose vendor deduplicates requests get through GetIdempotencyKey.
+type HedgePolicy struct {
+	// Delay is how long an attempt may run before it is hedged. With Percentile,
+	// it is the minimum delay. Sends are not hedged when no delay is known.
+	Delay time.Duration
+	// Percentile, between 0 and 1 (e.g. 0.95), hedges attempts that run longer
+	// than this percentile of the latencies of the recent successful attempts of
+	// the provider. Delay is used until MinSamples latencies were seen.
+	Percentile float64
+	// MinSamples is the number of latencies Percentile needs, DefaultHedgeMinSamples by default.
+	MinSamples int
+	// MaxRatio caps the hedges of the provider at a share of its attempts over the
+	// last DefaultRetryBudgetWindow, so that a slow vendor does not double the
+	// traffic. DefaultHedgeMaxRatio by default.
+	MaxRatio float64
+	// IdempotencyKey returns the key of a message, its MsgID by default. Messages
+	// with an empty key are not hedged, nor are messages whose key is already
+	// being sent by the provider, e.g. a message delivered twice by a queue.
+	IdempotencyKey func(Message) string
+}
+
+// hedger applies a HedgePolicy to the attempts of one provider.
+type hedger struct {
+	HedgePolicy
+
+	budget *retryBudget
+
+	mu        sync.Mutex
+	latencies [hedgeLatencySamples]time.Duration
+	samples   int
+	inflight  map[string]struct{}
+}
+
+func newHedger(policy *HedgePolicy) *hedger {
+	if policy == nil {
+		return nil
+	}
+	h := &hedger{HedgePolicy: *policy, inflight: make(map[string]struct{})}
+	if h.MinSamples <= 0 {
+		h.MinSamples = DefaultHedgeMinSamples
+	}
+	if h.MaxRatio <= 0 {
+		h.MaxRatio = DefaultHedgeMaxRatio
+	}
+	h.budget = newRetryBudget(&RetryBudget{Ratio: h.MaxRatio})
+	return h
+}
+
+// acquire returns the idempotency key of message and the delay before hedging
+// it, or false when the attempt is not to be hedged. The key must be released.
+func (h *hedger) acquire(message Message) (string, time.Duration, bool) {
+	key := message.MsgID()
+	if h.IdempotencyKey != nil {
+		key = h.IdempotencyKey(message)
+	}
+	if key == "" {
+		return "", 0, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delay := h.delay()
+	if delay <= 0 {
+		return "", 0, false
+	}
+	if _, busy := h.inflight[key]; busy {
+		return "", 0, false
+	}
+	h.inflight[key] = struct{}{}
+	h.budget.recordAttempt()
+	return key, delay, true
+}
+
+// release ends the hedged attempt of key.
+func (h *hedger) release(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.inflight, key)
+}
+
+// delay returns the delay before hedging; h.mu must be held.
+func (h *hedger) delay() time.Duration {
+	n := min(h.samples, hedgeLatencySamples)
+	if h.Percentile <= 0 || h.Percentile >= 1 || n < h.MinSamples {
+		return h.Delay
+	}
+	latencies := slices.Clone(h.latencies[:n])
+	slices.Sort(latencies)
+	return max(latencies[int(h.Percentile*float64(n-1))], h.Delay)
+}
+
+// observe records the latency of a successful attempt.
+func (h *hedger) observe(err error, latency time.Duration) {
+	if h == nil || err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latencies[h.samples%hedgeLatencySamples] = latency
+	h.samples++
+}
+
+// hedgeRun is an attempt of a hedged send.
+type hedgeRun struct {
+	sel   *accountSelection
+	start time.Time
+	hedge bool
+}
+
+// hedgeOutcome is the outcome of a hedgeRun.
+type hedgeOutcome struct {
+	run     *hedgeRun
+	result  *SendResult
+	err     error
+	latency time.Duration
+}
+
+// hedgedAttempt makes one attempt of a send on the account chosen by fo and, if
+// it has not finished after delay, a hedge on another account. The hedge is
+// skipped when the attempt has not selected its account yet or no other account
+// is eligible. It returns the first success, or the last failure when both fail;
+// the attempt that lost is canceled and not recorded.
+func (pd *ProviderDecorator) hedgedAttempt(
+	ctx context.Context,
+	message Message,
+	opts *SendOptions,
+	fo *failover,
+	delay time.Duration,
+) (*SendResult, error) {
+	// Canceling ctx on return stops the attempt that lost.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make(chan hedgeOutcome, 2)
+	start := func(route *RouteInfo, hedge bool) *hedgeRun {
+		actx, sel := withSelectionRecorder(ctx)
+		run := &hedgeRun{sel: sel, start: time.Now(), hedge: hedge}
+		go func() {
+			result, err := pd.executeSend(actx, message, opts, route)
+			outcomes <- hedgeOutcome{run: run, result: result, err: err, latency: time.Since(run.start)}
+		}()
+		return run
+	}
+
+	runs := []*hedgeRun{start(fo.route(), false)}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var last hedgeOutcome
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			first, _ := runs[0].sel.get()
+			route := fo.hedgeRoute(first)
+			if route == nil || !pd.hedger.budget.tryRetry() {
+				continue
+			}
+			if pd.logger != nil {
+				_ = pd.logger.Log(LevelDebug, "message", "hedging slow attempt",
+					"message_id", message.MsgID(), "account", first, "delay", delay.String())
+			}
+			runs = append(runs, start(route, true))
+			pending++
+		case o := <-outcomes:
+			pending--
+			if o.run.hedge && errors.Is(o.err, errNoOtherAccount) {
+				// No account to hedge on: the hedge was never made.
+				pd.hedger.budget.refundRetry()
+				continue
+			}
+			fo.record(o.run.sel, o.err, o.latency, o.run.hedge)
+			pd.hedger.observe(o.err, o.latency)
+			if o.err == nil {
+				return o.result, nil
+			}
+			last = o
+		}
+	}
+	return last.result, last.err
+}
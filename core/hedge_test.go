@@ -0,0 +1,161 @@
+package core_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// newHedgedProvider returns an accountProvider with two accounts whose n-th send
+// takes latency[n].
+func newHedgedProvider(latency ...time.Duration) *accountProvider {
+	p := newAccountProvider(account("aliyun-1", "aliyun"), account("tencent-1", "tencent"))
+	p.latency = latency
+	return p
+}
+
+func TestHedge_FasterAttemptWins(t *testing.T) {
+	p := newHedgedProvider(time.Second)
+	pd := core.NewProviderDecorator(p, &core.SenderMiddleware{
+		Hedge: &core.HedgePolicy{Delay: 20 * time.Millisecond, MaxRatio: 1},
+	}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	start := time.Now()
+	result, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendHedge(true))
+	if err != nil {
+		t.Fatalf("expected the hedge to succeed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the hedge to answer early, took %v", elapsed)
+	}
+	if len(result.Attempts) != 1 || result.Winner != 0 || !result.Attempts[0].Hedge {
+		t.Fatalf("expected only the winning hedge to be recorded, got %+v (winner %d)", result.Attempts, result.Winner)
+	}
+	selected, keys := p.sends()
+	if len(selected) != 2 || selected[0] == selected[1] || result.Attempts[0].Account != selected[1] {
+		t.Errorf("expected the hedge to run on the other account, got %v for %+v", selected, result.Attempts)
+	}
+	if keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("expected both attempts to share an idempotency key, got %q", keys)
+	}
+	deadline := time.Now().Add(time.Second)
+	for p.canceled.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if p.canceled.Load() != 1 {
+		t.Error("expected the slow attempt to see its context canceled")
+	}
+}
+
+func TestHedge_FastAttemptIsNotHedged(t *testing.T) {
+	p := newHedgedProvider()
+	pd := core.NewProviderDecorator(p, &core.SenderMiddleware{
+		Hedge: &core.HedgePolicy{Delay: 100 * time.Millisecond},
+	}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	result, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendHedge(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Attempts) != 1 || result.Attempts[0].Hedge || result.Winner != 0 {
+		t.Errorf("expected a single attempt, got %+v", result.Attempts)
+	}
+}
+
+func TestHedge_MaxRatioCapsHedges(t *testing.T) {
+	p := newHedgedProvider(slices.Repeat([]time.Duration{30 * time.Millisecond}, 16)...)
+	pd := core.NewProviderDecorator(p, &core.SenderMiddleware{
+		Hedge: &core.HedgePolicy{Delay: time.Millisecond, MaxRatio: 0.25},
+	}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	for range 8 {
+		if _, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendHedge(true)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if selected, _ := p.sends(); len(selected)-8 != 2 {
+		t.Errorf("expected a quarter of 8 sends to be hedged, got %d", len(selected)-8)
+	}
+}
+
+func TestHedge_OnlyOptedInSends(t *testing.T) {
+	p := newHedgedProvider(50 * time.Millisecond)
+	pd := core.NewProviderDecorator(p, &core.SenderMiddleware{
+		Hedge: &core.HedgePolicy{Delay: time.Millisecond},
+	}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	result, err := pd.Send(context.Background(), &fakeMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Attempts) != 0 {
+		t.Errorf("expected a send that did not opt in not to be hedged, got %+v", result.Attempts)
+	}
+}
+
+func TestHedge_OnlyOnAnotherAccount(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*accountProvider)
+		opts  []core.SendOption
+	}{
+		{name: "pinned", opts: []core.SendOption{core.WithSendAccount("aliyun-1")}},
+		{name: "single account", setup: func(p *accountProvider) { p.config.Delete("tencent-1") }},
+		{name: "account not selected yet", setup: func(p *accountProvider) { p.selectDelay = 30 * time.Millisecond }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newHedgedProvider(30 * time.Millisecond)
+			if tt.setup != nil {
+				tt.setup(p)
+			}
+			pd := core.NewProviderDecorator(p, &core.SenderMiddleware{
+				Hedge: &core.HedgePolicy{Delay: 5 * time.Millisecond, MaxRatio: 1},
+			}, &core.NoOpLogger{})
+			defer pd.Close()
+
+			result, err := pd.Send(context.Background(), &fakeMessage{}, append(tt.opts, core.WithSendHedge(true))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Attempts) != 1 || result.Attempts[0].Hedge {
+				t.Errorf("expected a single attempt, got %+v", result.Attempts)
+			}
+			if selected, _ := p.sends(); len(selected) != 1 {
+				t.Errorf("expected one call to the vendor, got %v", selected)
+			}
+		})
+	}
+}
+
+func TestHedge_NoOtherAccountKeepsBudget(t *testing.T) {
+	p := newHedgedProvider(30*time.Millisecond, 30*time.Millisecond)
+	p.config.Delete("tencent-1")
+	pd := core.NewProviderDecorator(p, &core.SenderMiddleware{
+		Hedge: &core.HedgePolicy{Delay: 5 * time.Millisecond, MaxRatio: 0.5},
+	}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	if _, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendHedge(true)); err != nil {
+		t.Fatal(err)
+	}
+	// With a second account, the hedge that could not be made above is still in
+	// the budget: two sends at a ratio of 0.5 allow one hedge.
+	if err := p.config.Add(&mockSelectable{name: "tencent-1", weight: 1, enabled: true, subType: "tencent"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendHedge(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Attempts) != 1 || !result.Attempts[0].Hedge {
+		t.Errorf("expected the second send to be hedged, got %+v", result.Attempts)
+	}
+}
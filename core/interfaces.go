@@ -91,6 +91,8 @@ type ProviderHealth struct {
 	LastCheck    time.Time     `json:"last_check"`
 	ErrorRate    float64       `json:"error_rate"`
 	Latency      time.Duration `json:"latency"`
+	// Accounts holds the probe result of each account, for providers implementing Prober.
+	Accounts map[string]*HealthCheck `json:"accounts,omitempty"`
 }
 
 // SenderHealth represents the overall health status of the sender.
@@ -182,6 +184,16 @@ func (q *QueueItem) GetScheduledAt() *time.Time {
 	return q.ScheduledAt
 }
 
+// GetID implements the Identifiable interface for QueueItem.
+func (q *QueueItem) GetID() string {
+	return q.ID
+}
+
+// Identifiable is an optional interface for queue items that carry a unique ID.
+type Identifiable interface {
+	GetID() string
+}
+
 // Queue is an interface for a message queuing system.
 type Queue interface {
 	// Enqueue adds an item to the queue for immediate processing.
@@ -196,6 +208,78 @@ type Queue interface {
 	Close() error
 }
 
+// QueueFilter selects queue items for inspection and management.
+// Zero-valued fields do not constrain the result.
+type QueueFilter struct {
+	// Provider matches [QueueItem.Provider] exactly.
+	Provider string
+	// MinPriority and MaxPriority bound [QueueItem.Priority] inclusively when non-nil.
+	MinPriority *int
+	MaxPriority *int
+	// ScheduledAfter and ScheduledBefore bound [QueueItem.ScheduledAt]. Items without
+	// a schedule never match when either bound is set.
+	ScheduledAfter  time.Time
+	ScheduledBefore time.Time
+	// Limit caps the number of returned items; 0 means no limit.
+	Limit int
+}
+
+// Match reports whether item satisfies the filter.
+func (f QueueFilter) Match(item *QueueItem) bool {
+	if item == nil {
+		return false
+	}
+	if f.Provider != "" && item.Provider != f.Provider {
+		return false
+	}
+	if f.MinPriority != nil && item.Priority < *f.MinPriority {
+		return false
+	}
+	if f.MaxPriority != nil && item.Priority > *f.MaxPriority {
+		return false
+	}
+	if !f.ScheduledAfter.IsZero() || !f.ScheduledBefore.IsZero() {
+		if item.ScheduledAt == nil {
+			return false
+		}
+		if !f.ScheduledAfter.IsZero() && item.ScheduledAt.Before(f.ScheduledAfter) {
+			return false
+		}
+		if !f.ScheduledBefore.IsZero() && !item.ScheduledAt.Before(f.ScheduledBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+// QueueManager is an optional interface for queues that support inspection and
+// management of waiting items. Items are returned in the order they would be
+// dequeued: ready items first, then scheduled items by due time.
+type QueueManager interface {
+	// List returns the items matching filter.
+	List(ctx context.Context, filter QueueFilter) ([]*QueueItem, error)
+	// Peek returns the next item matching filter without removing it.
+	Peek(ctx context.Context, filter QueueFilter) (*QueueItem, error)
+	// Get returns the item with the given ID.
+	Get(ctx context.Context, id string) (*QueueItem, error)
+	// Remove atomically removes and returns the item with the given ID that
+	// matches filter, e.g. the item of one provider in a shared queue.
+	Remove(ctx context.Context, id string, filter QueueFilter) (*QueueItem, error)
+	// Reschedule moves the item with the given ID to a new scheduled time.
+	Reschedule(ctx context.Context, id string, at time.Time) error
+	// Purge removes every item of the given provider and returns how many were removed.
+	Purge(ctx context.Context, provider string) (int, error)
+}
+
+// EvictionNotifier is an optional interface for queues that may evict items that were
+// already accepted, e.g. to make room for newer items under backpressure.
+// ProviderDecorator registers a handler so that the async Callback and metrics
+// learn about evicted and purged messages, and unregisters it when closed.
+type EvictionNotifier interface {
+	// OnEvict registers fn and returns a function that unregisters it.
+	OnEvict(fn func(item *QueueItem, err error)) (unsubscribe func())
+}
+
 // PerformanceMetrics represents detailed performance metrics.
 type PerformanceMetrics struct {
 	SendLatency         time.Duration `json:"send_latency"`
@@ -210,7 +294,11 @@ type PerformanceMetrics struct {
 
 // MetricsData represents structured metrics data.
 type MetricsData struct {
-	Provider     string                 `json:"provider"`
+	Provider string `json:"provider"`
+	// Account and SubType identify the account that served a sent operation,
+	// when the provider selects accounts through BaseConfig.
+	Account      string                 `json:"account,omitempty"`
+	SubType      string                 `json:"sub_type,omitempty"`
 	Success      bool                   `json:"success"`
 	Duration     time.Duration          `json:"duration"`
 	Operation    string                 `json:"operation,omitempty"`
@@ -228,6 +316,22 @@ type MetricsCollector interface {
 	RecordSendResult(data MetricsData)
 }
 
+// ProviderStats summarises the recent sends of a provider.
+type ProviderStats struct {
+	Sends     int64         `json:"sends"`
+	ErrorRate float64       `json:"error_rate"`
+	Latency   time.Duration `json:"latency"`
+}
+
+// ProviderStatsReporter is implemented by metrics collectors that keep recent
+// statistics per provider. Sender.HealthCheck uses them to fill
+// ProviderHealth.ErrorRate and Latency.
+type ProviderStatsReporter interface {
+	// ProviderStats returns the statistics of the provider named provider, or
+	// false when it sent nothing recently.
+	ProviderStats(provider string) (ProviderStats, bool)
+}
+
 // CircuitBreaker is an interface for implementing the Circuit Breaker pattern.
 type CircuitBreaker interface {
 	// Execute attempts to run a function, applying circuit breaker logic.
@@ -260,9 +364,13 @@ func (s *DefaultSendOptionsSerializer) Serialize(opts *SendOptions) ([]byte, err
 		Timeout:               int64(opts.Timeout),
 		DisableCircuitBreaker: opts.DisableCircuitBreaker,
 		DisableRateLimiter:    opts.DisableRateLimiter,
+		Hedge:                 opts.Hedge,
 		Metadata:              opts.Metadata,
 		AccountName:           opts.AccountName,
 		StrategyName:          opts.StrategyName,
+		ExpireAt:              opts.ExpireAt,
+		RoutingKey:            opts.RoutingKey,
+		RoutingKeyField:       opts.RoutingKeyField,
 	}
 
 	// Convert RetryPolicy to serializable format if present
@@ -294,9 +402,13 @@ func (s *DefaultSendOptionsSerializer) Deserialize(data []byte) (*SendOptions, e
 		Timeout:               time.Duration(dataStruct.Timeout),
 		DisableCircuitBreaker: dataStruct.DisableCircuitBreaker,
 		DisableRateLimiter:    dataStruct.DisableRateLimiter,
+		Hedge:                 dataStruct.Hedge,
 		Metadata:              dataStruct.Metadata,
 		AccountName:           dataStruct.AccountName,
 		StrategyName:          dataStruct.StrategyName,
+		ExpireAt:              dataStruct.ExpireAt,
+		RoutingKey:            dataStruct.RoutingKey,
+		RoutingKeyField:       dataStruct.RoutingKeyField,
 	}
 
 	// Convert serializable RetryPolicy back to RetryPolicy if present
@@ -319,9 +431,13 @@ type sendOptionsData struct {
 	Timeout               int64                  `json:"timeout_ns"`
 	DisableCircuitBreaker bool                   `json:"disable_circuit_breaker"`
 	DisableRateLimiter    bool                   `json:"disable_rate_limiter"`
+	Hedge                 bool                   `json:"hedge,omitempty"`
 	Metadata              map[string]interface{} `json:"metadata,omitempty"`
 	AccountName           string                 `json:"account_name,omitempty"`
 	StrategyName          string                 `json:"strategy_name,omitempty"`
+	ExpireAt              *time.Time             `json:"expire_at,omitempty"`
+	RoutingKey            string                 `json:"routing_key,omitempty"`
+	RoutingKeyField       string                 `json:"routing_key_field,omitempty"`
 	// Serializable retry policy (without Filter function)
 	RetryPolicy *serializableRetryPolicy `json:"retry_policy,omitempty"`
 }
@@ -346,6 +462,10 @@ const (
 	// OperationDequeue represents the dequeue operation.
 	OperationDequeue = "dequeue"
 	OperationSent    = "sent"
+	// OperationEvict represents a queued message evicted before it was processed.
+	OperationEvict = "evict"
+	// OperationExpire represents a message dropped because its ExpireAt deadline passed.
+	OperationExpire = "expire"
 )
 
 // SendResult represents the result of a send operation.
@@ -354,4 +474,15 @@ type SendResult struct {
 	StatusCode int         // HTTP状态码
 	Headers    http.Header // 响应头
 	Body       []byte      // 响应体
+	// Attempts lists every attempt of a send made with a retry or hedge policy,
+	// in the order they finished.
+	Attempts []SendAttempt
+	// Winner is the index in Attempts of the attempt that succeeded, -1 when none
+	// did. It is only meaningful when Attempts is set.
+	Winner int
+	// Cost is the estimated price of the send, for messages that implement CostEstimator.
+	Cost *CostEstimate
+	// Parts holds the results of the parts of a message that the provider split
+	// and sent separately, e.g. SMS to recipients in several regions.
+	Parts []*SendResult
 }
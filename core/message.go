@@ -20,6 +20,13 @@ type SubProviderAware interface {
 	GetSubProvider() string
 }
 
+// CategoryAware is an optional interface for messages that carry a business category,
+// for example an SMS verification code versus a marketing campaign. Queues use it to
+// route messages into scheduling lanes.
+type CategoryAware interface {
+	GetCategory() string
+}
+
 // BaseMessage is the base message structure.
 type BaseMessage struct {
 	msgID        string       `json:"-"` // 消息ID，不序列化
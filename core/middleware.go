@@ -6,11 +6,25 @@ import (
 
 // SenderMiddleware holds configurations for sender middlewares.
 type SenderMiddleware struct {
-	RateLimiter    RateLimiter
-	Retry          *RetryPolicy
+	RateLimiter RateLimiter
+	// RateLimitWait, when set, makes sends wait for RateLimiter instead of failing.
+	RateLimitWait *RateLimitWait
+	Retry         *RetryPolicy
+	// RetryBudget, when set, caps the retries of each provider; see [RetryBudget].
+	RetryBudget *RetryBudget
+	// Hedge, when set, races slow attempts against a second one; see [HedgePolicy].
+	Hedge          *HedgePolicy
 	Queue          Queue
 	CircuitBreaker CircuitBreaker
 	Metrics        MetricsCollector
+	// DeadLetter, when set, receives messages dropped because they expired.
+	DeadLetter Queue
+	// ResultStore, when set, receives the outcome of every async send so that
+	// [SendFuture]s can resolve across processes.
+	ResultStore ResultStore
+	// Tracer, when set, traces sends and propagates their trace context through
+	// the queue; see [Tracer].
+	Tracer Tracer
 
 	// beforeHooks are executed BEFORE each send. Returning a non-nil error aborts the send.
 	beforeHooks []BeforeHook
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand/v2"
 	"net/http"
@@ -169,6 +170,11 @@ type SendOptions struct {
 	// DelayUntil specifies the exact time until which sending should be delayed.
 	// This field is only effective when `Async` is set to `true`.
 	DelayUntil *time.Time
+	// ExpireAt is the deadline after which the message is no longer worth sending,
+	// e.g. a verification code stuck in the queue during an outage. It is checked
+	// when the message is dequeued and before each retry attempt; expired messages
+	// fail with ErrCodeMessageExpired and are moved to the dead-letter queue if one is configured.
+	ExpireAt *time.Time
 	// Timeout specifies the send operation timeout.
 	Timeout time.Duration
 	// Metadata holds additional metadata for the message.
@@ -179,10 +185,19 @@ type SendOptions struct {
 	AccountName string
 	// StrategyName overrides the default strategy for this single send (e.g. "round_robin").
 	StrategyName string
+	// RoutingKey is the key keyed strategies such as "consistent_hash" route by.
+	// When empty, the Metadata value named by RoutingKeyField is used, and then the
+	// message's own key if it implements RoutingKeyer (e.g. its single recipient).
+	RoutingKey string
+	// RoutingKeyField names the Metadata entry used as routing key, e.g. "tenant_id".
+	RoutingKeyField string
 	// DisableCircuitBreaker indicates whether to disable the circuit breaker middleware for this send.
 	DisableCircuitBreaker bool
 	// DisableRateLimiter indicates whether to disable the rate limiter middleware for this send.
 	DisableRateLimiter bool
+	// Hedge lets the hedging policy of the provider, if any, race this send; see
+	// HedgePolicy. Only set it when a duplicate delivery is harmless.
+	Hedge bool
 	// Callback is an optional function that will be executed after the message
 	// has been fully processed (either successfully sent or failed after all retries).
 	// This callback is primarily effective for local/in-memory queue processing
@@ -236,6 +251,9 @@ type RetryPolicy struct {
 	BackoffFactor float64
 	// Filter is a custom filter function to determine if a retry should occur.
 	Filter RetryFilter
+	// Failover controls which account later attempts use. Nil uses the default:
+	// each failed account is excluded for the remaining attempts.
+	Failover *FailoverPolicy
 	// currentAttempt tracks the internal state for managing retry attempts.
 	currentAttempt int
 	// mu protects the currentAttempt field for concurrent access.
@@ -290,7 +308,15 @@ func (r *RetryPolicy) ShouldRetry(attempt int, err error) bool {
 //   - For exponential backoff, the factor should be raised to the power of the attempt number.
 //     So, for attempt 0, delay = initialDelay * backoffFactor^0 = initialDelay.
 //   - For attempt 1, delay = initialDelay * backoffFactor^1. And so on.
-func (r *RetryPolicy) NextDelay(attempt int, _ error) time.Duration {
+//
+// When err carries a delay suggested by the vendor (see RetryAfter), that delay is
+// used instead, even beyond MaxDelay: retrying earlier would only be throttled
+// again. The sender then only retries if the message deadline allows such a wait.
+func (r *RetryPolicy) NextDelay(attempt int, err error) time.Duration {
+	if hint, ok := RetryAfter(err); ok {
+		return hint
+	}
+
 	calculatedDelay := time.Duration(float64(r.InitialDelay) * math.Pow(r.BackoffFactor, float64(attempt)))
 
 	// Apply full jitter using the package-level default random number generator, which is concurrency safe.
@@ -345,6 +371,13 @@ func WithRetryFilter(filter RetryFilter) RetryOption {
 	}
 }
 
+// WithRetryFailover sets the account failover policy for the policy.
+func WithRetryFailover(failover FailoverPolicy) RetryOption {
+	return func(c *RetryPolicy) {
+		c.Failover = &failover
+	}
+}
+
 // DefaultRetryFilter creates a default retry filter that uses retryable errors and an optional classifier fallback.
 func DefaultRetryFilter(retryableErrors []error, fallbackToClassifier bool) RetryFilter {
 	return func(_ int, err error) bool {
@@ -401,6 +434,21 @@ func WithSendDelay(delay time.Duration) SendOption {
 	}
 }
 
+// WithSendTTL sets how long the message stays valid, counted from now.
+func WithSendTTL(ttl time.Duration) SendOption {
+	return func(opts *SendOptions) {
+		expireAt := time.Now().Add(ttl)
+		opts.ExpireAt = &expireAt
+	}
+}
+
+// WithSendExpireAt sets the absolute deadline after which the message is dropped instead of sent.
+func WithSendExpireAt(expireAt time.Time) SendOption {
+	return func(opts *SendOptions) {
+		opts.ExpireAt = &expireAt
+	}
+}
+
 // WithSendTimeout sets a timeout for the send operation.
 func WithSendTimeout(timeout time.Duration) SendOption {
 	return func(opts *SendOptions) {
@@ -432,6 +480,20 @@ func WithSendStrategy(st StrategyType) SendOption {
 	}
 }
 
+// WithSendRoutingKey sets the routing key of the send, e.g. a user or tenant ID.
+func WithSendRoutingKey(key string) SendOption {
+	return func(opts *SendOptions) {
+		opts.RoutingKey = key
+	}
+}
+
+// WithSendRoutingKeyField routes by the value of the named Metadata entry.
+func WithSendRoutingKeyField(field string) SendOption {
+	return func(opts *SendOptions) {
+		opts.RoutingKeyField = field
+	}
+}
+
 // WithSendDisableCircuitBreaker sets the DisableCircuitBreaker option.
 func WithSendDisableCircuitBreaker(disable bool) SendOption {
 	return func(o *SendOptions) {
@@ -446,6 +508,13 @@ func WithSendDisableRateLimiter(disable bool) SendOption {
 	}
 }
 
+// WithSendHedge sets the Hedge option.
+func WithSendHedge(hedge bool) SendOption {
+	return func(o *SendOptions) {
+		o.Hedge = hedge
+	}
+}
+
 // WithSendCallback sets the callback function to be executed after message processing.
 func WithSendCallback(callback func(*SendResult, error)) SendOption {
 	return func(o *SendOptions) {
@@ -491,9 +560,10 @@ var defaultSerializer SendOptionsSerializer = &DefaultSendOptionsSerializer{}
 
 // serializeSendOptions serializes relevant SendOptions fields to JSON for storage in Metadata.
 // It logs a warning if the key already exists to alert about potential user conflicts.
-// It also preserves context information (like specified item names) for queue recovery.
+// It also preserves context information (like specified item names and the trace
+// context) for queue recovery.
 func serializeSendOptions(
-	_ context.Context,
+	ctx context.Context,
 	opts *SendOptions,
 	metadata map[string]interface{},
 ) (map[string]interface{}, error) {
@@ -507,6 +577,7 @@ func serializeSendOptions(
 		return nil, NewSenderError(ErrCodeQueueSerializationFailed, "failed to serialize SendOptions", err)
 	}
 	metadata[sendOptionsMetadataKey] = data
+	injectTrace(ctx, metadata)
 	return metadata, nil
 }
 
@@ -522,6 +593,7 @@ func deserializeSendOptions(
 	if metadata == nil {
 		return ctx, opts, nil
 	}
+	ctx = extractTrace(ctx, metadata)
 
 	// After deserialization we'll inject route info below.
 	data, ok := metadata[sendOptionsMetadataKey]
@@ -543,18 +615,52 @@ func deserializeSendOptions(
 	opts.Timeout = deserializedOpts.Timeout
 	opts.DisableCircuitBreaker = deserializedOpts.DisableCircuitBreaker
 	opts.DisableRateLimiter = deserializedOpts.DisableRateLimiter
+	opts.Hedge = deserializedOpts.Hedge
 	opts.RetryPolicy = deserializedOpts.RetryPolicy
 	opts.AccountName = deserializedOpts.AccountName
 	opts.StrategyName = deserializedOpts.StrategyName
+	opts.ExpireAt = deserializedOpts.ExpireAt
+	opts.RoutingKey = deserializedOpts.RoutingKey
+	opts.RoutingKeyField = deserializedOpts.RoutingKeyField
 
 	// Rebuild route info for ctx
-	if opts.AccountName != "" || opts.StrategyName != "" {
-		ctx = WithRoute(ctx, &RouteInfo{AccountName: opts.AccountName, StrategyType: StrategyType(opts.StrategyName)})
+	if opts.AccountName != "" || opts.StrategyName != "" || opts.RoutingKey != "" {
+		ctx = WithRoute(ctx, &RouteInfo{
+			AccountName:  opts.AccountName,
+			StrategyType: StrategyType(opts.StrategyName),
+			RoutingKey:   opts.RoutingKey,
+		})
 	}
 
 	return ctx, opts, nil
 }
 
+// routingKey returns the routing key of a send of message. An explicit key or
+// metadata field wins over fallback, the key already present in the route, which
+// wins over the message's own key.
+func (opts *SendOptions) routingKey(message Message, fallback string) string {
+	if opts.RoutingKey != "" {
+		return opts.RoutingKey
+	}
+	if opts.RoutingKeyField != "" {
+		if v, ok := opts.Metadata[opts.RoutingKeyField]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	if fallback != "" {
+		return fallback
+	}
+	if keyer, ok := message.(RoutingKeyer); ok {
+		return keyer.RoutingKey()
+	}
+	return ""
+}
+
+// expired reports whether the message deadline has passed at now.
+func (opts *SendOptions) expired(now time.Time) bool {
+	return opts.ExpireAt != nil && !now.Before(*opts.ExpireAt)
+}
+
 // Validate validates the retry policy configuration.
 func (r *RetryPolicy) Validate() error {
 	if r.MaxAttempts < 0 {
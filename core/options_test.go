@@ -1,6 +1,7 @@
 package core_test
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -127,6 +128,20 @@ func TestSerializeDeserializeSendOptions(t *testing.T) {
 	}
 }
 
+func TestSerializeDeserializeSendOptions_ExpireAt(t *testing.T) {
+	ser := &core.DefaultSendOptionsSerializer{}
+	opts := &core.SendOptions{}
+	core.WithSendTTL(time.Minute)(opts)
+	b, err := ser.Serialize(opts)
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	opts2, err := ser.Deserialize(b)
+	if err != nil || opts2.ExpireAt == nil || !opts2.ExpireAt.Equal(*opts.ExpireAt) {
+		t.Errorf("ExpireAt not preserved: %+v, %v", opts2.ExpireAt, err)
+	}
+}
+
 func TestSendOptions_Deserialize(t *testing.T) {
 	// 测试反序列化有效的选项
 	validData := `{
@@ -527,3 +542,48 @@ func TestDefaultRetryFilter_Extended(t *testing.T) {
 		t.Error("Expected false for attempt > MaxAttempts")
 	}
 }
+
+func TestRetryPolicy_NextDelayHonoursRetryAfter(t *testing.T) {
+	p := core.NewRetryPolicy(core.WithRetryInitialDelay(time.Millisecond), core.WithRetryMaxDelay(10*time.Second))
+	hinted := core.ThrottledError{Err: &core.RetryAfterError{Err: errors.New("HTTP 429"), Delay: 3 * time.Second}}
+
+	if delay := p.NextDelay(0, hinted); delay != 3*time.Second {
+		t.Errorf("expected the suggested 3s, got %v", delay)
+	}
+	long := &core.RetryAfterError{Err: errors.New("HTTP 503"), Delay: time.Minute}
+	if delay := p.NextDelay(0, long); delay != time.Minute {
+		t.Errorf("expected the suggested delay even beyond MaxDelay, got %v", delay)
+	}
+}
+
+func TestRetry_NeverEarlierThanRetryAfter(t *testing.T) {
+	throttled := core.ThrottledError{Err: &core.RetryAfterError{Err: errors.New("HTTP 429"), Delay: 100 * time.Millisecond}}
+	newDecorator := func() (*core.ProviderDecorator, *countingProvider) {
+		provider := &countingProvider{fakeProvider: fakeProvider{name: "throttled", sendErr: throttled}}
+		return core.NewProviderDecorator(provider, &core.SenderMiddleware{
+			Retry: core.NewRetryPolicy(core.WithRetryMaxAttempts(1), core.WithRetryMaxDelay(10*time.Millisecond)),
+		}, &core.NoOpLogger{}), provider
+	}
+
+	// Without a deadline, a hint beyond MaxDelay stops the retries.
+	pd, provider := newDecorator()
+	start := time.Now()
+	_, err := pd.Send(context.Background(), &fakeMessage{})
+	if !core.IsThrottled(err) || provider.calls != 1 {
+		t.Errorf("expected a single throttled attempt, got %d calls and %v", provider.calls, err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("expected no wait, took %v", elapsed)
+	}
+
+	// With a deadline that allows it, the hint is waited out in full.
+	pd, provider = newDecorator()
+	start = time.Now()
+	_, _ = pd.Send(context.Background(), &fakeMessage{}, core.WithSendTTL(time.Second))
+	if provider.calls != 2 {
+		t.Errorf("expected a retry within the deadline, got %d calls", provider.calls)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("retried before the suggested delay, after %v", elapsed)
+	}
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// ErrProbeUnsupported is returned by the probe of an account whose vendor offers
+// no way to check it without sending a message. Such accounts are reported with
+// HealthStatusUnknown and stay selectable.
+var ErrProbeUnsupported = errors.New("probe not supported")
+
+// ProbeResult is the outcome of probing one account.
+type ProbeResult struct {
+	// Account is the name of the probed account.
+	Account string
+	// Err is why the probe failed, nil if it succeeded.
+	Err error
+	// Latency is how long the probe took.
+	Latency time.Duration
+	// Time is when the probe finished.
+	Time time.Time
+	// Details holds what the probe learned, e.g. the balance of an SMS account.
+	Details map[string]interface{}
+}
+
+// Healthy reports whether the account can be used: the probe succeeded, or the
+// account cannot be probed.
+func (r ProbeResult) Healthy() bool {
+	return r.Err == nil || errors.Is(r.Err, ErrProbeUnsupported)
+}
+
+// Prober is implemented by providers that can check their accounts without
+// sending a message: an SMTP login, an access-token fetch, a balance query...
+// Probe checks every enabled account and returns one result per account. The
+// results are also recorded in the provider's BaseConfig, so that selection
+// skips the accounts whose probe failed (see [BaseConfig.RecordProbe]).
+//
+// Sender.HealthCheck probes the providers implementing it, and a [HealthMonitor]
+// probes them periodically.
+type Prober interface {
+	Probe(ctx context.Context, opts *ProviderSendOptions) []ProbeResult
+}
+
+// ProbeFunc checks one account; details may be nil.
+type ProbeFunc[T Selectable] func(ctx context.Context, item T) (details map[string]interface{}, err error)
+
+// ProbeItems runs probe concurrently on every enabled item, records the results
+// with RecordProbe and returns them in the order of the items.
+func (c *BaseConfig[T]) ProbeItems(ctx context.Context, probe ProbeFunc[T]) []ProbeResult {
+	var enabled []T
+	for _, item := range c.GetItems() {
+		if item.IsEnabled() {
+			enabled = append(enabled, item)
+		}
+	}
+
+	results := make([]ProbeResult, len(enabled))
+	var wg sync.WaitGroup
+	for i, item := range enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			details, err := probe(ctx, item)
+			results[i] = ProbeResult{
+				Account: item.GetName(),
+				Err:     err,
+				Latency: time.Since(start),
+				Time:    time.Now(),
+				Details: details,
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		c.RecordProbe(result)
+	}
+	return results
+}
+
+// RecordProbe records the probe result of an account. Until a later probe of
+// the account succeeds, selection skips it in favour of the accounts that are
+// healthy, if any; an explicitly requested account is still used.
+func (c *BaseConfig[T]) RecordProbe(result ProbeResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, down := c.unhealthy[result.Account]; !down && result.Healthy() {
+		return
+	}
+	// Select reads the map without the lock, so it is replaced rather than modified.
+	unhealthy := maps.Clone(c.unhealthy)
+	if unhealthy == nil {
+		unhealthy = make(map[string]error)
+	}
+	if result.Healthy() {
+		delete(unhealthy, result.Account)
+	} else {
+		unhealthy[result.Account] = result.Err
+	}
+	c.unhealthy = unhealthy
+}
+
+// ProbeError returns why the last probe of the account named name failed, or
+// nil if it succeeded or the account was never probed.
+func (c *BaseConfig[T]) ProbeError(name string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.unhealthy[name]
+}
+
+// preferHealthy drops the items whose last probe failed, unless that leaves none.
+func preferHealthy[T Selectable](items []T, unhealthy map[string]error) []T {
+	if len(unhealthy) == 0 {
+		return items
+	}
+	healthy := make([]T, 0, len(items))
+	for _, item := range items {
+		if _, down := unhealthy[item.GetName()]; !down {
+			healthy = append(healthy, item)
+		}
+	}
+	if len(healthy) == 0 {
+		return items
+	}
+	return healthy
+}
+
+// HealthFromProbes summarises probe results: healthy when every probed account
+// is, degraded when some are not and unhealthy when none is. Each account is
+// reported in Checks, with the details of its probe.
+func HealthFromProbes(results []ProbeResult) *HealthCheck {
+	check := &HealthCheck{
+		Status:    HealthStatusHealthy,
+		Timestamp: time.Now(),
+		Checks:    make(map[string]*HealthCheck, len(results)),
+	}
+	var probed, failed int
+	for _, result := range results {
+		account := &HealthCheck{
+			Status:    HealthStatusHealthy,
+			Details:   result.Details,
+			Timestamp: result.Time,
+		}
+		switch {
+		case errors.Is(result.Err, ErrProbeUnsupported):
+			account.Status = HealthStatusUnknown
+			account.Message = result.Err.Error()
+		case result.Err != nil:
+			account.Status = HealthStatusUnhealthy
+			account.Message = result.Err.Error()
+			probed++
+			failed++
+		default:
+			probed++
+		}
+		check.Checks[result.Account] = account
+	}
+
+	switch {
+	case probed == 0:
+		check.Status = HealthStatusUnknown
+		check.Message = "no account could be probed"
+	case failed == probed:
+		check.Status = HealthStatusUnhealthy
+		check.Message = fmt.Sprintf("all %d probed accounts are unhealthy", probed)
+	case failed > 0:
+		check.Status = HealthStatusDegraded
+		check.Message = fmt.Sprintf("%d of %d probed accounts are unhealthy", failed, probed)
+	}
+	return check
+}
@@ -0,0 +1,128 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// newProbedProvider returns an accountProvider whose probes fail on the accounts
+// in down; c cannot be probed.
+func newProbedProvider(down ...string) *accountProvider {
+	p := newAccountProvider(account("a", ""), account("b", ""), account("c", "legacy")).probeDown(down...)
+	p.unprobed["c"] = true
+	return p
+}
+
+func TestProbe_SelectionAvoidsUnhealthyAccounts(t *testing.T) {
+	p := newProbedProvider("a")
+	results := p.Probe(context.Background(), nil)
+	if len(results) != 3 || results[0].Account != "a" || results[0].Healthy() || !results[2].Healthy() {
+		t.Fatalf("unexpected probe results: %+v", results)
+	}
+	if p.config.ProbeError("a") == nil || p.config.ProbeError("b") != nil {
+		t.Fatal("expected only a to be recorded as unhealthy")
+	}
+
+	for range 6 {
+		item, err := p.config.Select(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.name == "a" {
+			t.Fatal("selected the account whose probe failed")
+		}
+	}
+
+	// An account requested by name is used anyway.
+	ctx := core.WithRoute(context.Background(), &core.RouteInfo{AccountName: "a"})
+	if item, err := p.config.Select(ctx, nil); err != nil || item.name != "a" {
+		t.Fatalf("expected the requested account, got %v, %v", item, err)
+	}
+
+	// Once it recovers, the account is selected again.
+	delete(p.down, "a")
+	p.Probe(context.Background(), nil)
+	seen := false
+	for range 6 {
+		item, _ := p.config.Select(context.Background(), nil)
+		seen = seen || item.name == "a"
+	}
+	if !seen {
+		t.Error("recovered account was not selected")
+	}
+}
+
+func TestProbe_AllUnhealthyFallsBack(t *testing.T) {
+	p := newProbedProvider("a", "b")
+	p.Probe(context.Background(), nil)
+	filter := func(item *mockSelectable) bool { return item.subType == "" }
+	if _, err := p.config.Select(context.Background(), filter); err != nil {
+		t.Errorf("selection should fall back to unhealthy accounts, got %v", err)
+	}
+}
+
+func TestHealthFromProbes(t *testing.T) {
+	cases := []struct {
+		name string
+		down []string
+		want core.HealthStatus
+	}{
+		{"healthy", nil, core.HealthStatusHealthy},
+		{"degraded", []string{"a"}, core.HealthStatusDegraded},
+		{"unhealthy", []string{"a", "b"}, core.HealthStatusUnhealthy},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := core.HealthFromProbes(newProbedProvider(tc.down...).Probe(context.Background(), nil))
+			if check.Status != tc.want {
+				t.Errorf("status = %s, want %s (%s)", check.Status, tc.want, check.Message)
+			}
+			if c := check.Checks["c"]; c == nil || c.Status != core.HealthStatusUnknown {
+				t.Errorf("unsupported probe should be reported as unknown, got %+v", c)
+			}
+			if b := check.Checks["b"]; b.Status == core.HealthStatusHealthy && b.Details["balance"] != 10 {
+				t.Errorf("expected the probe details, got %+v", b.Details)
+			}
+		})
+	}
+
+	if check := core.HealthFromProbes(nil); check.Status != core.HealthStatusUnknown {
+		t.Errorf("no probes should be unknown, got %s", check.Status)
+	}
+}
+
+func TestHealthMonitor_ProbesPeriodically(t *testing.T) {
+	p := newProbedProvider("b")
+	m := core.NewHealthMonitor(core.WithProbeInterval(10 * time.Millisecond))
+	m.Register("fake", p)
+	m.Start()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.probes.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if p.probes.Load() < 3 {
+		t.Fatalf("expected repeated probes, got %d", p.probes.Load())
+	}
+	check, ok := m.Health("fake")
+	if !ok || check.Status != core.HealthStatusDegraded {
+		t.Fatalf("expected a cached degraded result, got %+v", check)
+	}
+
+	probes := p.probes.Load()
+	time.Sleep(30 * time.Millisecond)
+	if p.probes.Load() != probes {
+		t.Error("probes continued after Close")
+	}
+
+	m.Unregister("fake")
+	if _, ok := m.Health("fake"); ok {
+		t.Error("unregistered provider still reported")
+	}
+}
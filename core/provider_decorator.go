@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -23,6 +24,17 @@ type ProviderDecorator struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	logger     Logger
+
+	// retryBudget counts attempts against middleware.RetryBudget, if any.
+	retryBudget *retryBudget
+	// hedger applies middleware.Hedge, if any.
+	hedger *hedger
+	// stopEvictions unregisters handleEvicted from the queue, if it was registered.
+	stopEvictions func()
+
+	// pending holds delayed async sends parked on timers when no queue is configured.
+	pending   map[*delayedSend]struct{}
+	pendingMu sync.Mutex
 }
 
 // NewProviderDecorator creates a new ProviderDecorator instance.
@@ -36,6 +48,10 @@ func NewProviderDecorator(provider Provider, middleware *SenderMiddleware, logge
 		cancel:     cancel,
 		logger:     logger,
 	}
+	if middleware != nil {
+		pd.retryBudget = newRetryBudget(middleware.RetryBudget)
+		pd.hedger = newHedger(middleware.Hedge)
+	}
 
 	// Check if provider supports logger injection
 	if loggerAware, ok := provider.(LoggerAware); ok {
@@ -44,6 +60,9 @@ func NewProviderDecorator(provider Provider, middleware *SenderMiddleware, logge
 
 	// Start the queue processor if a queue is configured.
 	if middleware != nil && middleware.Queue != nil {
+		if notifier, ok := middleware.Queue.(EvictionNotifier); ok {
+			pd.stopEvictions = notifier.OnEvict(pd.handleEvicted)
+		}
 		pd.startQueueProcessor()
 	}
 
@@ -75,6 +94,18 @@ func (pd *ProviderDecorator) Send(ctx context.Context, message Message, opts ...
 		}
 	}
 
+	ctx, span := StartSpan(pd.withTracer(ctx), SpanSend,
+		Attr("provider", pd.Provider.Name()),
+		Attr("message_id", message.MsgID()),
+		Attr("async", sendOpts.Async),
+	)
+	result, err := pd.send(ctx, message, sendOpts)
+	FinishSpan(span, err)
+	return result, err
+}
+
+// send runs Send once the span of the send is started.
+func (pd *ProviderDecorator) send(ctx context.Context, message Message, sendOpts *SendOptions) (*SendResult, error) {
 	if sendOpts.Async {
 		// Validate callback usage
 		if sendOpts.Callback != nil && !sendOpts.Async {
@@ -83,14 +114,15 @@ func (pd *ProviderDecorator) Send(ctx context.Context, message Message, opts ...
 
 		// Enqueue chain: only enqueue and record metrics
 		err := pd.sendAsync(ctx, message, sendOpts)
-		pd.recordMetric(OperationEnqueue, message, err == nil, 0, 0)
+		pd.recordMetric(OperationEnqueue, message, err, 0, 0)
 		return nil, err
 	}
 
 	// Synchronous chain: rate limiting -> circuit breaker -> retry -> send -> metrics
+	ctx, sel := withSelectionRecorder(ctx)
 	startTime := time.Now()
 	result, err := pd.executeWithMiddleware(ctx, message, sendOpts)
-	pd.recordMetric(OperationSent, message, err == nil, time.Since(startTime), 0)
+	pd.recordSendMetric(sel, result, err, time.Since(startTime))
 	return result, err
 }
 
@@ -153,12 +185,28 @@ func (pd *ProviderDecorator) executeWithMiddleware(
 	message Message,
 	opts *SendOptions,
 ) (*SendResult, error) {
+	// An async send that fails before reaching the provider must still be
+	// reported, or its Callback never fires and its SendFuture stays pending.
+	fail := func(err error) (*SendResult, error) {
+		if opts.Async {
+			pd.reportAsync(message.MsgID(), opts.Callback, nil, err)
+		}
+		return nil, err
+	}
+
 	if ctx.Err() != nil {
-		return nil, ctx.Err()
+		return fail(ctx.Err())
+	}
+
+	if opts.expired(time.Now()) {
+		err := NewSenderErrorf(ErrCodeMessageExpired, "message %s expired at %s",
+			message.MsgID(), opts.ExpireAt.Format(time.RFC3339))
+		pd.handleExpired(message, opts, err)
+		return fail(err)
 	}
 
 	if err := pd.callBeforeHooks(ctx, message, opts); err != nil {
-		return nil, err
+		return fail(err)
 	}
 
 	if pd.logger != nil {
@@ -174,9 +222,21 @@ func (pd *ProviderDecorator) executeWithMiddleware(
 	}
 
 	// Rate limiting
+	if pd.middleware != nil && pd.middleware.RateLimitWait != nil {
+		ctx = withRateLimitWait(ctx, pd.middleware.RateLimitWait.maxWait(opts.Async))
+	}
 	if pd.middleware != nil && pd.middleware.RateLimiter != nil && !opts.DisableRateLimiter {
-		if !pd.middleware.RateLimiter.Allow() {
-			return nil, NewSenderError(ErrCodeRateLimitExceeded, "rate limit exceeded", nil)
+		maxWait := pd.middleware.RateLimitWait.maxWait(opts.Async)
+		limitCtx, span := StartSpan(ctx, SpanRateLimit)
+		var err error
+		if limiter, ok := pd.middleware.RateLimiter.(RequestRateLimiter); ok {
+			err = acquireRequestRateLimit(limitCtx, limiter, newRateLimitRequest(message, opts), maxWait)
+		} else {
+			err = acquireRateLimit(limitCtx, pd.middleware.RateLimiter, rateLimitTokens(message), maxWait)
+		}
+		FinishSpan(span, err)
+		if err != nil {
+			return fail(err)
 		}
 	}
 
@@ -194,11 +254,21 @@ func (pd *ProviderDecorator) executeWithMiddleware(
 		result, err = pd.doSendWithRetry(ctx, message, opts)
 	}
 
+	if pd.middleware != nil && pd.middleware.RateLimiter != nil && !opts.DisableRateLimiter &&
+		GetSenderErrorCode(err) != ErrCodeCircuitBreakerOpen {
+		observeRateLimit(pd.middleware.RateLimiter, err)
+	}
+
+	// The deadline may pass between retry attempts.
+	if GetSenderErrorCode(err) == ErrCodeMessageExpired {
+		pd.handleExpired(message, opts, err)
+	}
+
 	// Execute callback **only** when the send originated from an async flow. For
 	// synchronous Send (opts.Async == false) callbacks must be ignored per the
 	// updated API contract.
-	if opts.Async && opts.Callback != nil {
-		opts.Callback(result, err)
+	if opts.Async {
+		pd.reportAsync(message.MsgID(), opts.Callback, result, err)
 	}
 
 	pd.callAfterHooks(ctx, message, opts, result, err)
@@ -213,10 +283,15 @@ func (pd *ProviderDecorator) doSendWithRetry(
 ) (*SendResult, error) {
 	var result *SendResult
 	var err error
-	if pd.middleware != nil && pd.middleware.Retry != nil {
+	switch {
+	case pd.middleware != nil && pd.middleware.Retry != nil:
 		result, err = pd.sendWithRetry(ctx, message, opts)
-	} else {
-		result, err = pd.executeSend(ctx, message, opts)
+	case pd.hedger != nil && opts.Hedge:
+		fo := newFailover(ctx, nil, opts)
+		result, err = pd.attemptSend(ctx, message, opts, fo)
+		result = fo.attach(result)
+	default:
+		result, err = pd.executeSend(ctx, message, opts, nil)
 	}
 	return result, err
 }
@@ -227,8 +302,10 @@ func (pd *ProviderDecorator) processQueueItem(ctx context.Context, item *QueueIt
 	if !item.CreatedAt.IsZero() {
 		queueLatency = time.Since(item.CreatedAt)
 	}
-	pd.recordMetric(OperationDequeue, item.Message, true, 0, queueLatency)
-	// Deserialize SendOptions and restore context
+	pd.recordMetric(OperationDequeue, item.Message, nil, 0, queueLatency)
+	// Deserialize SendOptions and restore context, including the trace of the send
+	// that enqueued the item.
+	ctx = pd.withTracer(ctx)
 	restoredCtx, opts, err := deserializeSendOptions(ctx, item.Metadata)
 	if err != nil {
 		if pd.logger != nil {
@@ -253,19 +330,19 @@ func (pd *ProviderDecorator) processQueueItem(ctx context.Context, item *QueueIt
 		case <-ctx.Done():
 			// Context cancelled while waiting, do not process
 			pd.logWarn(fmt.Sprintf("Message %s processing cancelled during scheduled wait: %v", item.ID, ctx.Err()))
+			pd.reportAsync(item.ID, item.Callback, nil, ctx.Err())
 			return
 		}
 	}
 
 	// Propagate callback stored in QueueItem to SendOptions before processing.
 	// Note: Async flag might be lost during serialization, so re-enable it here
-	// to ensure the callback is honoured for local queue processing.
-	if item.Callback != nil {
-		opts.Callback = item.Callback
-		opts.Async = true
-	}
+	// to ensure the callback is honoured for local queue processing and the
+	// outcome reaches the ResultStore for items enqueued by other processes.
+	opts.Callback = item.Callback
+	opts.Async = true
 
-	result, err := pd.executeWithMiddleware(restoredCtx, item.Message, opts)
+	result, err := pd.deliver(restoredCtx, item.Message, opts)
 	_ = result // result already delivered via internal callback if any
 	if err != nil {
 		if pd.logger != nil {
@@ -274,6 +351,104 @@ func (pd *ProviderDecorator) processQueueItem(ctx context.Context, item *QueueIt
 	}
 }
 
+// handleEvicted reports a queue item of this provider that was evicted or purged
+// before processing. Shared queues notify every decorator, so items of other
+// providers are ignored.
+func (pd *ProviderDecorator) handleEvicted(item *QueueItem, cause error) {
+	if item == nil || item.Provider != pd.Provider.Name() {
+		return
+	}
+	err := cause
+	if !IsSenderError(err) {
+		err = NewSenderError(ErrCodeQueueItemEvicted, "queue item evicted", cause)
+	}
+	pd.logWarn(fmt.Sprintf("Message %s dropped from queue: %v", item.ID, err))
+	pd.recordMetric(OperationEvict, item.Message, err, 0, 0)
+	pd.reportAsync(item.ID, item.Callback, nil, err)
+}
+
+// SendAsync enqueues the message like Send with [WithSendAsync] and returns a
+// [SendFuture] that resolves with the final outcome. A Callback in opts is still
+// invoked. When a ResultStore is configured the outcome is also saved there, so
+// the future resolves even if the message is processed by another process.
+func (pd *ProviderDecorator) SendAsync(ctx context.Context, message Message, opts ...SendOption) (*SendFuture, error) {
+	var store ResultStore
+	if pd.middleware != nil {
+		store = pd.middleware.ResultStore
+	}
+	future := NewSendFuture(message.MsgID(), store)
+
+	var userCallback func(*SendResult, error)
+	opts = append(opts, func(o *SendOptions) {
+		o.Async = true
+		userCallback = o.Callback
+		o.Callback = func(result *SendResult, err error) {
+			future.resolve(statusOf(err), result, err)
+			if userCallback != nil {
+				userCallback(result, err)
+			}
+		}
+	})
+
+	if _, err := pd.Send(ctx, message, opts...); err != nil {
+		return nil, err
+	}
+	return future, nil
+}
+
+// reportAsync saves the outcome of an async send to the ResultStore, if configured,
+// and hands it to the callback.
+func (pd *ProviderDecorator) reportAsync(msgID string, callback func(*SendResult, error), result *SendResult, err error) {
+	if pd.middleware != nil && pd.middleware.ResultStore != nil {
+		record := newSendRecord(msgID, pd.Provider.Name(), result, err)
+		if saveErr := pd.middleware.ResultStore.Save(context.WithoutCancel(pd.ctx), record); saveErr != nil {
+			pd.logError("Save send result failed", saveErr)
+		}
+	}
+	if callback != nil {
+		callback(result, err)
+	}
+}
+
+// deadLetterReasonMetadataKey records why a message was moved to the dead-letter queue.
+const deadLetterReasonMetadataKey = "__gosender_dead_letter_reason__"
+
+// handleExpired records an expired message and hands it to the dead-letter queue, if configured.
+// Reporting to the Callback is left to the caller.
+func (pd *ProviderDecorator) handleExpired(message Message, opts *SendOptions, err error) {
+	pd.logWarn(fmt.Sprintf("Message %s expired, dropping", message.MsgID()))
+	pd.recordMetric(OperationExpire, message, err, 0, 0)
+
+	if pd.middleware == nil || pd.middleware.DeadLetter == nil {
+		return
+	}
+	metadata, serErr := serializeSendOptions(pd.ctx, opts, copyMetadata(opts.Metadata))
+	if serErr != nil {
+		pd.logError("Serialize expired message for dead-letter queue failed", serErr)
+		return
+	}
+	metadata[deadLetterReasonMetadataKey] = err.Error()
+	item := &QueueItem{
+		ID:        message.MsgID(),
+		Provider:  pd.Provider.Name(),
+		Message:   message,
+		Priority:  opts.Priority,
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+	}
+	if dlqErr := pd.middleware.DeadLetter.Enqueue(context.WithoutCancel(pd.ctx), item); dlqErr != nil {
+		pd.logError("Dead-letter enqueue failed", dlqErr)
+	}
+}
+
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	return out
+}
+
 // sendAsync sends the message asynchronously, using a queue if available, otherwise a goroutine.
 func (pd *ProviderDecorator) sendAsync(ctx context.Context, message Message, opts *SendOptions) error {
 	metadata, err := serializeSendOptions(ctx, opts, opts.Metadata)
@@ -296,52 +471,181 @@ func (pd *ProviderDecorator) sendAsync(ctx context.Context, message Message, opt
 		return pd.middleware.Queue.Enqueue(ctx, item)
 	}
 
-	// Fallback to goroutine if no queue is configured
+	// Fallback to in-process dispatch if no queue is configured. Delayed sends are
+	// parked on a runtime timer rather than a sleeping goroutine each.
+	if opts.DelayUntil != nil && opts.DelayUntil.After(time.Now()) {
+		return pd.scheduleAsync(message, opts, metadata, *opts.DelayUntil)
+	}
+
 	pd.workers.Add(1)
 	go func() {
 		defer pd.workers.Done()
+		pd.runAsync(message, opts, metadata)
+	}()
 
-		// If DelayUntil is set, wait until that time
-		if opts.DelayUntil != nil && opts.DelayUntil.After(time.Now()) {
-			select {
-			case <-time.After(time.Until(*opts.DelayUntil)):
-				// Waited successfully
-			case <-pd.ctx.Done(): // Use provider context to respect shutdown signals
-				// Context cancelled while waiting, do not send
-				if opts.Callback != nil {
-					opts.Callback(nil, pd.ctx.Err())
-				}
-				return
-			}
-		}
+	return nil
+}
 
-		// Check if provider is being shut down before proceeding
-		select {
-		case <-pd.ctx.Done():
-			if opts.Callback != nil {
-				opts.Callback(nil, pd.ctx.Err())
-			}
+// delayedSend is an async send waiting for its DelayUntil time when no queue is configured.
+type delayedSend struct {
+	message  Message
+	opts     *SendOptions
+	metadata map[string]interface{}
+	timer    *time.Timer
+}
+
+// scheduleAsync registers a delayed send that fires at the given time.
+// Whoever removes the entry from pd.pending (the timer or Close) owns its worker slot.
+func (pd *ProviderDecorator) scheduleAsync(
+	message Message,
+	opts *SendOptions,
+	metadata map[string]interface{},
+	at time.Time,
+) error {
+	pd.pendingMu.Lock()
+	defer pd.pendingMu.Unlock()
+
+	if pd.ctx.Err() != nil {
+		return pd.ctx.Err()
+	}
+	if pd.pending == nil {
+		pd.pending = make(map[*delayedSend]struct{})
+	}
+
+	ds := &delayedSend{message: message, opts: opts, metadata: metadata}
+	pd.workers.Add(1)
+	pd.pending[ds] = struct{}{}
+	ds.timer = time.AfterFunc(time.Until(at), func() {
+		if !pd.takePending(ds) {
 			return
-		default:
 		}
+		defer pd.workers.Done()
+		pd.runAsync(message, opts, metadata)
+	})
+	return nil
+}
 
-		_, errSend := pd.executeWithMiddleware(pd.ctx, message, opts)
-		if errSend != nil && pd.logger != nil {
-			_ = pd.logger.Log(
-				LevelError,
-				"message",
-				"async send failed",
-				"message_id",
-				message.MsgID(),
-				"error",
-				fmt.Sprintf("%v", errSend),
-			)
+// takePending removes ds from the pending set and reports whether it was still there.
+func (pd *ProviderDecorator) takePending(ds *delayedSend) bool {
+	pd.pendingMu.Lock()
+	defer pd.pendingMu.Unlock()
+	if _, ok := pd.pending[ds]; !ok {
+		return false
+	}
+	delete(pd.pending, ds)
+	return true
+}
+
+// cancelPending stops every delayed send that has not fired yet and reports err to its callback.
+func (pd *ProviderDecorator) cancelPending(err error) {
+	pd.pendingMu.Lock()
+	cancelled := make([]*delayedSend, 0, len(pd.pending))
+	for ds := range pd.pending {
+		ds.timer.Stop()
+		cancelled = append(cancelled, ds)
+	}
+	pd.pending = nil
+	pd.pendingMu.Unlock()
+
+	for _, ds := range cancelled {
+		pd.reportAsync(ds.message.MsgID(), ds.opts.Callback, nil, err)
+		pd.workers.Done()
+	}
+}
+
+// Cancel cancels a pending async send by message ID before it is processed.
+//
+// It looks at delayed sends parked in-process (no queue configured) and, when the
+// configured queue implements [QueueManager], at items waiting in the queue. The
+// cancelled message's Callback receives an ErrCodeQueueItemCancelled error.
+// An ErrCodeQueueItemNotFound error is returned when nothing was cancelled.
+func (pd *ProviderDecorator) Cancel(ctx context.Context, msgID string) error {
+	cancelErr := NewSenderError(ErrCodeQueueItemCancelled, "message cancelled", nil)
+
+	if ds := pd.takePendingByID(msgID); ds != nil {
+		ds.timer.Stop()
+		pd.reportAsync(msgID, ds.opts.Callback, nil, cancelErr)
+		pd.workers.Done()
+		return nil
+	}
+
+	if pd.middleware != nil && pd.middleware.Queue != nil {
+		if manager, ok := pd.middleware.Queue.(QueueManager); ok {
+			// A shared queue holds the items of other providers as well.
+			item, err := manager.Remove(ctx, msgID, QueueFilter{Provider: pd.Provider.Name()})
+			if err != nil {
+				return err
+			}
+			pd.reportAsync(msgID, item.Callback, nil, cancelErr)
+			return nil
 		}
-	}()
+	}
+
+	return NewSenderErrorf(ErrCodeQueueItemNotFound, "message %s not found", msgID)
+}
 
+// takePendingByID removes the first pending delayed send for msgID, if any.
+func (pd *ProviderDecorator) takePendingByID(msgID string) *delayedSend {
+	pd.pendingMu.Lock()
+	defer pd.pendingMu.Unlock()
+	for ds := range pd.pending {
+		if ds.message.MsgID() == msgID {
+			delete(pd.pending, ds)
+			return ds
+		}
+	}
 	return nil
 }
 
+// runAsync executes an async send in-process, honouring provider shutdown.
+// metadata is the serialized form of opts, which carries the caller's trace.
+func (pd *ProviderDecorator) runAsync(message Message, opts *SendOptions, metadata map[string]interface{}) {
+	// Check if provider is being shut down before proceeding
+	select {
+	case <-pd.ctx.Done():
+		pd.reportAsync(message.MsgID(), opts.Callback, nil, pd.ctx.Err())
+		return
+	default:
+	}
+
+	ctx := extractTrace(pd.withTracer(pd.ctx), metadata)
+	_, errSend := pd.deliver(ctx, message, opts)
+	if errSend != nil && pd.logger != nil {
+		_ = pd.logger.Log(
+			LevelError,
+			"message",
+			"async send failed",
+			"message_id",
+			message.MsgID(),
+			"error",
+			fmt.Sprintf("%v", errSend),
+		)
+	}
+}
+
+// deliver processes an async send under its own span and records it like a
+// synchronous send.
+func (pd *ProviderDecorator) deliver(ctx context.Context, message Message, opts *SendOptions) (*SendResult, error) {
+	ctx, span := StartSpan(ctx, SpanDeliver,
+		Attr("provider", pd.Provider.Name()),
+		Attr("message_id", message.MsgID()),
+	)
+	ctx, sel := withSelectionRecorder(ctx)
+	startTime := time.Now()
+	result, err := pd.executeWithMiddleware(ctx, message, opts)
+	pd.recordSendMetric(sel, result, err, time.Since(startTime))
+	FinishSpan(span, err)
+	return result, err
+}
+
+// withTracer returns ctx with the Tracer of the middleware, unless ctx has one.
+func (pd *ProviderDecorator) withTracer(ctx context.Context) context.Context {
+	if pd.middleware == nil || pd.middleware.Tracer == nil || TracerFromContext(ctx) != nil {
+		return ctx
+	}
+	return WithTracer(ctx, pd.middleware.Tracer)
+}
+
 // sendWithRetry attempts to send the message with retry logic.
 func (pd *ProviderDecorator) sendWithRetry(
 	ctx context.Context,
@@ -354,15 +658,17 @@ func (pd *ProviderDecorator) sendWithRetry(
 	}
 
 	if retryPolicy == nil {
-		return pd.executeSend(ctx, message, opts)
+		return pd.executeSend(ctx, message, opts, nil)
 	}
 
+	fo := newFailover(ctx, retryPolicy.Failover, opts)
 	var lastErr error
 	var lastResult *SendResult
+	pd.retryBudget.recordAttempt()
 	for attempt := 0; attempt <= retryPolicy.MaxAttempts; attempt++ {
-		result, err := pd.executeSend(ctx, message, opts)
+		result, err := pd.attemptSend(ctx, message, opts, fo)
 		if err == nil {
-			return result, nil
+			return fo.attach(result), nil
 		}
 
 		lastErr = err
@@ -381,8 +687,20 @@ func (pd *ProviderDecorator) sendWithRetry(
 			break
 		}
 
-		// Wait before retrying using NextDelay method
+		// Wait before retrying using NextDelay method. Give up early if the
+		// message would expire before the next attempt. Only a retry that will
+		// be made is charged to the retry budget.
 		delay := retryPolicy.NextDelay(attempt, err)
+		if delay > retryPolicy.MaxDelay && !deadlineAllows(ctx, opts, time.Now().Add(delay)) {
+			return fo.attach(lastResult), fmt.Errorf("vendor asked to retry after %s, more than MaxDelay %s: %w",
+				delay, retryPolicy.MaxDelay, lastErr)
+		}
+		if opts.expired(time.Now().Add(delay)) {
+			return fo.attach(lastResult), NewSenderError(ErrCodeMessageExpired, "message expired before retry", lastErr)
+		}
+		if !pd.retryBudget.tryRetry() {
+			return fo.attach(lastResult), NewSenderError(ErrCodeRetryBudgetExhausted, "retry budget exhausted", lastErr)
+		}
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
@@ -391,11 +709,52 @@ func (pd *ProviderDecorator) sendWithRetry(
 		}
 	}
 
-	return lastResult, fmt.Errorf("failed after %d attempts: %w", retryPolicy.MaxAttempts+1, lastErr)
+	return fo.attach(lastResult), fmt.Errorf("failed after %d attempts: %w", retryPolicy.MaxAttempts+1, lastErr)
+}
+
+// deadlineAllows reports whether the send has a deadline, set with a message TTL
+// or on ctx, and whether retrying at retryAt still meets it. Waits longer than
+// MaxDelay are only made for sends with a deadline.
+func deadlineAllows(ctx context.Context, opts *SendOptions, retryAt time.Time) bool {
+	ctxDeadline, hasCtxDeadline := ctx.Deadline()
+	if opts.ExpireAt == nil && !hasCtxDeadline {
+		return false
+	}
+	return !opts.expired(retryAt) && (!hasCtxDeadline || retryAt.Before(ctxDeadline))
+}
+
+// attemptSend makes one attempt of a retried or hedged send on the account
+// chosen by fo, and records the outcome in fo.
+func (pd *ProviderDecorator) attemptSend(
+	ctx context.Context,
+	message Message,
+	opts *SendOptions,
+	fo *failover,
+) (*SendResult, error) {
+	if pd.hedger != nil && opts.Hedge {
+		if key, delay, ok := pd.hedger.acquire(message); ok {
+			defer pd.hedger.release(key)
+			return pd.hedgedAttempt(withIdempotencyKey(ctx, key), message, opts, fo, delay)
+		}
+	}
+
+	ctx, sel := withSelectionRecorder(ctx)
+	start := time.Now()
+	result, err := pd.executeSend(ctx, message, opts, fo.route())
+	latency := time.Since(start)
+	fo.record(sel, err, latency, false)
+	pd.hedger.observe(err, latency)
+	return result, err
 }
 
 // executeSend executes the actual send operation with timeout and metrics.
-func (pd *ProviderDecorator) executeSend(ctx context.Context, message Message, opts *SendOptions) (*SendResult, error) {
+// A non-nil route replaces the routing derived from opts.
+func (pd *ProviderDecorator) executeSend(
+	ctx context.Context,
+	message Message,
+	opts *SendOptions,
+	route *RouteInfo,
+) (*SendResult, error) {
 	var (
 		err     error
 		timeout = opts.Timeout
@@ -405,9 +764,14 @@ func (pd *ProviderDecorator) executeSend(ctx context.Context, message Message, o
 		timeout = DefaultSendTimeout
 	}
 
-	if opts.StrategyName != "" || opts.AccountName != "" {
-		ctx = WithRoute(ctx, &RouteInfo{AccountName: opts.AccountName, StrategyType: StrategyType(opts.StrategyName)})
+	if route == nil && (opts.StrategyName != "" || opts.AccountName != "") {
+		route = &RouteInfo{AccountName: opts.AccountName, StrategyType: StrategyType(opts.StrategyName)}
+	}
+	if route == nil {
+		route = cloneRoute(GetRoute(ctx))
 	}
+	route.RoutingKey = opts.routingKey(message, route.RoutingKey)
+	ctx = WithRoute(ctx, route)
 
 	if opts.Metadata != nil {
 		ctx = context.WithValue(ctx, metadataKey{}, opts.Metadata)
@@ -416,6 +780,9 @@ func (pd *ProviderDecorator) executeSend(ctx context.Context, message Message, o
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	ctx, span := StartSpan(ctx, SpanAttempt, Attr("provider", pd.Provider.Name()))
+	ctx, sel := withSelectionRecorder(ctx)
+
 	// Convert SendOptions to ProviderSendOptions
 	providerOpts := &ProviderSendOptions{
 		HTTPClient: EnsureHTTPClient(opts.HTTPClient),
@@ -423,6 +790,10 @@ func (pd *ProviderDecorator) executeSend(ctx context.Context, message Message, o
 
 	result, err := pd.Provider.Send(ctx, message, providerOpts)
 
+	if account, subType := sel.get(); account != "" {
+		span.SetAttributes(Attr("account", account), Attr("sub_type", subType))
+	}
+	FinishSpan(span, err)
 	return result, err
 }
 
@@ -494,10 +865,15 @@ func (pd *ProviderDecorator) logError(msg string, err error) {
 
 // Close gracefully shuts down the ProviderDecorator and its associated middleware.
 func (pd *ProviderDecorator) Close() error {
-	// Signal all goroutines to shut down.
+	// Signal all goroutines to shut down, and stop handling the evictions of a
+	// queue that may outlive the decorator.
 	if pd.cancel != nil {
 		pd.cancel()
 	}
+	if pd.stopEvictions != nil {
+		pd.stopEvictions()
+	}
+	pd.cancelPending(pd.ctx.Err())
 
 	// Wait for all workers to finish
 	pd.workers.Wait()
@@ -522,22 +898,76 @@ func (pd *ProviderDecorator) Close() error {
 func (pd *ProviderDecorator) recordMetric(
 	operation string,
 	_ Message,
-	success bool,
+	err error,
 	duration time.Duration,
 	queueLatency time.Duration,
 ) {
-	queueSize := 0
-	if pd.middleware != nil && pd.middleware.Queue != nil {
-		queueSize = pd.middleware.Queue.Size()
-	}
-	if pd.middleware != nil && pd.middleware.Metrics != nil {
-		pd.middleware.Metrics.RecordSendResult(MetricsData{
-			Provider:     pd.Provider.Name(),
-			Success:      success,
-			Duration:     duration,
-			Operation:    operation,
-			QueueLatency: queueLatency,
-			QueueSize:    queueSize,
-		})
+	pd.emitMetric(MetricsData{
+		Operation:    operation,
+		Success:      err == nil,
+		Duration:     duration,
+		ErrorType:    metricErrorType(err),
+		QueueLatency: queueLatency,
+	})
+}
+
+// recordSendMetric records a processed send, with its retries and the account
+// that served it: the winner of its attempts if known, else the last account sel
+// saw selected.
+func (pd *ProviderDecorator) recordSendMetric(
+	sel *accountSelection,
+	result *SendResult,
+	err error,
+	duration time.Duration,
+) {
+	account, subType := sel.get()
+	retries := 0
+	if result != nil {
+		if result.Winner >= 0 && result.Winner < len(result.Attempts) {
+			account, subType = result.Attempts[result.Winner].Account, result.Attempts[result.Winner].SubType
+		}
+		for _, attempt := range result.Attempts {
+			if !attempt.Hedge {
+				retries++
+			}
+		}
+		retries = max(retries-1, 0)
+	}
+	pd.emitMetric(MetricsData{
+		Operation:  OperationSent,
+		Account:    account,
+		SubType:    subType,
+		Success:    err == nil,
+		Duration:   duration,
+		ErrorType:  metricErrorType(err),
+		RetryCount: retries,
+	})
+}
+
+// emitMetric completes data with the provider and queue size and hands it to
+// the metrics collector, if any.
+func (pd *ProviderDecorator) emitMetric(data MetricsData) {
+	if pd.middleware == nil || pd.middleware.Metrics == nil {
+		return
+	}
+	data.Provider = pd.Provider.Name()
+	if pd.middleware.Queue != nil {
+		data.QueueSize = pd.middleware.Queue.Size()
+	}
+	pd.middleware.Metrics.RecordSendResult(data)
+}
+
+// metricErrorType derives a low-cardinality MetricsData.ErrorType from err:
+// the SenderError code when available, otherwise a coarse category.
+func metricErrorType(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "context"
+	}
+	if code := GetSenderErrorCode(err); code != ErrCodeUnknown {
+		return strconv.Itoa(int(code))
 	}
+	return "error"
 }
@@ -3,10 +3,12 @@ package core_test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/queue"
 )
 
 type fakeProvider struct {
@@ -82,3 +84,274 @@ func TestProviderDecorator_Close_Idempotent(_ *testing.T) {
 	_ = pd.Close()
 	_ = pd.Close()
 }
+
+func TestProviderDecorator_Send_AsyncDelayed(t *testing.T) {
+	p := &fakeProvider{name: "p6"}
+	pd := core.NewProviderDecorator(p, nil, &core.NoOpLogger{})
+	defer pd.Close()
+
+	ch := make(chan time.Time, 1)
+	start := time.Now()
+	_, err := pd.Send(
+		context.Background(),
+		&fakeMessage{},
+		core.WithSendAsync(true),
+		core.WithSendDelay(30*time.Millisecond),
+		core.WithSendCallback(func(*core.SendResult, error) { ch <- time.Now() }),
+	)
+	if err != nil {
+		t.Fatalf("Async Send should succeed, got %v", err)
+	}
+	select {
+	case at := <-ch:
+		if at.Sub(start) < 30*time.Millisecond {
+			t.Errorf("delayed send fired too early after %v", at.Sub(start))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("delayed callback not called")
+	}
+}
+
+func TestProviderDecorator_Close_CancelsDelayed(t *testing.T) {
+	p := &fakeProvider{name: "p7"}
+	pd := core.NewProviderDecorator(p, nil, &core.NoOpLogger{})
+
+	ch := make(chan error, 1)
+	_, err := pd.Send(
+		context.Background(),
+		&fakeMessage{},
+		core.WithSendAsync(true),
+		core.WithSendDelay(time.Hour),
+		core.WithSendCallback(func(_ *core.SendResult, err error) { ch <- err }),
+	)
+	if err != nil {
+		t.Fatalf("Async Send should succeed, got %v", err)
+	}
+	_ = pd.Close()
+	select {
+	case err := <-ch:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending delayed send not cancelled on Close")
+	}
+}
+
+func TestProviderDecorator_QueueEviction(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](1, queue.WithOverflowPolicy(queue.OverflowEvictOldest))
+	blocker := &blockingProvider{name: "p8", release: make(chan struct{})}
+	pd := core.NewProviderDecorator(blocker, &core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+	defer func() {
+		close(blocker.release)
+		_ = pd.Close()
+	}()
+
+	// The first message occupies the worker; the next two compete for the single slot.
+	_, _ = pd.Send(context.Background(), &fakeMessage{}, core.WithSendAsync())
+	time.Sleep(20 * time.Millisecond)
+
+	evicted := make(chan error, 1)
+	_, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendAsync(),
+		core.WithSendCallback(func(_ *core.SendResult, err error) { evicted <- err }))
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if _, err = pd.Send(context.Background(), &fakeMessage{}, core.WithSendAsync()); err != nil {
+		t.Fatalf("enqueue with eviction failed: %v", err)
+	}
+
+	select {
+	case err := <-evicted:
+		if core.GetSenderErrorCode(err) != core.ErrCodeQueueItemEvicted {
+			t.Errorf("expected eviction error code, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("eviction not reported to callback")
+	}
+}
+
+type blockingProvider struct {
+	name    string
+	release chan struct{}
+}
+
+func (b *blockingProvider) Send(ctx context.Context, _ core.Message, _ *core.ProviderSendOptions) (*core.SendResult, error) {
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+	}
+	return nil, nil
+}
+func (b *blockingProvider) Name() string { return b.name }
+
+func TestProviderDecorator_Cancel(t *testing.T) {
+	pd := core.NewProviderDecorator(&fakeProvider{name: "p9"}, nil, &core.NoOpLogger{})
+	defer pd.Close()
+
+	ch := make(chan error, 1)
+	msg := &fakeMessage{}
+	_, _ = pd.Send(context.Background(), msg, core.WithSendAsync(), core.WithSendDelay(time.Hour),
+		core.WithSendCallback(func(_ *core.SendResult, err error) { ch <- err }))
+
+	if err := pd.Cancel(context.Background(), msg.MsgID()); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	select {
+	case err := <-ch:
+		if core.GetSenderErrorCode(err) != core.ErrCodeQueueItemCancelled {
+			t.Errorf("expected cancelled error, got %v", err)
+		}
+	default:
+		t.Error("callback not invoked on cancel")
+	}
+	if err := pd.Cancel(context.Background(), msg.MsgID()); core.GetSenderErrorCode(err) != core.ErrCodeQueueItemNotFound {
+		t.Errorf("expected not found on second cancel, got %v", err)
+	}
+}
+
+func TestProviderDecorator_Cancel_Queue(t *testing.T) {
+	queues := map[string]core.Queue{
+		"memory": queue.NewMemoryQueue[*core.QueueItem](0),
+		"fair":   queue.NewFairQueue[*core.QueueItem](0, queue.LaneByCategory()),
+	}
+	for name, q := range queues {
+		t.Run(name, func(t *testing.T) {
+			pd := core.NewProviderDecorator(&fakeProvider{name: "p10"}, &core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+			defer pd.Close()
+
+			msg := &fakeMessage{}
+			_, _ = pd.Send(context.Background(), msg, core.WithSendAsync(), core.WithSendDelay(time.Hour))
+			if q.Size() != 1 {
+				t.Fatalf("expected scheduled item in queue, size=%d", q.Size())
+			}
+			if err := pd.Cancel(context.Background(), msg.MsgID()); err != nil {
+				t.Fatalf("Cancel failed: %v", err)
+			}
+			if q.Size() != 0 {
+				t.Errorf("expected queue to be empty after cancel, size=%d", q.Size())
+			}
+		})
+	}
+}
+
+func TestProviderDecorator_Cancel_SharedQueue(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	owner := core.NewProviderDecorator(&fakeProvider{name: "p10a"}, &core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+	defer owner.Close()
+	other := core.NewProviderDecorator(&fakeProvider{name: "p10b"}, &core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+	defer other.Close()
+
+	msg := &fakeMessage{}
+	_, _ = owner.Send(context.Background(), msg, core.WithSendAsync(), core.WithSendDelay(time.Hour))
+	if err := other.Cancel(context.Background(), msg.MsgID()); core.GetSenderErrorCode(err) != core.ErrCodeQueueItemNotFound {
+		t.Errorf("expected not found for another provider's item, got %v", err)
+	}
+	if q.Size() != 1 {
+		t.Fatalf("item of another provider was removed, size=%d", q.Size())
+	}
+	if err := owner.Cancel(context.Background(), msg.MsgID()); err != nil {
+		t.Errorf("Cancel failed: %v", err)
+	}
+}
+
+func TestProviderDecorator_PurgeResolvesFutures(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	pd := core.NewProviderDecorator(&fakeProvider{name: "p10c"}, &core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	future, err := pd.SendAsync(context.Background(), &fakeMessage{}, core.WithSendDelay(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := q.Purge(context.Background(), "p10c"); n != 1 {
+		t.Fatalf("expected one purged item, got %d", n)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = future.Wait(ctx); core.GetSenderErrorCode(err) != core.ErrCodeQueueItemCancelled {
+		t.Errorf("expected a cancelled error, got %v", err)
+	}
+	if future.Status() != core.SendStatusCancelled {
+		t.Errorf("expected cancelled status, got %s", future.Status())
+	}
+}
+
+func TestProviderDecorator_ClosedStopsHandlingEvictions(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	old := core.NewProviderDecorator(&fakeProvider{name: "p10d"}, &core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+	_ = old.Close()
+	// A replacement with the same name shares the queue.
+	pd := core.NewProviderDecorator(&fakeProvider{name: "p10d"}, &core.SenderMiddleware{Queue: q}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	var calls atomic.Int32
+	_, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendAsync(), core.WithSendDelay(time.Hour),
+		core.WithSendCallback(func(*core.SendResult, error) { calls.Add(1) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := q.Purge(context.Background(), "p10d"); n != 1 {
+		t.Fatalf("expected one purged item, got %d", n)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the callback to run once, got %d", n)
+	}
+}
+
+func TestProviderDecorator_ExpiredAtDequeue(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	dlq := queue.NewMemoryQueue[*core.QueueItem](0)
+	pd := core.NewProviderDecorator(&fakeProvider{name: "p11"},
+		&core.SenderMiddleware{Queue: q, DeadLetter: dlq}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	ch := make(chan error, 1)
+	_, err := pd.Send(context.Background(), &fakeMessage{},
+		core.WithSendAsync(),
+		core.WithSendDelay(20*time.Millisecond),
+		core.WithSendTTL(10*time.Millisecond),
+		core.WithSendCallback(func(_ *core.SendResult, err error) { ch <- err }))
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	select {
+	case err = <-ch:
+		if core.GetSenderErrorCode(err) != core.ErrCodeMessageExpired {
+			t.Errorf("expected expired error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("callback not invoked for expired message")
+	}
+	if dlq.Size() != 1 {
+		t.Errorf("expected expired message in dead-letter queue, size=%d", dlq.Size())
+	}
+}
+
+type slowFailProvider struct{ delay time.Duration }
+
+func (p *slowFailProvider) Send(context.Context, core.Message, *core.ProviderSendOptions) (*core.SendResult, error) {
+	time.Sleep(p.delay)
+	return nil, errors.New("fail")
+}
+func (p *slowFailProvider) Name() string { return "slow" }
+
+func TestProviderDecorator_ExpiredBeforeRetry(t *testing.T) {
+	retry := core.NewRetryPolicy(
+		core.WithRetryMaxAttempts(5),
+		core.WithRetryFilter(func(int, error) bool { return true }),
+	)
+	pd := core.NewProviderDecorator(&slowFailProvider{delay: 20 * time.Millisecond},
+		&core.SenderMiddleware{Retry: retry}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	start := time.Now()
+	_, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendTTL(10*time.Millisecond))
+	if core.GetSenderErrorCode(err) != core.ErrCodeMessageExpired {
+		t.Errorf("expected expired error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected no retries after expiry, took %v", elapsed)
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quota is a vendor rate limit: at most Limit messages per Per. In text form,
+// as used in config files, it is written "20/1m" or "30/s".
+type Quota struct {
+	Limit int
+	Per   time.Duration
+}
+
+// ParseQuota parses a quota such as "20/1m", "30/s" or "10/24h".
+func ParseQuota(s string) (Quota, error) {
+	limit, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Quota{}, fmt.Errorf("invalid quota %q: expected <limit>/<duration>", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n <= 0 {
+		return Quota{}, fmt.Errorf("invalid quota %q: limit must be a positive integer", s)
+	}
+	per = strings.TrimSpace(per)
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per // "30/s" means 30 per second
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Quota{}, fmt.Errorf("invalid quota %q: period must be a positive duration", s)
+	}
+	return Quota{Limit: n, Per: d}, nil
+}
+
+// String returns the quota in the form accepted by ParseQuota.
+func (q Quota) String() string {
+	per := q.Per.String()
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{{time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}} {
+		if q.Per > 0 && q.Per%unit.d == 0 {
+			per = strconv.FormatInt(int64(q.Per/unit.d), 10) + unit.name
+			break
+		}
+	}
+	return strconv.Itoa(q.Limit) + "/" + per
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (q Quota) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (q *Quota) UnmarshalText(text []byte) error {
+	parsed, err := ParseQuota(string(text))
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+// Quotas are several limits on the same messages that all apply together, such as
+// "1/1m,5/1h,10/24h": at most one message per minute, five per hour and ten per day.
+// In text form the quotas are separated by commas.
+type Quotas []Quota
+
+// ParseQuotas parses comma-separated quotas such as "1/1m,5/1h,10/24h".
+func ParseQuotas(s string) (Quotas, error) {
+	var quotas Quotas
+	for _, part := range strings.Split(s, ",") {
+		q, err := ParseQuota(part)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, q)
+	}
+	return quotas, nil
+}
+
+// String returns the quotas in the form accepted by ParseQuotas.
+func (qs Quotas) String() string {
+	parts := make([]string, 0, len(qs))
+	for _, q := range qs {
+		parts = append(parts, q.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (qs Quotas) MarshalText() ([]byte, error) {
+	return []byte(qs.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (qs *Quotas) UnmarshalText(text []byte) error {
+	parsed, err := ParseQuotas(string(text))
+	if err != nil {
+		return err
+	}
+	*qs = parsed
+	return nil
+}
+
+// QuotaProfile holds the rate limits of a provider or sub-provider: those of each
+// account (webhook, bot or app key), and those of each recipient (phone number or
+// chat) of an account. A message passes only when it is within every quota; empty
+// quotas are not limited.
+type QuotaProfile struct {
+	Account   Quotas `json:"account,omitempty"   yaml:"account,omitempty"`
+	Recipient Quotas `json:"recipient,omitempty" yaml:"recipient,omitempty"`
+	// Disabled turns off the quotas, including the built-in ones, when set on an account.
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// Override returns p with the quotas set in o replacing its own. A nil o returns p.
+func (p QuotaProfile) Override(o *QuotaProfile) QuotaProfile {
+	switch {
+	case o == nil:
+		return p
+	case o.Disabled:
+		return QuotaProfile{Disabled: true}
+	}
+	if len(o.Account) > 0 {
+		p.Account = o.Account
+	}
+	if len(o.Recipient) > 0 {
+		p.Recipient = o.Recipient
+	}
+	return p
+}
+
+// QuotaAware is implemented by accounts that override the quotas of their provider.
+// AccountMeta implements it through its Quota field.
+type QuotaAware interface {
+	GetQuota() *QuotaProfile
+}
@@ -0,0 +1,74 @@
+package core_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+func TestParseQuota(t *testing.T) {
+	cases := []struct {
+		in   string
+		want core.Quota
+		str  string
+	}{
+		{"20/1m", core.Quota{Limit: 20, Per: time.Minute}, "20/1m"},
+		{"30/s", core.Quota{Limit: 30, Per: time.Second}, "30/1s"},
+		{" 10 / 24h ", core.Quota{Limit: 10, Per: 24 * time.Hour}, "10/24h"},
+		{"5/500ms", core.Quota{Limit: 5, Per: 500 * time.Millisecond}, "5/500ms"},
+	}
+	for _, tc := range cases {
+		got, err := core.ParseQuota(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseQuota(%q) = %+v, %v; want %+v", tc.in, got, err, tc.want)
+			continue
+		}
+		if got.String() != tc.str {
+			t.Errorf("String() = %q, want %q", got.String(), tc.str)
+		}
+	}
+	for _, bad := range []string{"20", "0/1m", "x/1m", "20/0s", "20/forever"} {
+		if _, err := core.ParseQuota(bad); err == nil {
+			t.Errorf("ParseQuota(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseQuotas(t *testing.T) {
+	qs, err := core.ParseQuotas("1/1m, 5/1h,10/24h")
+	if err != nil || len(qs) != 3 || qs[2] != (core.Quota{Limit: 10, Per: 24 * time.Hour}) {
+		t.Fatalf("ParseQuotas = %v, %v", qs, err)
+	}
+	if qs.String() != "1/1m,5/1h,10/24h" {
+		t.Errorf("unexpected text form %q", qs.String())
+	}
+	if _, err = core.ParseQuotas("1/1m,"); err == nil {
+		t.Error("an empty quota should fail")
+	}
+}
+
+func TestAccountMeta_QuotaFromJSON(t *testing.T) {
+	var meta core.AccountMeta
+	if err := json.Unmarshal([]byte(`{"name":"bot","quota":{"account":"10/1m,100/1h"}}`), &meta); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	builtIn := core.QuotaProfile{
+		Account:   core.Quotas{{Limit: 20, Per: time.Minute}},
+		Recipient: core.Quotas{{Limit: 1, Per: time.Second}},
+	}
+
+	got := builtIn.Override(meta.GetQuota())
+	if got.Account.String() != "10/1m,100/1h" || got.Recipient.String() != builtIn.Recipient.String() {
+		t.Errorf("unexpected profile: account %v, recipient %v", got.Account, got.Recipient)
+	}
+	if got := builtIn.Override(&core.QuotaProfile{Disabled: true}); got.Account != nil || got.Recipient != nil {
+		t.Errorf("a disabled override should drop every quota, got %+v", got)
+	}
+
+	data, err := json.Marshal(meta.Quota)
+	if err != nil || string(data) != `{"account":"10/1m,100/1h"}` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
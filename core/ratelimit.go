@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// RateLimitWait makes sends wait for the rate limiter, and for the account and
+// recipient limits of an AccountGuard such as the built-in vendor quotas, instead
+// of failing at once with ErrCodeRateLimitExceeded.
+type RateLimitWait struct {
+	// MaxWait is how long a send may wait for its tokens. Sends that would have to
+	// wait longer fail at once. A negative value waits as long as the context allows.
+	MaxWait time.Duration
+	// InQueue makes async sends, run by queue workers or background goroutines,
+	// wait as long as needed regardless of MaxWait: nobody is waiting for their
+	// result, so there is no point in failing them.
+	InQueue bool
+}
+
+// maxWait returns how long a send may wait; negative means without limit.
+func (w *RateLimitWait) maxWait(async bool) time.Duration {
+	switch {
+	case w == nil:
+		return 0
+	case async && w.InQueue:
+		return -1
+	default:
+		return w.MaxWait
+	}
+}
+
+type rateLimitWaitKey struct{}
+
+// withRateLimitWait stores into ctx how long the send may wait for rate limits,
+// so that the account and recipient limits applied during account selection
+// honour the RateLimitWait of the sender too.
+func withRateLimitWait(ctx context.Context, maxWait time.Duration) context.Context {
+	return context.WithValue(ctx, rateLimitWaitKey{}, maxWait)
+}
+
+// rateLimitWaitFrom returns how long the send in ctx may wait for rate limits;
+// zero when it may not wait, negative when it may wait without limit.
+func rateLimitWaitFrom(ctx context.Context) time.Duration {
+	maxWait, _ := ctx.Value(rateLimitWaitKey{}).(time.Duration)
+	return maxWait
+}
+
+// Reservation is a claim on rate limiter tokens that may only become available
+// later. *rate.Reservation from golang.org/x/time/rate satisfies it.
+type Reservation interface {
+	// OK reports whether the tokens can be granted at all; it is false when more
+	// tokens were requested than the limiter ever holds.
+	OK() bool
+	// Delay returns how long to wait before the tokens may be used.
+	Delay() time.Duration
+	// Cancel gives back the tokens, as far as possible.
+	Cancel()
+}
+
+// ReservingRateLimiter is a RateLimiter that can reserve several tokens at once,
+// so that a batch send either gets all its tokens or none. A batch larger than
+// the limiter ever holds is rejected.
+type ReservingRateLimiter interface {
+	RateLimiter
+	// ReserveN reserves n tokens. The caller must wait Delay before acting, or
+	// Cancel the reservation.
+	ReserveN(n int) Reservation
+}
+
+// BurstLimiter is implemented by ReservingRateLimiters that can tell the largest
+// number of tokens a single reservation may take, e.g. the burst of a token
+// bucket, so that rejected batches report it.
+type BurstLimiter interface {
+	Burst() int
+}
+
+// AdaptiveRateLimiter is a RateLimiter that adapts its rate to the outcome of the
+// sends it let through, e.g. slowing down when the vendor throttles (see
+// IsThrottled). The sender's RateLimiter and the account limiters of an
+// AccountGuard are told the outcome of every send when they implement it.
+type AdaptiveRateLimiter interface {
+	RateLimiter
+	// Observe reports the outcome of a send; err is nil on success.
+	Observe(err error)
+}
+
+// observeRateLimit reports the outcome of a send to rl if it adapts to it. Sends
+// cancelled by the caller say nothing about the rate.
+func observeRateLimit(rl RateLimiter, err error) {
+	if adaptive, ok := rl.(AdaptiveRateLimiter); ok && !errors.Is(err, context.Canceled) {
+		adaptive.Observe(err)
+	}
+}
+
+// RateLimitWeighted is implemented by messages that take more than one rate
+// limiter token, such as an SMS to several recipients. Limiters that do not
+// implement ReservingRateLimiter charge one token per message regardless.
+type RateLimitWeighted interface {
+	RateLimitTokens() int
+}
+
+// rateLimitTokens returns the number of tokens message takes.
+func rateLimitTokens(message Message) int {
+	if weighted, ok := message.(RateLimitWeighted); ok {
+		return max(weighted.RateLimitTokens(), 1)
+	}
+	return 1
+}
+
+// acquireRateLimit takes n tokens from rl, waiting at most maxWait for them
+// (without limit if maxWait is negative).
+func acquireRateLimit(ctx context.Context, rl RateLimiter, n int, maxWait time.Duration) error {
+	if reserver, ok := rl.(ReservingRateLimiter); ok {
+		return reserveRateLimit(ctx, reserver, n, maxWait)
+	}
+
+	if rl.Allow() {
+		return nil
+	}
+	if maxWait == 0 {
+		return NewSenderError(ErrCodeRateLimitExceeded, "rate limit exceeded", nil)
+	}
+	waitCtx := ctx
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+	if err := rl.Wait(waitCtx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return NewSenderError(ErrCodeRateLimitExceeded, "rate limit exceeded", err)
+	}
+	return nil
+}
+
+func reserveRateLimit(ctx context.Context, rl ReservingRateLimiter, n int, maxWait time.Duration) error {
+	r := rl.ReserveN(n)
+	if !r.OK() {
+		if bl, ok := rl.(BurstLimiter); ok {
+			return NewSenderErrorf(ErrCodeRateLimitExceeded,
+				"rate limit exceeded: %d tokens exceed the limiter's burst of %d", n, bl.Burst())
+		}
+		return NewSenderErrorf(ErrCodeRateLimitExceeded, "rate limit exceeded: %d tokens exceed the limiter's capacity", n)
+	}
+	delay := r.Delay()
+	if delay <= 0 {
+		return nil
+	}
+	if maxWait >= 0 && delay > maxWait {
+		r.Cancel()
+		if maxWait == 0 {
+			return NewSenderError(ErrCodeRateLimitExceeded, "rate limit exceeded", nil)
+		}
+		return NewSenderErrorf(ErrCodeRateLimitExceeded, "rate limit exceeded: tokens available in %s, more than %s", delay, maxWait)
+	}
+	return waitReservation(ctx, r, delay)
+}
+
+// waitReservation waits delay for r, cancelling it if ctx is done first.
+func waitReservation(ctx context.Context, r Reservation, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// RateLimitRequest describes a send to a RequestRateLimiter.
+type RateLimitRequest struct {
+	Message  Message
+	Provider ProviderType
+	// Account is the account chosen with WithSendAccount; it is empty when the
+	// provider picks one itself.
+	Account string
+	// Recipient is the message's RoutingKey (see RoutingKeyer), if it has one.
+	Recipient string
+	// Metadata is the Metadata of the SendOptions, e.g. a tenant ID.
+	Metadata map[string]interface{}
+	// Tokens is the number of tokens the send takes (see RateLimitWeighted).
+	Tokens int
+}
+
+// newRateLimitRequest describes sending message with opts.
+func newRateLimitRequest(message Message, opts *SendOptions) *RateLimitRequest {
+	req := &RateLimitRequest{
+		Message:  message,
+		Provider: message.ProviderType(),
+		Account:  opts.AccountName,
+		Metadata: opts.Metadata,
+		Tokens:   rateLimitTokens(message),
+	}
+	if keyer, ok := message.(RoutingKeyer); ok {
+		req.Recipient = keyer.RoutingKey()
+	}
+	return req
+}
+
+// RequestRateLimiter is a RateLimiter that limits sends by what they send and to
+// whom, e.g. per provider, account, recipient and tenant at once. The sender
+// middleware prefers ReserveRequest over the plain RateLimiter methods.
+type RequestRateLimiter interface {
+	RateLimiter
+	// ReserveRequest reserves req.Tokens in every limit applying to req, or in
+	// none. When a limit cannot grant them within maxWait (a negative maxWait
+	// waits without limit), it returns a *RateLimitRejection. Otherwise the
+	// caller must wait Delay before sending, or Cancel the reservation.
+	ReserveRequest(req *RateLimitRequest, maxWait time.Duration) (Reservation, error)
+}
+
+// RateLimitRejection reports which limit of a RequestRateLimiter rejected a send.
+type RateLimitRejection struct {
+	// Dimension names the limit, e.g. "recipient".
+	Dimension string
+	// Key is the value the send was limited by, e.g. the phone number.
+	Key string
+	// Delay is how long the send would have had to wait; zero when it can never pass.
+	Delay time.Duration
+}
+
+func (r *RateLimitRejection) Error() string {
+	msg := r.Dimension + " limit"
+	if r.Key != "" {
+		msg = r.Dimension + " " + strconv.Quote(r.Key) + " limit"
+	}
+	if r.Delay > 0 {
+		return msg + " reached, tokens available in " + r.Delay.String()
+	}
+	return msg + " reached"
+}
+
+// acquireRequestRateLimit waits for the reservation of rl for req.
+func acquireRequestRateLimit(ctx context.Context, rl RequestRateLimiter, req *RateLimitRequest, maxWait time.Duration) error {
+	r, err := rl.ReserveRequest(req, maxWait)
+	if err != nil {
+		return NewSenderError(ErrCodeRateLimitExceeded, "rate limit exceeded", err)
+	}
+	return waitReservation(ctx, r, r.Delay())
+}
@@ -0,0 +1,179 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/ratelimiter"
+)
+
+type batchMessage struct {
+	fakeMessage
+
+	recipients int
+}
+
+func (m *batchMessage) RateLimitTokens() int { return m.recipients }
+
+func newLimitedDecorator(rl core.RateLimiter, wait *core.RateLimitWait) *core.ProviderDecorator {
+	return core.NewProviderDecorator(&fakeProvider{name: "limited"},
+		&core.SenderMiddleware{RateLimiter: rl, RateLimitWait: wait}, &core.NoOpLogger{})
+}
+
+func TestRateLimit_FailsFastByDefault(t *testing.T) {
+	pd := newLimitedDecorator(ratelimiter.NewTokenBucketRateLimiter(1, 1), nil)
+	ctx := context.Background()
+
+	if _, err := pd.Send(ctx, &fakeMessage{}); err != nil {
+		t.Fatalf("first send should pass: %v", err)
+	}
+	if _, err := pd.Send(ctx, &fakeMessage{}); core.GetSenderErrorCode(err) != core.ErrCodeRateLimitExceeded {
+		t.Errorf("expected ErrCodeRateLimitExceeded, got %v", err)
+	}
+}
+
+func TestRateLimit_WaitsUpToMaxWait(t *testing.T) {
+	pd := newLimitedDecorator(ratelimiter.NewTokenBucketRateLimiter(20, 1),
+		&core.RateLimitWait{MaxWait: 500 * time.Millisecond})
+	ctx := context.Background()
+
+	_, _ = pd.Send(ctx, &fakeMessage{})
+	start := time.Now()
+	if _, err := pd.Send(ctx, &fakeMessage{}); err != nil {
+		t.Fatalf("second send should wait for a token: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected the send to wait, took %v", elapsed)
+	}
+}
+
+func TestRateLimit_FailsWhenWaitTooLong(t *testing.T) {
+	pd := newLimitedDecorator(ratelimiter.NewTokenBucketRateLimiter(1, 1),
+		&core.RateLimitWait{MaxWait: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	_, _ = pd.Send(ctx, &fakeMessage{})
+	start := time.Now()
+	_, err := pd.Send(ctx, &fakeMessage{})
+	if core.GetSenderErrorCode(err) != core.ErrCodeRateLimitExceeded {
+		t.Errorf("expected ErrCodeRateLimitExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("a token a second away should fail at once, took %v", elapsed)
+	}
+}
+
+func TestRateLimit_ReservesTokensPerRecipient(t *testing.T) {
+	rl, err := ratelimiter.NewSlidingWindowRateLimiter(time.Minute, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd := newLimitedDecorator(rl, nil)
+	ctx := context.Background()
+
+	if _, err = pd.Send(ctx, &batchMessage{recipients: 2}); err != nil {
+		t.Fatalf("first batch should pass: %v", err)
+	}
+	if _, err = pd.Send(ctx, &batchMessage{recipients: 2}); core.GetSenderErrorCode(err) != core.ErrCodeRateLimitExceeded {
+		t.Errorf("second batch should exceed the limit, got %v", err)
+	}
+	if _, err = pd.Send(ctx, &batchMessage{recipients: 1}); err != nil {
+		t.Errorf("the cancelled reservation should have freed its tokens: %v", err)
+	}
+	if _, err = pd.Send(ctx, &batchMessage{recipients: 4}); core.GetSenderErrorCode(err) != core.ErrCodeRateLimitExceeded {
+		t.Errorf("a batch exceeding the full window must fail, got %v", err)
+	}
+}
+
+func TestRateLimit_BatchLargerThanBurst(t *testing.T) {
+	window, err := ratelimiter.NewSlidingWindowRateLimiter(time.Minute, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		rl   core.RateLimiter
+		n    int
+	}{
+		{"token bucket", ratelimiter.NewTokenBucketRateLimiter(0.0001, 10), 50},
+		{"sliding window", window, 50},
+		{"aimd", ratelimiter.NewAIMDRateLimiter(0.0001), 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pd := newLimitedDecorator(tc.rl, &core.RateLimitWait{MaxWait: time.Second})
+			_, err := pd.Send(context.Background(), &batchMessage{recipients: tc.n})
+			if core.GetSenderErrorCode(err) != core.ErrCodeRateLimitExceeded {
+				t.Fatalf("a batch larger than the burst must be rejected, even when sends may wait: %v", err)
+			}
+			if _, err := pd.Send(context.Background(), &fakeMessage{}); err != nil {
+				t.Errorf("the rejected batch should not have taken any token, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRateLimit_AsyncWaitsInQueue(t *testing.T) {
+	pd := newLimitedDecorator(ratelimiter.NewTokenBucketRateLimiter(20, 1), &core.RateLimitWait{InQueue: true})
+	defer pd.Close()
+	ctx := context.Background()
+
+	done := make(chan error, 2)
+	for range 2 {
+		_, err := pd.Send(ctx, &fakeMessage{}, core.WithSendAsync(true),
+			core.WithSendCallback(func(_ *core.SendResult, err error) { done <- err }))
+		if err != nil {
+			t.Fatalf("async send failed: %v", err)
+		}
+	}
+	for range 2 {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("async sends should wait for tokens, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("async send did not complete")
+		}
+	}
+}
+
+type recipientMessage struct {
+	fakeMessage
+
+	to string
+}
+
+func (m *recipientMessage) RoutingKey() string { return m.to }
+
+func TestRateLimit_RequestLimiterSeesMessageAndOptions(t *testing.T) {
+	rl := ratelimiter.NewCompositeRateLimiter(
+		ratelimiter.WithKeyedLimit("tenant", ratelimiter.MetadataKey("tenant_id"), func(string) core.ReservingRateLimiter {
+			return ratelimiter.NewTokenBucketRateLimiter(0.001, 2)
+		}),
+		ratelimiter.WithKeyedLimit("recipient", ratelimiter.RecipientKey, func(string) core.ReservingRateLimiter {
+			return ratelimiter.NewTokenBucketRateLimiter(0.001, 1)
+		}),
+	)
+	pd := newLimitedDecorator(rl, nil)
+	ctx := context.Background()
+	tenant := core.WithSendMetadata("tenant_id", "acme")
+
+	if _, err := pd.Send(ctx, &recipientMessage{to: "alice"}, tenant); err != nil {
+		t.Fatalf("first send should pass: %v", err)
+	}
+	_, err := pd.Send(ctx, &recipientMessage{to: "alice"}, tenant)
+	var rejection *core.RateLimitRejection
+	if core.GetSenderErrorCode(err) != core.ErrCodeRateLimitExceeded || !errors.As(err, &rejection) || rejection.Dimension != "recipient" {
+		t.Fatalf("expected a recipient rejection, got %v", err)
+	}
+	if _, err = pd.Send(ctx, &recipientMessage{to: "bob"}, tenant); err != nil {
+		t.Fatalf("the recipient rejection should not have used tenant tokens: %v", err)
+	}
+	if _, err = pd.Send(ctx, &recipientMessage{to: "carol"}, tenant); !errors.As(err, &rejection) || rejection.Dimension != "tenant" {
+		t.Errorf("expected a tenant rejection, got %v", err)
+	}
+}
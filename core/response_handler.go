@@ -9,6 +9,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ---------------- New minimal config -------------------------------------
@@ -46,6 +47,14 @@ type ResponseHandlerConfig struct {
 	CodePath string            `json:"code_path,omitempty"`
 	MsgPath  string            `json:"msg_path,omitempty"`
 	CodeMap  map[string]string `json:"code_map,omitempty"`
+	// ErrorKinds classifies the vendor's codes (at CodePath). Failures are
+	// returned as a *VendorError; those with an unlisted code stay unclassified,
+	// unless their HTTP status failed, which StatusErrorKind classifies.
+	ErrorKinds ErrorKinds `json:"error_kinds,omitempty"`
+	// RetryAfterPath is the path of a body field giving the seconds to wait before
+	// retrying a failure, e.g. Telegram's "parameters.retry_after". A Retry-After
+	// header takes precedence. The delay is returned as a RetryAfterError.
+	RetryAfterPath string `json:"retry_after_path,omitempty"`
 }
 
 // ---------------- Core handler -------------------------------------------
@@ -63,21 +72,13 @@ func NewSendResultHandler(cfg *ResponseHandlerConfig) SendResultHandler {
 		cfg.CodePath = cfg.Path
 	}
 
-	return func(result *SendResult) error {
+	check := func(result *SendResult) error {
 		if result == nil {
 			return errors.New("result is nil")
 		}
 
 		bodyBytes := result.Body
 
-		// 1. HTTP status
-		if !isStatusOK(cfg.AcceptStatus, result.StatusCode) {
-			return fmt.Errorf("HTTP status %d not acceptable", result.StatusCode)
-		}
-		if !cfg.CheckBody {
-			return nil
-		}
-
 		// Determine body type
 		bType := cfg.BodyType
 		if bType == BodyTypeNone {
@@ -88,6 +89,14 @@ func NewSendResultHandler(cfg *ResponseHandlerConfig) SendResultHandler {
 			bType = detectBodyType(ct)
 		}
 
+		// 1. HTTP status
+		if !isStatusOK(cfg.AcceptStatus, result.StatusCode) {
+			return vendorError(cfg, bType, result, fmt.Sprintf("HTTP status %d not acceptable", result.StatusCode))
+		}
+		if !cfg.CheckBody {
+			return nil
+		}
+
 		success, evalErr := evaluateSuccessSimple(cfg, bType, bodyBytes)
 		if evalErr != nil {
 			return evalErr
@@ -97,16 +106,90 @@ func NewSendResultHandler(cfg *ResponseHandlerConfig) SendResultHandler {
 		}
 
 		// Failure – build error message
-		code := extractValue(splitDotPath(cfg.CodePath), bType, bodyBytes)
-		msg := extractValue(splitDotPath(cfg.MsgPath), bType, bodyBytes)
-		if mapped, ok := cfg.CodeMap[code]; ok {
-			msg = mapped
+		return vendorError(cfg, bType, result, "unknown error")
+	}
+
+	return func(result *SendResult) error {
+		err := check(result)
+		if err == nil {
+			return nil
 		}
-		if msg == "" {
-			msg = "unknown error"
+		delay, ok := retryAfterHint(cfg, result)
+		if !ok {
+			return err
 		}
-		return fmt.Errorf("api error: %s (code=%s)", msg, code)
+		return &RetryAfterError{Err: err, Delay: delay}
+	}
+}
+
+// vendorError builds the error for a failed result from the code and message in
+// its body, using fallback when the body has no message.
+func vendorError(cfg *ResponseHandlerConfig, bType BodyType, result *SendResult, fallback string) *VendorError {
+	var code, msg string
+	if cfg.CodePath != "" {
+		code = extractValue(splitDotPath(cfg.CodePath), bType, result.Body)
+	}
+	if cfg.MsgPath != "" {
+		msg = extractValue(splitDotPath(cfg.MsgPath), bType, result.Body)
+	}
+	if mapped, ok := cfg.CodeMap[code]; ok {
+		msg = mapped
+	}
+	if msg == "" {
+		msg = fallback
+	}
+
+	status := isStatusOK(cfg.AcceptStatus, result.StatusCode)
+	if code == "" && !status {
+		code = strconv.Itoa(result.StatusCode)
+	}
+	kind, ok := cfg.ErrorKinds[code]
+	if !ok && !status {
+		kind = StatusErrorKind(result.StatusCode)
+	}
+	return &VendorError{Kind: kind, Code: code, Message: msg, StatusCode: result.StatusCode}
+}
+
+// retryAfterHint returns the delay the vendor asks for before a retry: the
+// Retry-After header, or else the body field at cfg.RetryAfterPath.
+func retryAfterHint(cfg *ResponseHandlerConfig, result *SendResult) (time.Duration, bool) {
+	if result.Headers != nil {
+		if delay, ok := ParseRetryAfter(result.Headers.Get("Retry-After"), time.Now()); ok {
+			return delay, true
+		}
+	}
+	if cfg.RetryAfterPath == "" {
+		return 0, false
+	}
+	bType := cfg.BodyType
+	if bType == BodyTypeNone {
+		ct := ""
+		if result.Headers != nil {
+			ct = result.Headers.Get("Content-Type")
+		}
+		bType = detectBodyType(ct)
+	}
+	return ParseRetryAfter(extractValue(splitDotPath(cfg.RetryAfterPath), bType, result.Body), time.Time{})
+}
+
+// ParseRetryAfter parses a Retry-After value: a number of seconds, or an HTTP
+// date, which is compared to now. It reports false for missing, malformed or
+// past values.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil && !now.IsZero() && at.After(now) {
+		return at.Sub(now), true
 	}
+	return 0, false
 }
 
 func isStatusOK(white []int, code int) bool {
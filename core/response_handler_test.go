@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shellvon/go-sender/core"
 	"github.com/shellvon/go-sender/utils"
@@ -237,3 +238,76 @@ func TestResponseHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseHandler_Throttling(t *testing.T) {
+	dingtalk := &core.ResponseHandlerConfig{
+		CheckBody:  true,
+		BodyType:   core.BodyTypeJSON,
+		Path:       "errcode",
+		Expect:     "0",
+		CodePath:   "errcode",
+		MsgPath:    "errmsg",
+		ErrorKinds: core.ErrorKinds{"130101": core.ErrorKindRateLimited},
+	}
+	tests := []struct {
+		name      string
+		cfg       *core.ResponseHandlerConfig
+		status    int
+		body      string
+		throttled bool
+	}{
+		{"HTTP 429", nil, http.StatusTooManyRequests, ``, true},
+		{"throttle code", dingtalk, http.StatusOK, `{"errcode":130101,"errmsg":"send too fast"}`, true},
+		{"other code", dingtalk, http.StatusOK, `{"errcode":310000,"errmsg":"keywords not in content"}`, false},
+		{"HTTP 500", nil, http.StatusInternalServerError, ``, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := core.NewSendResultHandler(tc.cfg)(&core.SendResult{StatusCode: tc.status, Body: []byte(tc.body)})
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if core.IsThrottled(err) != tc.throttled {
+				t.Errorf("IsThrottled(%v) = %v, want %v", err, !tc.throttled, tc.throttled)
+			}
+		})
+	}
+}
+
+func TestResponseHandler_RetryAfter(t *testing.T) {
+	telegram := &core.ResponseHandlerConfig{
+		CheckBody:      true,
+		BodyType:       core.BodyTypeJSON,
+		Path:           "ok",
+		Expect:         "true",
+		CodePath:       "error_code",
+		ErrorKinds:     core.ErrorKinds{"429": core.ErrorKindRateLimited},
+		RetryAfterPath: "parameters.retry_after",
+	}
+	body := `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`
+	err := core.NewSendResultHandler(telegram)(&core.SendResult{StatusCode: http.StatusOK, Body: []byte(body)})
+	if delay, ok := core.RetryAfter(err); !ok || delay != 7*time.Second || !core.IsThrottled(err) {
+		t.Errorf("expected a throttling error asking for 7s, got %v (%v)", err, delay)
+	}
+
+	headers := http.Header{"Retry-After": []string{"120"}}
+	err = core.NewSendResultHandler(nil)(&core.SendResult{StatusCode: http.StatusServiceUnavailable, Headers: headers})
+	if delay, ok := core.RetryAfter(err); !ok || delay != 2*time.Minute {
+		t.Errorf("expected the Retry-After header to ask for 2m, got %v (%v)", err, delay)
+	}
+	if !core.NewDefaultErrorClassifier().IsRetryableError(err) {
+		t.Error("a hinted 503 should stay retryable")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if d, ok := core.ParseRetryAfter("Mon, 01 Jan 2024 12:00:30 GMT", now); !ok || d != 30*time.Second {
+		t.Errorf("HTTP date: got %v, %v", d, ok)
+	}
+	for _, v := range []string{"", "0", "-5", "soon", "Mon, 01 Jan 2024 11:00:00 GMT"} {
+		if _, ok := core.ParseRetryAfter(v, now); ok {
+			t.Errorf("%q should not parse", v)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRetryBudgetWindow is the period over which a RetryBudget counts attempts.
+const DefaultRetryBudgetWindow = 10 * time.Second
+
+const retryBudgetBuckets = 10
+
+// RetryBudget caps the retries of a provider at a share of its first attempts,
+// so that retries cannot multiply the load on a vendor that is already failing.
+// Every provider registered with a Sender keeps its own count.
+type RetryBudget struct {
+	// Ratio is the number of retries allowed per first attempt, e.g. 0.2 for one
+	// retry every five sends.
+	Ratio float64
+	// MinRetries are allowed in every Window regardless of Ratio, so that a
+	// provider with little traffic can still retry.
+	MinRetries int
+	// Window is the period over which attempts are counted, DefaultRetryBudgetWindow by default.
+	Window time.Duration
+}
+
+// retryBudget counts the attempts of one provider against a RetryBudget.
+type retryBudget struct {
+	RetryBudget
+
+	mu      sync.Mutex
+	buckets [retryBudgetBuckets]retryBudgetBucket
+}
+
+type retryBudgetBucket struct {
+	epoch   int64
+	firsts  int
+	retries int
+}
+
+func newRetryBudget(cfg *RetryBudget) *retryBudget {
+	if cfg == nil {
+		return nil
+	}
+	b := &retryBudget{RetryBudget: *cfg}
+	if b.Window <= 0 {
+		b.Window = DefaultRetryBudgetWindow
+	}
+	return b
+}
+
+// bucket returns the bucket for now, resetting it if it held an older period.
+func (b *retryBudget) bucket(now time.Time) *retryBudgetBucket {
+	epoch := now.UnixNano() / int64(b.Window/retryBudgetBuckets)
+	bucket := &b.buckets[epoch%retryBudgetBuckets]
+	if bucket.epoch != epoch {
+		*bucket = retryBudgetBucket{epoch: epoch}
+	}
+	return bucket
+}
+
+// recordAttempt counts a first attempt.
+func (b *retryBudget) recordAttempt() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket(time.Now()).firsts++
+}
+
+// refundRetry gives back a retry counted by tryRetry that was not made.
+func (b *retryBudget) refundRetry() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if bucket := b.bucket(time.Now()); bucket.retries > 0 {
+		bucket.retries--
+	}
+}
+
+// tryRetry counts a retry and reports whether the budget allows it.
+func (b *retryBudget) tryRetry() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	current := b.bucket(now)
+	oldest := current.epoch - retryBudgetBuckets
+	var firsts, retries int
+	for _, bucket := range b.buckets {
+		if bucket.epoch > oldest {
+			firsts += bucket.firsts
+			retries += bucket.retries
+		}
+	}
+	if retries >= b.MinRetries && float64(retries) >= b.Ratio*float64(firsts) {
+		return false
+	}
+	current.retries++
+	return true
+}
@@ -0,0 +1,77 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+type countingProvider struct {
+	fakeProvider
+
+	calls int
+}
+
+func (p *countingProvider) Send(ctx context.Context, msg core.Message, opts *core.ProviderSendOptions) (*core.SendResult, error) {
+	p.calls++
+	return p.fakeProvider.Send(ctx, msg, opts)
+}
+
+func TestRetryBudget_CapsRetries(t *testing.T) {
+	provider := &countingProvider{fakeProvider: fakeProvider{name: "down", sendErr: core.NetworkError{Err: errors.New("connection refused")}}}
+	pd := core.NewProviderDecorator(provider, &core.SenderMiddleware{
+		Retry: core.NewRetryPolicy(
+			core.WithRetryMaxAttempts(3),
+			core.WithRetryInitialDelay(0),
+			core.WithRetryMaxDelay(time.Millisecond),
+		),
+		RetryBudget: &core.RetryBudget{Ratio: 0.5, MinRetries: 2},
+	}, &core.NoOpLogger{})
+
+	exhausted := 0
+	for range 4 {
+		_, err := pd.Send(context.Background(), &fakeMessage{})
+		if core.GetSenderErrorCode(err) == core.ErrCodeRetryBudgetExhausted {
+			exhausted++
+		}
+	}
+	// The first send spends the 2 minimum retries, and 4 first attempts at a
+	// ratio of 0.5 allow no more; without a budget there would be 12 retries.
+	if retries := provider.calls - 4; retries != 2 {
+		t.Errorf("expected 2 retries, got %d", retries)
+	}
+	if exhausted != 4 {
+		t.Errorf("expected every send to report the exhausted budget, got %d", exhausted)
+	}
+}
+
+func TestRetryBudget_NotSpentWhenGivingUp(t *testing.T) {
+	throttled := core.ThrottledError{Err: &core.RetryAfterError{Err: errors.New("HTTP 429"), Delay: time.Minute}}
+	provider := &countingProvider{fakeProvider: fakeProvider{name: "throttled", sendErr: throttled}}
+	pd := core.NewProviderDecorator(provider, &core.SenderMiddleware{
+		Retry: core.NewRetryPolicy(
+			core.WithRetryMaxAttempts(1),
+			core.WithRetryInitialDelay(0),
+			core.WithRetryMaxDelay(time.Millisecond),
+		),
+		RetryBudget: &core.RetryBudget{MinRetries: 1},
+	}, &core.NoOpLogger{})
+
+	// The vendor asks for a wait beyond MaxDelay: the sends give up without retrying.
+	for range 2 {
+		_, err := pd.Send(context.Background(), &fakeMessage{})
+		if core.GetSenderErrorCode(err) == core.ErrCodeRetryBudgetExhausted {
+			t.Fatalf("expected the send to give up on the delay, got %v", err)
+		}
+	}
+
+	provider.sendErr = core.NetworkError{Err: errors.New("connection refused")}
+	provider.calls = 0
+	_, _ = pd.Send(context.Background(), &fakeMessage{})
+	if provider.calls != 2 {
+		t.Errorf("expected the budget to still allow one retry, got %d calls", provider.calls)
+	}
+}
@@ -24,6 +24,13 @@ const (
 	StrategyWeighted StrategyType = "weighted"
 	// StrategyHealthBased represents the health-based selection strategy.
 	StrategyHealthBased StrategyType = "health_based"
+	// StrategyAdaptive represents the adaptive selection strategy, which learns from send outcomes.
+	StrategyAdaptive StrategyType = "adaptive"
+	// StrategyConsistentHash represents the consistent-hash selection strategy, which
+	// keeps each routing key on the same item.
+	StrategyConsistentHash StrategyType = "consistent_hash"
+	// StrategyCheapest represents the cheapest-first selection strategy.
+	StrategyCheapest StrategyType = "cheapest"
 )
 
 // Selectable defines an interface for items that can be selected.
@@ -291,6 +298,9 @@ func NewStrategyRegistry() *StrategyRegistry {
 	registry.Register(StrategyRoundRobin, NewRoundRobinStrategy())
 	registry.Register(StrategyRandom, NewRandomStrategy())
 	registry.Register(StrategyWeighted, NewWeightedStrategy())
+	registry.Register(StrategyAdaptive, NewAdaptiveStrategy())
+	registry.Register(StrategyConsistentHash, NewConsistentHashStrategy())
+	registry.Register(StrategyCheapest, NewCheapestStrategy())
 
 	return registry
 }
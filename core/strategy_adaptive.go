@@ -0,0 +1,272 @@
+package core
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	mathrand "math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultAdaptiveDecay is the EWMA smoothing factor: the weight of the newest sample.
+	defaultAdaptiveDecay = 0.2
+	// defaultAdaptiveProbeRatio is the share of selections sent to unhealthy items.
+	defaultAdaptiveProbeRatio = 0.05
+	// defaultAdaptiveMinSuccessRate is the success rate below which an item is unhealthy.
+	defaultAdaptiveMinSuccessRate = 0.5
+	// defaultAdaptiveMinSamples is the number of outcomes needed before an item can be judged unhealthy.
+	defaultAdaptiveMinSamples = 5
+)
+
+// OutcomeObserver is implemented by strategies that learn from the outcome of
+// the sends made with the items they selected. BaseConfig.Execute reports every
+// successful send, and every send failing because of the account or its vendor,
+// to the strategy in use when it implements this interface.
+type OutcomeObserver interface {
+	Observe(item Selectable, err error, latency time.Duration)
+}
+
+// ScopedStrategy is implemented by strategies that keep state about the items
+// they select. BaseConfig selects with, and reports outcomes to, its own scope of
+// such a strategy, created from the registered instance by NewScope, so that
+// accounts of different providers never share state even when their names match.
+type ScopedStrategy interface {
+	SelectionStrategy
+	// NewScope returns a strategy configured like this one, with empty state.
+	NewScope() SelectionStrategy
+}
+
+// AdaptiveStats is a snapshot of what AdaptiveStrategy has learned about an item.
+type AdaptiveStats struct {
+	// SuccessRate is the moving average of successful sends, between 0 and 1.
+	SuccessRate float64
+	// Latency is the moving average latency of successful sends.
+	Latency time.Duration
+	// Samples is the number of observed sends.
+	Samples int
+}
+
+// AdaptiveStrategy selects items by their observed health. It keeps an
+// exponentially weighted moving average of the success rate and latency of each
+// item, and picks among healthy items with probability proportional to
+//
+//	weight * successRate² * (fastest latency / latency)
+//
+// Items whose success rate falls below the threshold only receive a small probe
+// share of the traffic until they recover. Items without observations are
+// assumed healthy and fast, so new accounts get traffic right away.
+//
+// Statistics are keyed by the item's type and name. The instance registered in
+// GlobalStrategyRegistry is not fed directly: every BaseConfig learns in its own
+// scope (see [ScopedStrategy]), so that accounts of different providers with the
+// same name and sub-provider keep apart.
+type AdaptiveStrategy struct {
+	decay          float64
+	probeRatio     float64
+	minSuccessRate float64
+	minSamples     int
+
+	mu    sync.Mutex
+	stats map[string]*AdaptiveStats
+	rand  *mathrand.Rand
+}
+
+// AdaptiveOption configures an AdaptiveStrategy.
+type AdaptiveOption func(*AdaptiveStrategy)
+
+// WithAdaptiveDecay sets the EWMA smoothing factor in (0, 1]; higher values react faster.
+func WithAdaptiveDecay(decay float64) AdaptiveOption {
+	return func(s *AdaptiveStrategy) {
+		if decay > 0 && decay <= 1 {
+			s.decay = decay
+		}
+	}
+}
+
+// WithAdaptiveProbeRatio sets the share of selections that go to unhealthy items.
+func WithAdaptiveProbeRatio(ratio float64) AdaptiveOption {
+	return func(s *AdaptiveStrategy) {
+		if ratio >= 0 && ratio <= 1 {
+			s.probeRatio = ratio
+		}
+	}
+}
+
+// WithAdaptiveMinSuccessRate sets the success rate below which an item is unhealthy,
+// and the number of samples needed before that judgement is made.
+func WithAdaptiveMinSuccessRate(rate float64, minSamples int) AdaptiveOption {
+	return func(s *AdaptiveStrategy) {
+		s.minSuccessRate = rate
+		s.minSamples = minSamples
+	}
+}
+
+// NewAdaptiveStrategy creates a new adaptive selection strategy.
+func NewAdaptiveStrategy(opts ...AdaptiveOption) *AdaptiveStrategy {
+	s := &AdaptiveStrategy{
+		decay:          defaultAdaptiveDecay,
+		probeRatio:     defaultAdaptiveProbeRatio,
+		minSuccessRate: defaultAdaptiveMinSuccessRate,
+		minSamples:     defaultAdaptiveMinSamples,
+		stats:          make(map[string]*AdaptiveStats),
+		rand:           newAdaptiveRand(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func newAdaptiveRand() *mathrand.Rand {
+	seed := make([]byte, seedByteLen)
+	_, err := crand.Read(seed)
+	var seedInt uint64
+	if err == nil {
+		seedInt = binary.LittleEndian.Uint64(seed)
+	} else {
+		//nolint:gosec // not for security, only for load balancing/random selection
+		seedInt = uint64(time.Now().UnixNano() & maxSeedInt)
+	}
+	//nolint:gosec // not for security, only for load balancing/random selection
+	return mathrand.New(mathrand.NewPCG(seedInt, 0))
+}
+
+// Name returns the name of the adaptive strategy.
+func (s *AdaptiveStrategy) Name() StrategyType {
+	return StrategyAdaptive
+}
+
+// NewScope returns an adaptive strategy with the same options and nothing learned yet.
+func (s *AdaptiveStrategy) NewScope() SelectionStrategy {
+	return &AdaptiveStrategy{
+		decay:          s.decay,
+		probeRatio:     s.probeRatio,
+		minSuccessRate: s.minSuccessRate,
+		minSamples:     s.minSamples,
+		stats:          make(map[string]*AdaptiveStats),
+		rand:           newAdaptiveRand(),
+	}
+}
+
+// Observe records the outcome of a send made with item.
+func (s *AdaptiveStrategy) Observe(item Selectable, err error, latency time.Duration) {
+	outcome := 1.0
+	if err != nil {
+		outcome = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.stats[adaptiveKey(item)]
+	if !ok {
+		st = &AdaptiveStats{SuccessRate: outcome}
+		s.stats[adaptiveKey(item)] = st
+	}
+	st.SuccessRate += s.decay * (outcome - st.SuccessRate)
+	// Failures are often fast (connection refused) and would make a broken item look quick.
+	if err == nil {
+		if st.Latency == 0 {
+			st.Latency = latency
+		} else {
+			st.Latency += time.Duration(s.decay * float64(latency-st.Latency))
+		}
+	}
+	st.Samples++
+}
+
+// Stats returns what the strategy has learned about item.
+func (s *AdaptiveStrategy) Stats(item Selectable) AdaptiveStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.stats[adaptiveKey(item)]; ok {
+		return *st
+	}
+	return AdaptiveStats{SuccessRate: 1}
+}
+
+// Reset forgets everything learned about item.
+func (s *AdaptiveStrategy) Reset(item Selectable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.stats, adaptiveKey(item))
+}
+
+// Select picks an item weighted toward fast and healthy ones.
+func (s *AdaptiveStrategy) Select(items []Selectable) Selectable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var healthy, unhealthy []Selectable
+	fastest := time.Duration(0)
+	for _, item := range items {
+		if !item.IsEnabled() {
+			continue
+		}
+		st := s.stats[adaptiveKey(item)]
+		if st != nil && st.Samples >= s.minSamples && st.SuccessRate < s.minSuccessRate {
+			unhealthy = append(unhealthy, item)
+			continue
+		}
+		healthy = append(healthy, item)
+		if st != nil && st.Latency > 0 && (fastest == 0 || st.Latency < fastest) {
+			fastest = st.Latency
+		}
+	}
+
+	switch {
+	case len(healthy) == 0 && len(unhealthy) == 0:
+		return nil
+	case len(healthy) == 0:
+		return s.mostSuccessful(unhealthy)
+	case len(unhealthy) > 0 && s.rand.Float64() < s.probeRatio:
+		return unhealthy[s.rand.IntN(len(unhealthy))]
+	}
+
+	scores := make([]float64, len(healthy))
+	total := 0.0
+	for i, item := range healthy {
+		scores[i] = s.score(item, fastest)
+		total += scores[i]
+	}
+	if total <= 0 {
+		return healthy[s.rand.IntN(len(healthy))]
+	}
+	r := s.rand.Float64() * total
+	for i, score := range scores {
+		if r < score {
+			return healthy[i]
+		}
+		r -= score
+	}
+	return healthy[len(healthy)-1]
+}
+
+// score computes the selection weight of a healthy item. Callers hold s.mu.
+func (s *AdaptiveStrategy) score(item Selectable, fastest time.Duration) float64 {
+	weight := float64(max(item.GetWeight(), 1))
+	st := s.stats[adaptiveKey(item)]
+	if st == nil {
+		return weight
+	}
+	score := weight * st.SuccessRate * st.SuccessRate
+	if st.Latency > 0 && fastest > 0 {
+		score *= float64(fastest) / float64(st.Latency)
+	}
+	return score
+}
+
+// mostSuccessful returns the item with the highest success rate. Callers hold s.mu.
+func (s *AdaptiveStrategy) mostSuccessful(items []Selectable) Selectable {
+	best := items[0]
+	for _, item := range items[1:] {
+		if s.stats[adaptiveKey(item)].SuccessRate > s.stats[adaptiveKey(best)].SuccessRate {
+			best = item
+		}
+	}
+	return best
+}
+
+func adaptiveKey(item Selectable) string {
+	return item.GetType() + "/" + item.GetName()
+}
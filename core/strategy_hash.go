@@ -0,0 +1,213 @@
+package core
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"hash/crc32"
+	mathrand "math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultVirtualNodes is the number of ring points per unit of item weight.
+	defaultVirtualNodes = 160
+	// maxCachedRings bounds the rings kept by a ConsistentHashStrategy; one is
+	// needed per distinct item set (provider, filter, exclusions).
+	maxCachedRings = 64
+)
+
+// KeyedSelectionStrategy is implemented by strategies that pick items by the
+// routing key of a send (see [RouteInfo.RoutingKey]). BaseConfig.Select calls
+// SelectByKey instead of Select whenever the route carries a key.
+type KeyedSelectionStrategy interface {
+	SelectionStrategy
+	SelectByKey(key string, items []Selectable) Selectable
+}
+
+// StrictKeyedStrategy is implemented by keyed strategies that can forbid moving a
+// routing key to another item. When Strict reports true, BaseConfig.Select fails
+// instead of selecting another item whenever the one owning the key may not be
+// used for the send.
+type StrictKeyedStrategy interface {
+	KeyedSelectionStrategy
+	Strict() bool
+}
+
+// RoutingKeyer is implemented by messages that provide a default routing key,
+// typically their single recipient.
+type RoutingKeyer interface {
+	RoutingKey() string
+}
+
+// ConsistentHashStrategy maps routing keys to items with a consistent-hash ring,
+// so that a given recipient or tenant keeps being served by the same account.
+// Each item owns VirtualNodes × weight points on the ring; adding, disabling or
+// removing an item only moves the keys of the affected points.
+//
+// The ring is built over the enabled items the send may use, so by default a key
+// falls back to the next item on the ring whenever its own cannot serve it: when
+// failover excludes it after a failure, its circuit breaker is open, its last
+// probe failed or its rate limit is used up. The key returns to its item once
+// that is usable again. Use [WithStrictStickiness] to fail such sends instead.
+//
+// Sends without a routing key are spread randomly.
+type ConsistentHashStrategy struct {
+	virtualNodes int
+	strict       bool
+
+	mu    sync.Mutex
+	rings map[string]*hashRing
+	rand  *mathrand.Rand
+}
+
+// hashRing is a sorted list of points, each owned by an item.
+type hashRing struct {
+	points []uint32
+	owners []Selectable
+}
+
+// ConsistentHashOption configures a ConsistentHashStrategy.
+type ConsistentHashOption func(*ConsistentHashStrategy)
+
+// WithVirtualNodes sets the number of ring points per unit of item weight.
+// More points spread keys more evenly at the cost of memory.
+func WithVirtualNodes(n int) ConsistentHashOption {
+	return func(s *ConsistentHashStrategy) {
+		if n > 0 {
+			s.virtualNodes = n
+		}
+	}
+}
+
+// WithStrictStickiness keeps every routing key on its own item: a send whose item
+// is excluded by failover, has an open circuit breaker or failed its last probe
+// fails instead of moving to another item, and one whose item is rate limited
+// waits for it as RateLimitWait allows. Disabling or removing an item still
+// moves its keys.
+func WithStrictStickiness() ConsistentHashOption {
+	return func(s *ConsistentHashStrategy) {
+		s.strict = true
+	}
+}
+
+// NewConsistentHashStrategy creates a new consistent-hash selection strategy.
+func NewConsistentHashStrategy(opts ...ConsistentHashOption) *ConsistentHashStrategy {
+	seed := make([]byte, seedByteLen)
+	_, err := crand.Read(seed)
+	var seedInt uint64
+	if err == nil {
+		seedInt = binary.LittleEndian.Uint64(seed)
+	} else {
+		//nolint:gosec // not for security, only for load balancing/random selection
+		seedInt = uint64(time.Now().UnixNano() & maxSeedInt)
+	}
+	s := &ConsistentHashStrategy{
+		virtualNodes: defaultVirtualNodes,
+		rings:        make(map[string]*hashRing),
+		//nolint:gosec // not for security, only for load balancing/random selection
+		rand: mathrand.New(mathrand.NewPCG(seedInt, 0)),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name returns the name of the consistent-hash strategy.
+func (s *ConsistentHashStrategy) Name() StrategyType {
+	return StrategyConsistentHash
+}
+
+// Strict reports whether routing keys must never move to another item, see
+// [WithStrictStickiness].
+func (s *ConsistentHashStrategy) Strict() bool {
+	return s.strict
+}
+
+// Select picks a random enabled item; it is used for sends without a routing key.
+func (s *ConsistentHashStrategy) Select(items []Selectable) Selectable {
+	enabled := enabledItems(items)
+	if len(enabled) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return enabled[s.rand.IntN(len(enabled))]
+}
+
+// SelectByKey returns the item owning the first ring point at or after the hash of key.
+func (s *ConsistentHashStrategy) SelectByKey(key string, items []Selectable) Selectable {
+	enabled := enabledItems(items)
+	if len(enabled) == 0 {
+		return nil
+	}
+	ring := s.ring(enabled)
+	h := crc32.ChecksumIEEE([]byte(key))
+	i, _ := slices.BinarySearch(ring.points, h)
+	if i == len(ring.points) {
+		i = 0
+	}
+	return ring.owners[i]
+}
+
+// ring returns the ring for items, building it on first use.
+func (s *ConsistentHashStrategy) ring(items []Selectable) *hashRing {
+	var sig strings.Builder
+	for _, item := range items {
+		sig.WriteString(item.GetName())
+		sig.WriteByte(':')
+		sig.WriteString(strconv.Itoa(item.GetWeight()))
+		sig.WriteByte(',')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ring, ok := s.rings[sig.String()]; ok {
+		return ring
+	}
+	if len(s.rings) >= maxCachedRings {
+		clear(s.rings)
+	}
+
+	type point struct {
+		hash  uint32
+		owner Selectable
+	}
+	var points []point
+	for _, item := range items {
+		n := s.virtualNodes * max(item.GetWeight(), 1)
+		for v := range n {
+			h := crc32.ChecksumIEEE([]byte(item.GetName() + "#" + strconv.Itoa(v)))
+			points = append(points, point{hash: h, owner: item})
+		}
+	}
+	slices.SortFunc(points, func(a, b point) int {
+		if a.hash != b.hash {
+			return int(int64(a.hash) - int64(b.hash))
+		}
+		// Break collisions by name so that the ring does not depend on item order.
+		return strings.Compare(a.owner.GetName(), b.owner.GetName())
+	})
+
+	ring := &hashRing{points: make([]uint32, len(points)), owners: make([]Selectable, len(points))}
+	for i, p := range points {
+		ring.points[i], ring.owners[i] = p.hash, p.owner
+	}
+	s.rings[sig.String()] = ring
+	return ring
+}
+
+// enabledItems returns the enabled items in items.
+func enabledItems(items []Selectable) []Selectable {
+	enabled := make([]Selectable, 0, len(items))
+	for _, item := range items {
+		if item.IsEnabled() {
+			enabled = append(enabled, item)
+		}
+	}
+	return enabled
+}
@@ -2,9 +2,12 @@ package core_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/shellvon/go-sender/circuitbreaker"
 	"github.com/shellvon/go-sender/core"
 )
 
@@ -364,3 +367,216 @@ func TestWeightedStrategy_Distribution(t *testing.T) {
 		t.Errorf("High weight item count %d, expected around %d", counts["high"], expectedHigh)
 	}
 }
+
+func TestAdaptiveStrategy_PrefersFastHealthyItems(t *testing.T) {
+	fast := &fakeSelectable{name: "fast", weight: 1, enabled: true}
+	slow := &fakeSelectable{name: "slow", weight: 1, enabled: true}
+	broken := &fakeSelectable{name: "broken", weight: 1, enabled: true}
+	strategy := core.NewAdaptiveStrategy(core.WithAdaptiveProbeRatio(0.1))
+
+	for range 10 {
+		strategy.Observe(fast, nil, 10*time.Millisecond)
+		strategy.Observe(slow, nil, 100*time.Millisecond)
+		strategy.Observe(broken, errors.New("vendor down"), time.Millisecond)
+	}
+
+	counts := map[string]int{}
+	items := []core.Selectable{fast, slow, broken}
+	for range 2000 {
+		counts[strategy.Select(items).GetName()]++
+	}
+	if counts["fast"] < 3*counts["slow"] {
+		t.Errorf("expected fast to dominate slow, got %v", counts)
+	}
+	if counts["broken"] == 0 || counts["broken"] > 400 {
+		t.Errorf("expected broken to get only probe traffic, got %v", counts)
+	}
+}
+
+func TestAdaptiveStrategy_Recovers(t *testing.T) {
+	item := &fakeSelectable{name: "a", weight: 1, enabled: true}
+	strategy := core.NewAdaptiveStrategy(core.WithAdaptiveDecay(0.5))
+	for range 5 {
+		strategy.Observe(item, errors.New("down"), 0)
+	}
+	if rate := strategy.Stats(item).SuccessRate; rate > 0.1 {
+		t.Fatalf("expected low success rate, got %v", rate)
+	}
+	for range 5 {
+		strategy.Observe(item, nil, time.Millisecond)
+	}
+	if rate := strategy.Stats(item).SuccessRate; rate < 0.9 {
+		t.Errorf("expected recovered success rate, got %v", rate)
+	}
+}
+
+func TestAdaptiveStrategy_RegisteredAndFedByExecute(t *testing.T) {
+	if _, ok := core.GlobalStrategyRegistry.Get(core.StrategyAdaptive); !ok {
+		t.Fatal("adaptive strategy not registered")
+	}
+
+	item := &mockSelectable{name: "adaptive-exec", weight: 1, enabled: true}
+	config := &core.BaseConfig[*mockSelectable]{
+		ProviderMeta: core.ProviderMeta{Strategy: core.StrategyAdaptive},
+		Items:        []*mockSelectable{item},
+	}
+	adaptive := config.StrategyScope(core.StrategyAdaptive).(*core.AdaptiveStrategy)
+
+	_ = config.Execute(context.Background(), item, func() error {
+		return core.NewSenderError(core.ErrCodeProviderUnavailable, "boom", nil)
+	})
+	if st := adaptive.Stats(item); st.Samples != 1 || st.SuccessRate != 0 {
+		t.Errorf("expected one failed sample, got %+v", st)
+	}
+
+	// Failures caused by the message say nothing about the account.
+	kinds := core.ErrorKinds{"E1": core.ErrorKindValidation, "E2": core.ErrorKindTemplateRejected}
+	for _, err := range []error{
+		core.NewVendorError(kinds, "E1", "invalid mobile"),
+		core.NewVendorError(kinds, "E2", "template not approved"),
+		core.ValidationError{Err: errors.New("empty content")},
+		context.Canceled,
+	} {
+		_ = config.Execute(context.Background(), item, func() error { return err })
+	}
+	if st := adaptive.Stats(item); st.Samples != 1 {
+		t.Errorf("message failures were reported to the strategy: %+v", st)
+	}
+}
+
+func TestAdaptiveStrategy_StatsPerConfig(t *testing.T) {
+	// An email and a DingTalk account may both be called "main".
+	email := &mockSelectable{name: "main", weight: 1, enabled: true}
+	dingtalk := &mockSelectable{name: "main", weight: 1, enabled: true}
+	emailConfig := &core.BaseConfig[*mockSelectable]{
+		ProviderMeta: core.ProviderMeta{Strategy: core.StrategyAdaptive},
+		Items:        []*mockSelectable{email},
+	}
+	dingtalkConfig := &core.BaseConfig[*mockSelectable]{
+		ProviderMeta: core.ProviderMeta{Strategy: core.StrategyAdaptive},
+		Items:        []*mockSelectable{dingtalk},
+	}
+
+	_ = emailConfig.Execute(context.Background(), email, func() error {
+		return core.NewSenderError(core.ErrCodeProviderUnavailable, "boom", nil)
+	})
+
+	emailStats := emailConfig.StrategyScope(core.StrategyAdaptive).(*core.AdaptiveStrategy).Stats(email)
+	if emailStats.Samples != 1 || emailStats.SuccessRate != 0 {
+		t.Errorf("expected one failed sample for the email account, got %+v", emailStats)
+	}
+	dingtalkStats := dingtalkConfig.StrategyScope(core.StrategyAdaptive).(*core.AdaptiveStrategy).Stats(dingtalk)
+	if dingtalkStats.Samples != 0 {
+		t.Errorf("the email failure was counted for the dingtalk account: %+v", dingtalkStats)
+	}
+}
+
+func TestConsistentHashStrategy_StickyAndStable(t *testing.T) {
+	strategy := core.NewConsistentHashStrategy()
+	a := &fakeSelectable{name: "a", weight: 1, enabled: true}
+	b := &fakeSelectable{name: "b", weight: 1, enabled: true}
+	c := &fakeSelectable{name: "c", weight: 1, enabled: true}
+	three := []core.Selectable{a, b, c}
+
+	before := map[string]string{}
+	for i := range 1000 {
+		key := fmt.Sprintf("user-%d", i)
+		before[key] = strategy.SelectByKey(key, three).GetName()
+		if again := strategy.SelectByKey(key, three).GetName(); again != before[key] {
+			t.Fatalf("key %s moved from %s to %s", key, before[key], again)
+		}
+	}
+
+	// Disabling c must only move the keys c owned.
+	c.enabled = false
+	moved := 0
+	for key, owner := range before {
+		now := strategy.SelectByKey(key, three).GetName()
+		if now == "c" {
+			t.Fatalf("key %s routed to disabled item", key)
+		}
+		if owner != "c" && now != owner {
+			moved++
+		}
+	}
+	if moved != 0 {
+		t.Errorf("expected only keys of c to move, %d others moved", moved)
+	}
+}
+
+func TestConsistentHashStrategy_RoutingKeyFromOptions(t *testing.T) {
+	p := newAccountProvider()
+	pd := core.NewProviderDecorator(p, &core.SenderMiddleware{}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	send := func(opts ...core.SendOption) string {
+		opts = append(opts, core.WithSendStrategy(core.StrategyConsistentHash))
+		result, err := pd.Send(context.Background(), &fakeMessage{}, opts...)
+		if err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+		return result.Config.(*mockSelectable).GetName()
+	}
+
+	first := send(core.WithSendRoutingKey("tenant-42"))
+	for range 10 {
+		if got := send(core.WithSendRoutingKey("tenant-42")); got != first {
+			t.Fatalf("expected tenant-42 to stay on %s, got %s", first, got)
+		}
+		got := send(core.WithSendMetadata("tenant", "tenant-42"), core.WithSendRoutingKeyField("tenant"))
+		if got != first {
+			t.Fatalf("expected metadata key to route like the explicit key, got %s", got)
+		}
+	}
+}
+
+func TestConsistentHashStrategy_StrictStickiness(t *testing.T) {
+	const strictHash core.StrategyType = "strict_consistent_hash"
+	core.GlobalStrategyRegistry.Register(strictHash, core.NewConsistentHashStrategy(core.WithStrictStickiness()))
+
+	guard := core.NewAccountGuard(core.WithAccountBreakers(func(key string) core.CircuitBreaker {
+		return circuitbreaker.NewMemoryCircuitBreaker(key, 1, time.Minute)
+	}))
+	config := newGuardedConfig(guard)
+	config.Strategy = strictHash
+	selectWith := func(ri core.RouteInfo) (string, error) {
+		ri.RoutingKey = "tenant-42"
+		item, err := config.Select(core.WithRoute(context.Background(), &ri), nil)
+		if err != nil {
+			return "", err
+		}
+		return item.GetName(), nil
+	}
+
+	owner, err := selectWith(core.RouteInfo{})
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	excluded := []string{owner}
+
+	// By default the key falls back to another account.
+	fallback := core.RouteInfo{StrategyType: core.StrategyConsistentHash, ExcludeAccounts: excluded}
+	if other, _ := selectWith(fallback); other == owner {
+		t.Errorf("expected the default strategy to move the key off the excluded %s", owner)
+	}
+
+	_, err = selectWith(core.RouteInfo{ExcludeAccounts: excluded})
+	if core.GetSenderErrorCode(err) != core.ErrCodeProviderUnavailable {
+		t.Errorf("expected an error when the owner is excluded, got %v", err)
+	}
+
+	config.RecordProbe(core.ProbeResult{Account: owner, Err: errors.New("auth failed")})
+	if _, err = selectWith(core.RouteInfo{}); core.GetSenderErrorCode(err) != core.ErrCodeProviderUnavailable {
+		t.Errorf("expected an error when the owner failed its probe, got %v", err)
+	}
+	config.RecordProbe(core.ProbeResult{Account: owner})
+
+	item := config.Items[0]
+	if item.GetName() != owner {
+		item = config.Items[1]
+	}
+	_ = config.Execute(context.Background(), item, func() error { return errors.New("vendor down") })
+	if _, err = selectWith(core.RouteInfo{}); core.GetSenderErrorCode(err) != core.ErrCodeCircuitBreakerOpen {
+		t.Errorf("expected an error while the owner's breaker is open, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"maps"
+)
+
+// Names of the spans go-sender starts.
+const (
+	SpanSend      = "gosender.send"       // a call to Send, sync or async
+	SpanDeliver   = "gosender.deliver"    // an async send processed from the queue or a goroutine
+	SpanRateLimit = "gosender.rate_limit" // waiting for the rate limiter
+	SpanAttempt   = "gosender.attempt"    // one attempt of a send, retried or hedged
+	SpanHTTP      = "gosender.http"       // the HTTP call of an HTTP provider
+	SpanSMTP      = "gosender.smtp"       // SMTP delivery of the email provider
+)
+
+// traceMetadataKey is the QueueItem.Metadata key holding the trace context of an async send.
+const traceMetadataKey = "__gosender_trace__"
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr returns an Attribute.
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span is an operation being traced.
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...Attribute)
+	// RecordError marks the span as failed with err.
+	RecordError(err error)
+	// End completes the span.
+	End()
+}
+
+// Tracer starts spans and propagates trace context, e.g. across the async queue.
+// An OpenTelemetry adapter implements it with a trace.Tracer, and Inject and
+// Extract with a propagation.TextMapPropagator over a propagation.MapCarrier.
+type Tracer interface {
+	// Start starts a span as a child of the span in ctx, if any, and returns a
+	// context holding the new span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+	// Inject writes the trace context of ctx into carrier.
+	Inject(ctx context.Context, carrier map[string]string)
+	// Extract returns ctx with the trace context read from carrier, so that spans
+	// started from it continue the trace.
+	Extract(ctx context.Context, carrier map[string]string) context.Context
+}
+
+type tracerKey struct{}
+
+// WithTracer returns a context whose spans are started by tracer. The
+// ProviderDecorator sets SenderMiddleware.Tracer this way for the providers.
+func WithTracer(ctx context.Context, tracer Tracer) context.Context {
+	if tracer == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+// TracerFromContext returns the Tracer of ctx, or nil.
+func TracerFromContext(ctx context.Context) Tracer {
+	tracer, _ := ctx.Value(tracerKey{}).(Tracer)
+	return tracer
+}
+
+// StartSpan starts a span with the Tracer of ctx. Without one, the span does nothing.
+func StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	if tracer := TracerFromContext(ctx); tracer != nil {
+		return tracer.Start(ctx, name, attrs...)
+	}
+	return ctx, noopSpan{}
+}
+
+// FinishSpan records err, if any, on span and ends it.
+func FinishSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// injectTrace stores the trace context of ctx in metadata, if ctx has a Tracer.
+func injectTrace(ctx context.Context, metadata map[string]interface{}) {
+	tracer := TracerFromContext(ctx)
+	if tracer == nil {
+		return
+	}
+	carrier := make(map[string]string)
+	tracer.Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		metadata[traceMetadataKey] = carrier
+	}
+}
+
+// extractTrace restores the trace context stored in metadata by injectTrace.
+// Queues that round-trip metadata through JSON turn the carrier into a
+// map[string]interface{}, which is accepted too.
+func extractTrace(ctx context.Context, metadata map[string]interface{}) context.Context {
+	tracer := TracerFromContext(ctx)
+	if tracer == nil {
+		return ctx
+	}
+	carrier := make(map[string]string)
+	switch stored := metadata[traceMetadataKey].(type) {
+	case map[string]string:
+		maps.Copy(carrier, stored)
+	case map[string]interface{}:
+		for k, v := range stored {
+			if s, ok := v.(string); ok {
+				carrier[k] = s
+			}
+		}
+	default:
+		return ctx
+	}
+	return tracer.Extract(ctx, carrier)
+}
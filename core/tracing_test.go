@@ -0,0 +1,183 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/queue"
+)
+
+// recordingTracer keeps the spans it starts. A span continues the trace of its
+// parent, or of the trace id extracted from a carrier.
+type recordingTracer struct {
+	mu     sync.Mutex
+	spans  []*recordedSpan
+	nextID int
+}
+
+type recordedSpan struct {
+	mu      sync.Mutex
+	name    string
+	traceID string
+	parent  *recordedSpan
+	attrs   map[string]any
+	err     error
+	ended   bool
+}
+
+type spanKey struct{}
+
+type remoteTraceKey struct{}
+
+func (tr *recordingTracer) Start(ctx context.Context, name string, attrs ...core.Attribute) (context.Context, core.Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	span := &recordedSpan{name: name, attrs: map[string]any{}}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.parent, span.traceID = parent, parent.traceID
+	} else if traceID, ok := ctx.Value(remoteTraceKey{}).(string); ok {
+		span.traceID = traceID
+	} else {
+		tr.nextID++
+		span.traceID = strconv.Itoa(tr.nextID)
+	}
+	span.SetAttributes(attrs...)
+	tr.spans = append(tr.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (tr *recordingTracer) Inject(ctx context.Context, carrier map[string]string) {
+	if span, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		carrier["trace-id"] = span.traceID
+	}
+}
+
+func (tr *recordingTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if traceID := carrier["trace-id"]; traceID != "" {
+		return context.WithValue(ctx, remoteTraceKey{}, traceID)
+	}
+	return ctx
+}
+
+func (tr *recordingTracer) named(name string) []*recordedSpan {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	var spans []*recordedSpan
+	for _, span := range tr.spans {
+		if span.name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...core.Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+// outcome returns whether the span ended and the error it recorded.
+func (s *recordedSpan) outcome() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ended, s.err
+}
+
+func TestTracing_SpansPerAttempt(t *testing.T) {
+	tracer := &recordingTracer{}
+	retry := core.NewRetryPolicy(
+		core.WithRetryMaxAttempts(2),
+		core.WithRetryInitialDelay(time.Millisecond),
+		core.WithRetryFilter(func(int, error) bool { return true }),
+	)
+	pd := core.NewProviderDecorator(newAccountProvider().failOn("aliyun-1"),
+		&core.SenderMiddleware{Retry: retry, Tracer: tracer}, &core.NoOpLogger{})
+	defer pd.Close()
+
+	if _, err := pd.Send(context.Background(), &fakeMessage{}, core.WithSendStrategy(core.StrategyRoundRobin)); err != nil {
+		t.Fatal(err)
+	}
+	sends := tracer.named(core.SpanSend)
+	if len(sends) != 1 || !sends[0].ended || sends[0].err != nil {
+		t.Fatalf("expected one successful send span, got %+v", sends)
+	}
+	attempts := tracer.named(core.SpanAttempt)
+	if len(attempts) == 0 {
+		t.Fatal("expected attempt spans")
+	}
+	for _, attempt := range attempts {
+		if attempt.parent != sends[0] || !attempt.ended || attempt.attrs["account"] == nil {
+			t.Errorf("attempt span should be a finished child of the send: %+v", attempt)
+		}
+		if failed := attempt.attrs["account"] == "aliyun-1"; failed != (attempt.err != nil) {
+			t.Errorf("attempt on %v recorded error %v", attempt.attrs["account"], attempt.err)
+		}
+	}
+}
+
+func TestTracing_AsyncSendContinuesTrace(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue core.Queue
+	}{
+		{name: "queue", queue: queue.NewMemoryQueue[*core.QueueItem](0)},
+		{name: "in-process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &recordingTracer{}
+			pd := core.NewProviderDecorator(&fakeProvider{name: "fake", sendErr: errors.New("down")},
+				&core.SenderMiddleware{Queue: tt.queue, Tracer: tracer}, &core.NoOpLogger{})
+			defer pd.Close()
+
+			done := make(chan struct{})
+			_, err := pd.Send(context.Background(), &fakeMessage{},
+				core.WithSendAsync(), core.WithSendCallback(func(*core.SendResult, error) { close(done) }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatal("async send not processed")
+			}
+
+			sends, delivers := tracer.named(core.SpanSend), tracer.named(core.SpanDeliver)
+			if len(sends) != 1 || len(delivers) != 1 {
+				t.Fatalf("expected a send and a deliver span, got %d and %d", len(sends), len(delivers))
+			}
+			if delivers[0].traceID != sends[0].traceID {
+				t.Errorf("deliver span in trace %s, want the trace of the send %s", delivers[0].traceID, sends[0].traceID)
+			}
+			deadline := time.Now().Add(time.Second)
+			ended, err := delivers[0].outcome()
+			for !ended && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+				ended, err = delivers[0].outcome()
+			}
+			if !ended || err == nil {
+				t.Error("expected the deliver span to end with the failure")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrorKind classifies the failures vendors report, whatever their own codes.
+type ErrorKind string
+
+const (
+	// ErrorKindValidation means the request was invalid, e.g. a malformed phone number.
+	ErrorKindValidation ErrorKind = "validation"
+	// ErrorKindAuthentication means the credentials, signature or IP were refused.
+	ErrorKindAuthentication ErrorKind = "authentication"
+	// ErrorKindRateLimited means the request was sent too fast; it may be retried later.
+	ErrorKindRateLimited ErrorKind = "rate_limited"
+	// ErrorKindQuotaExhausted means a balance or daily quota was used up.
+	ErrorKindQuotaExhausted ErrorKind = "quota_exhausted"
+	// ErrorKindRecipientBlocked means the recipient refuses messages, e.g. is
+	// blacklisted or has blocked the bot.
+	ErrorKindRecipientBlocked ErrorKind = "recipient_blocked"
+	// ErrorKindTemplateRejected means the template, signature or content was not
+	// approved, e.g. for containing forbidden words.
+	ErrorKindTemplateRejected ErrorKind = "template_rejected"
+	// ErrorKindTransient means a temporary vendor failure; the request may be retried.
+	ErrorKindTransient ErrorKind = "transient"
+)
+
+// ErrorKinds maps the error codes of a vendor to their kind.
+type ErrorKinds map[string]ErrorKind
+
+// StatusErrorKind returns the kind of a failure reported only by its HTTP status,
+// or "" for statuses that do not tell.
+func StatusErrorKind(status int) ErrorKind {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorKindAuthentication
+	case status == http.StatusPaymentRequired:
+		return ErrorKindQuotaExhausted
+	case status == http.StatusBadRequest || status == http.StatusNotFound ||
+		status == http.StatusRequestEntityTooLarge || status == http.StatusUnprocessableEntity:
+		return ErrorKindValidation
+	case status == http.StatusRequestTimeout || status >= http.StatusInternalServerError:
+		return ErrorKindTransient
+	default:
+		return ""
+	}
+}
+
+// VendorError is a failure reported by a vendor API, carrying the vendor's own
+// code and message, classified by Kind. Besides *VendorError itself, errors.As
+// matches it as a *SenderError with the ErrorCode of its kind, and as a
+// ValidationError, AuthenticationError or ThrottledError when of that kind.
+type VendorError struct {
+	// Kind classifies the failure; it is empty when the vendor code is unknown.
+	Kind ErrorKind
+	// Code is the vendor's error code, or the HTTP status when the body has none.
+	Code string
+	// Message is the vendor's error message.
+	Message string
+	// StatusCode is the HTTP status of the response, if any.
+	StatusCode int
+	// Err is the provider-specific error the failure was decoded into, if any.
+	Err error
+}
+
+// NewVendorError creates a VendorError for code, classified with kinds.
+func NewVendorError(kinds ErrorKinds, code, message string) *VendorError {
+	return &VendorError{Kind: kinds[code], Code: code, Message: message}
+}
+
+func (e *VendorError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("api error: %s (code=%s)", e.Message, e.Code)
+}
+
+// Unwrap returns the provider-specific error, if any.
+func (e *VendorError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the ErrorCode of the kind of error, ErrCodeUnknown when it is
+// not classified.
+func (e *VendorError) ErrorCode() ErrorCode {
+	switch e.Kind {
+	case ErrorKindValidation:
+		return ErrCodeValidationFailed
+	case ErrorKindAuthentication:
+		return ErrCodeProviderAuthFailed
+	case ErrorKindRateLimited:
+		return ErrCodeProviderRateLimited
+	case ErrorKindQuotaExhausted:
+		return ErrCodeProviderQuotaExhausted
+	case ErrorKindRecipientBlocked:
+		return ErrCodeRecipientBlocked
+	case ErrorKindTemplateRejected:
+		return ErrCodeTemplateRejected
+	case ErrorKindTransient:
+		return ErrCodeProviderUnavailable
+	default:
+		return ErrCodeUnknown
+	}
+}
+
+// IsRetryable reports whether retrying may succeed: only rate-limited and
+// transient failures are retryable.
+func (e *VendorError) IsRetryable() bool {
+	return e.Kind == ErrorKindRateLimited || e.Kind == ErrorKindTransient
+}
+
+// As lets errors.As match e as the error types of its kind.
+func (e *VendorError) As(target any) bool {
+	switch t := target.(type) {
+	case **SenderError:
+		if e.Kind == "" {
+			return false
+		}
+		*t = &SenderError{Code: e.ErrorCode(), Message: e.Message, Cause: e}
+	case *ValidationError:
+		if e.Kind != ErrorKindValidation {
+			return false
+		}
+		*t = ValidationError{Err: e}
+	case *AuthenticationError:
+		if e.Kind != ErrorKindAuthentication {
+			return false
+		}
+		*t = AuthenticationError{Err: e}
+	case *ThrottledError:
+		if e.Kind != ErrorKindRateLimited {
+			return false
+		}
+		*t = ThrottledError{Err: e}
+	default:
+		return false
+	}
+	return true
+}
+
+// VendorErrorKind returns the kind of the VendorError in err's chain, or "".
+func VendorErrorKind(err error) ErrorKind {
+	var vendorErr *VendorError
+	if errors.As(err, &vendorErr) {
+		return vendorErr.Kind
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package core_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+func TestVendorError_MatchesKindTypes(t *testing.T) {
+	kinds := core.ErrorKinds{"401": core.ErrorKindAuthentication, "BLOCKED": core.ErrorKindRecipientBlocked}
+
+	auth := core.NewVendorError(kinds, "401", "bad token")
+	var authErr core.AuthenticationError
+	if !errors.As(auth, &authErr) || core.GetSenderErrorCode(auth) != core.ErrCodeProviderAuthFailed {
+		t.Errorf("expected an authentication error, got %v", auth)
+	}
+	if errors.As(auth, new(core.ValidationError)) || core.IsThrottled(auth) {
+		t.Error("an authentication error should match no other kind")
+	}
+
+	blocked := core.NewVendorError(kinds, "BLOCKED", "in blacklist")
+	if core.GetSenderErrorCode(blocked) != core.ErrCodeRecipientBlocked || blocked.IsRetryable() {
+		t.Errorf("expected a non-retryable ErrCodeRecipientBlocked, got %v", blocked)
+	}
+	if core.VendorErrorKind(blocked) != core.ErrorKindRecipientBlocked {
+		t.Errorf("unexpected kind %q", core.VendorErrorKind(blocked))
+	}
+
+	unknown := core.NewVendorError(kinds, "E42", "something")
+	if core.IsSenderError(unknown) || unknown.IsRetryable() {
+		t.Error("unclassified vendor errors should stay plain, non-retryable errors")
+	}
+}
+
+func TestResponseHandler_ClassifiesFailures(t *testing.T) {
+	cfg := &core.ResponseHandlerConfig{
+		CheckBody:  true,
+		BodyType:   core.BodyTypeJSON,
+		Path:       "ok",
+		Expect:     "true",
+		CodePath:   "error_code",
+		MsgPath:    "description",
+		ErrorKinds: core.ErrorKinds{"403": core.ErrorKindRecipientBlocked},
+	}
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   core.ErrorKind
+	}{
+		{"body code", http.StatusForbidden, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`, core.ErrorKindRecipientBlocked},
+		{"status only", http.StatusBadGateway, `<html>Bad Gateway</html>`, core.ErrorKindTransient},
+		{"unlisted code", http.StatusOK, `{"ok":false,"error_code":999}`, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := core.NewSendResultHandler(cfg)(&core.SendResult{StatusCode: tc.status, Body: []byte(tc.body)})
+			if kind := core.VendorErrorKind(err); kind != tc.kind {
+				t.Errorf("expected kind %q, got %q (%v)", tc.kind, kind, err)
+			}
+		})
+	}
+}
@@ -1,35 +1,101 @@
 package metrics
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/shellvon/go-sender/core"
 )
 
-// MemoryMetricsCollector is an in-memory implementation of MetricsCollector
-// Only keep sendResult related statistics.
+// Default windows of the rolling statistics of a MemoryMetricsCollector.
+const (
+	Window1m = time.Minute
+	Window5m = 5 * time.Minute
+	Window1h = time.Hour
+)
+
+// DefaultHealthWindow is the window whose statistics feed Sender.HealthCheck.
+const DefaultHealthWindow = Window5m
+
+// windowBuckets is the number of buckets a window is divided into; statistics
+// expire one bucket at a time.
+const windowBuckets = 12
+
+// MemoryMetricsCollector is an in-memory implementation of MetricsCollector.
+// Besides lifetime totals per provider (GetStats), it keeps rolling statistics
+// over the last minute, five minutes and hour, per provider and per account:
+// see Snapshot.
 type MemoryMetricsCollector struct {
+	healthWindow time.Duration
+
 	mu      sync.RWMutex
 	metrics map[string]*providerMetrics
+	windows map[time.Duration]*window
 }
 
+var (
+	_ core.MetricsCollector      = (*MemoryMetricsCollector)(nil)
+	_ core.ProviderStatsReporter = (*MemoryMetricsCollector)(nil)
+)
+
 type providerMetrics struct {
 	totalRequests   int64
 	successRequests int64
 	failedRequests  int64
 }
 
+// MemoryOption configures a MemoryMetricsCollector.
+type MemoryOption func(*MemoryMetricsCollector)
+
+// WithWindows replaces the windows of the rolling statistics.
+func WithWindows(windows ...time.Duration) MemoryOption {
+	return func(m *MemoryMetricsCollector) {
+		m.windows = make(map[time.Duration]*window)
+		for _, w := range windows {
+			if w >= windowBuckets {
+				m.windows[w] = &window{width: w / windowBuckets}
+			}
+		}
+	}
+}
+
+// WithHealthWindow sets the window whose statistics Sender.HealthCheck reports,
+// DefaultHealthWindow by default. It is added to the windows if missing.
+func WithHealthWindow(w time.Duration) MemoryOption {
+	return func(m *MemoryMetricsCollector) {
+		if w >= windowBuckets {
+			m.healthWindow = w
+		}
+	}
+}
+
 // NewMemoryMetricsCollector creates a new in-memory metrics collector.
-func NewMemoryMetricsCollector() *MemoryMetricsCollector {
-	return &MemoryMetricsCollector{
-		metrics: make(map[string]*providerMetrics),
+func NewMemoryMetricsCollector(opts ...MemoryOption) *MemoryMetricsCollector {
+	m := &MemoryMetricsCollector{
+		healthWindow: DefaultHealthWindow,
+		metrics:      make(map[string]*providerMetrics),
+	}
+	WithWindows(Window1m, Window5m, Window1h)(m)
+	for _, opt := range opts {
+		opt(m)
 	}
+	if _, ok := m.windows[m.healthWindow]; !ok {
+		m.windows[m.healthWindow] = &window{width: m.healthWindow / windowBuckets}
+	}
+	return m
 }
 
 // RecordSendResult records the result of a send operation.
 func (m *MemoryMetricsCollector) RecordSendResult(data core.MetricsData) {
+	now := time.Now()
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	for _, w := range m.windows {
+		w.record(now, data)
+	}
 	provider, exists := m.metrics[data.Provider]
 	if !exists {
 		provider = &providerMetrics{}
@@ -55,3 +121,245 @@ func (m *MemoryMetricsCollector) GetStats(provider string) (int64, int64, int64)
 
 	return stats.totalRequests, stats.successRequests, stats.failedRequests
 }
+
+// Snapshot is the statistics of one window.
+type Snapshot struct {
+	// Window is the period covered, ending at Time.
+	Window time.Duration
+	Time   time.Time
+	// Providers holds the statistics of each provider.
+	Providers map[string]*WindowStats
+	// Accounts holds the statistics of each account, by provider and account name,
+	// for providers that select accounts through core.BaseConfig.
+	Accounts map[string]map[string]*WindowStats
+}
+
+// WindowStats is the statistics of a provider or account over a window.
+type WindowStats struct {
+	// Sends is the number of sent operations, and Failures those that failed.
+	Sends    int64
+	Failures int64
+	// ErrorRate is Failures divided by Sends.
+	ErrorRate float64
+	// Throughput is the number of sends per second over the window.
+	Throughput float64
+	// Errors counts the failed sends by core.MetricsData.ErrorType.
+	Errors map[string]int64
+	// Operations counts every operation (sent, enqueue, dequeue, ...).
+	Operations map[string]int64
+	// Retries is the number of retries of the sends.
+	Retries int64
+	// Latency is the distribution of the durations of the sends.
+	Latency Percentiles
+	// QueueLatency is the distribution of the time messages waited in the queue.
+	QueueLatency Percentiles
+}
+
+// Percentiles summarises a latency distribution; percentiles are accurate to 1%.
+type Percentiles struct {
+	P50, P95, P99, Max, Mean time.Duration
+}
+
+// Snapshot returns the statistics of window, which must be one of the windows
+// of the collector.
+func (m *MemoryMetricsCollector) Snapshot(window time.Duration) (*Snapshot, error) {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	w, ok := m.windows[window]
+	if !ok {
+		return nil, fmt.Errorf("metrics: window %s is not tracked, use one of %v", window, m.trackedWindows())
+	}
+
+	snapshot := &Snapshot{
+		Window:    window,
+		Time:      now,
+		Providers: make(map[string]*WindowStats),
+		Accounts:  make(map[string]map[string]*WindowStats),
+	}
+	for key, stats := range w.merged(now) {
+		out := stats.summary(window)
+		if key.account == "" {
+			snapshot.Providers[key.provider] = out
+			continue
+		}
+		if snapshot.Accounts[key.provider] == nil {
+			snapshot.Accounts[key.provider] = make(map[string]*WindowStats)
+		}
+		snapshot.Accounts[key.provider][key.account] = out
+	}
+	return snapshot, nil
+}
+
+// ProviderStats reports the error rate and 95th percentile latency of provider
+// over the health window, see core.ProviderStatsReporter.
+func (m *MemoryMetricsCollector) ProviderStats(provider string) (core.ProviderStats, bool) {
+	snapshot, err := m.Snapshot(m.healthWindow)
+	if err != nil {
+		return core.ProviderStats{}, false
+	}
+	stats, ok := snapshot.Providers[provider]
+	if !ok || stats.Sends == 0 {
+		return core.ProviderStats{}, false
+	}
+	return core.ProviderStats{
+		Sends:     stats.Sends,
+		ErrorRate: stats.ErrorRate,
+		Latency:   stats.Latency.P95,
+	}, true
+}
+
+func (m *MemoryMetricsCollector) trackedWindows() []string {
+	windows := slices.Sorted(maps.Keys(m.windows))
+	names := make([]string, len(windows))
+	for i, w := range windows {
+		names[i] = w.String()
+	}
+	return names
+}
+
+// isSend reports whether operation is a send. Operation is empty for
+// collectors fed by hand.
+func isSend(operation string) bool {
+	return operation == "" || operation == core.OperationSent
+}
+
+// seriesKey identifies a provider, or an account of it.
+type seriesKey struct {
+	provider, account string
+}
+
+// window is a ring of buckets of width width, each holding the statistics of
+// the operations recorded during it.
+type window struct {
+	width   time.Duration
+	buckets [windowBuckets]windowBucket
+}
+
+type windowBucket struct {
+	epoch  int64
+	series map[seriesKey]*windowStats
+}
+
+func (w *window) record(now time.Time, data core.MetricsData) {
+	epoch := now.UnixNano() / int64(w.width)
+	bucket := &w.buckets[epoch%windowBuckets]
+	if bucket.epoch != epoch || bucket.series == nil {
+		*bucket = windowBucket{epoch: epoch, series: make(map[seriesKey]*windowStats)}
+	}
+	keys := []seriesKey{{provider: data.Provider}}
+	if data.Account != "" {
+		keys = append(keys, seriesKey{provider: data.Provider, account: data.Account})
+	}
+	for _, key := range keys {
+		stats, ok := bucket.series[key]
+		if !ok {
+			stats = newWindowStats()
+			bucket.series[key] = stats
+		}
+		stats.add(data)
+	}
+}
+
+// merged returns the statistics of the buckets still in the window at now.
+func (w *window) merged(now time.Time) map[seriesKey]*windowStats {
+	epoch := now.UnixNano() / int64(w.width)
+	merged := make(map[seriesKey]*windowStats)
+	for i := range w.buckets {
+		bucket := &w.buckets[i]
+		if bucket.series == nil || bucket.epoch <= epoch-windowBuckets || bucket.epoch > epoch {
+			continue
+		}
+		for key, stats := range bucket.series {
+			total, ok := merged[key]
+			if !ok {
+				total = newWindowStats()
+				merged[key] = total
+			}
+			total.merge(stats)
+		}
+	}
+	return merged
+}
+
+// windowStats accumulates the operations of a series during a bucket.
+type windowStats struct {
+	sends, failures, retries int64
+	errors                   map[string]int64
+	operations               map[string]int64
+	latency, queueLatency    sketch
+}
+
+func newWindowStats() *windowStats {
+	return &windowStats{errors: make(map[string]int64), operations: make(map[string]int64)}
+}
+
+func (s *windowStats) add(data core.MetricsData) {
+	operation := data.Operation
+	if operation == "" {
+		operation = core.OperationSent
+	}
+	s.operations[operation]++
+	if data.QueueLatency > 0 {
+		s.queueLatency.add(data.QueueLatency.Seconds())
+	}
+	if !isSend(data.Operation) {
+		return
+	}
+	s.sends++
+	s.retries += int64(data.RetryCount)
+	s.latency.add(data.Duration.Seconds())
+	if !data.Success {
+		s.failures++
+		errorType := data.ErrorType
+		if errorType == "" {
+			errorType = "error"
+		}
+		s.errors[errorType]++
+	}
+}
+
+func (s *windowStats) merge(o *windowStats) {
+	s.sends += o.sends
+	s.failures += o.failures
+	s.retries += o.retries
+	for k, n := range o.errors {
+		s.errors[k] += n
+	}
+	for k, n := range o.operations {
+		s.operations[k] += n
+	}
+	s.latency.merge(&o.latency)
+	s.queueLatency.merge(&o.queueLatency)
+}
+
+func (s *windowStats) summary(window time.Duration) *WindowStats {
+	out := &WindowStats{
+		Sends:        s.sends,
+		Failures:     s.failures,
+		Throughput:   float64(s.sends) / window.Seconds(),
+		Errors:       s.errors,
+		Operations:   s.operations,
+		Retries:      s.retries,
+		Latency:      percentiles(&s.latency),
+		QueueLatency: percentiles(&s.queueLatency),
+	}
+	if s.sends > 0 {
+		out.ErrorRate = float64(s.failures) / float64(s.sends)
+	}
+	return out
+}
+
+func percentiles(s *sketch) Percentiles {
+	seconds := func(v float64) time.Duration { return time.Duration(v * float64(time.Second)) }
+	p := Percentiles{
+		P50: seconds(s.quantile(0.5)),
+		P95: seconds(s.quantile(0.95)),
+		P99: seconds(s.quantile(0.99)),
+		Max: seconds(s.max),
+	}
+	if s.count > 0 {
+		p.Mean = seconds(s.sum / float64(s.count))
+	}
+	return p
+}
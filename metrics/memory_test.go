@@ -3,6 +3,7 @@ package metrics_test
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/shellvon/go-sender/core"
 	"github.com/shellvon/go-sender/metrics"
@@ -41,3 +42,65 @@ func TestMemoryMetricsCollector_Concurrency(t *testing.T) {
 		t.Errorf("expected 100 total, got %d", total)
 	}
 }
+
+func TestMemoryMetricsCollector_Snapshot(t *testing.T) {
+	m := metrics.NewMemoryMetricsCollector()
+	for i := 1; i <= 1000; i++ {
+		m.RecordSendResult(core.MetricsData{
+			Provider:   "sms",
+			Account:    "aliyun-1",
+			Operation:  core.OperationSent,
+			Success:    i%10 != 0,
+			ErrorType:  "2101",
+			Duration:   time.Duration(i) * time.Millisecond,
+			RetryCount: 1,
+		})
+	}
+	m.RecordSendResult(core.MetricsData{Provider: "sms", Operation: core.OperationDequeue, Success: true, QueueLatency: time.Second})
+
+	snapshot, err := m.Snapshot(metrics.Window1m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := snapshot.Providers["sms"]
+	if stats == nil || stats.Sends != 1000 || stats.Failures != 100 || stats.ErrorRate != 0.1 {
+		t.Fatalf("unexpected provider stats: %+v", stats)
+	}
+	if stats.Errors["2101"] != 100 || stats.Retries != 1000 || stats.Operations[core.OperationDequeue] != 1 {
+		t.Errorf("unexpected breakdowns: %+v", stats)
+	}
+	for _, tc := range []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"p50", stats.Latency.P50, 500 * time.Millisecond},
+		{"p95", stats.Latency.P95, 950 * time.Millisecond},
+		{"p99", stats.Latency.P99, 990 * time.Millisecond},
+		{"queue p50", stats.QueueLatency.P50, time.Second},
+	} {
+		if diff := tc.got - tc.want; diff < -tc.want/50 || diff > tc.want/50 {
+			t.Errorf("%s = %v, want %v ±2%%", tc.name, tc.got, tc.want)
+		}
+	}
+	if account := snapshot.Accounts["sms"]["aliyun-1"]; account == nil || account.Sends != 1000 {
+		t.Errorf("unexpected account stats: %+v", account)
+	}
+	if _, err := m.Snapshot(time.Second); err == nil {
+		t.Error("expected an error for a window that is not tracked")
+	}
+}
+
+func TestMemoryMetricsCollector_WindowExpires(t *testing.T) {
+	m := metrics.NewMemoryMetricsCollector(metrics.WithWindows(120*time.Millisecond), metrics.WithHealthWindow(120*time.Millisecond))
+	m.RecordSendResult(core.MetricsData{Provider: "sms", Success: false, Duration: 20 * time.Millisecond})
+	if stats, ok := m.ProviderStats("sms"); !ok || stats.ErrorRate != 1 || stats.Latency == 0 {
+		t.Fatalf("unexpected health stats: %+v", stats)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if stats, ok := m.ProviderStats("sms"); ok {
+		t.Errorf("expected the window to be empty, got %+v", stats)
+	}
+	if total, _, _ := m.GetStats("sms"); total != 1 {
+		t.Errorf("lifetime totals should not expire, got %d", total)
+	}
+}
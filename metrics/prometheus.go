@@ -0,0 +1,367 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the send latency histogram.
+//
+//nolint:gochecknoglobals // default bucket layout
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// DefaultQueueLatencyBuckets are the upper bounds, in seconds, of the queue latency histogram.
+//
+//nolint:gochecknoglobals // default bucket layout
+var DefaultQueueLatencyBuckets = []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600}
+
+// DefaultNamespace prefixes the names of the metrics of a PrometheusCollector.
+const DefaultNamespace = "gosender"
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusCollector is a core.MetricsCollector that serves what it collects in
+// the Prometheus text exposition format, without depending on the Prometheus
+// client library. It is an http.Handler to mount on the scrape path:
+//
+//	collector := metrics.NewPrometheusCollector()
+//	sender.SetMetrics(collector)
+//	http.Handle("/metrics", collector)
+//
+// It exposes, with the default namespace:
+//
+//   - gosender_operations_total, a counter by provider, account, sub_provider,
+//     operation, outcome and error_code;
+//   - gosender_send_duration_seconds, a histogram of sent operations by provider,
+//     account, sub_provider and outcome;
+//   - gosender_queue_latency_seconds, a histogram of the time messages waited in
+//     the queue, by provider;
+//   - gosender_queue_size, the last queue size seen by provider;
+//   - gosender_circuit_breaker_open, 1 for each breaker registered with
+//     ObserveBreaker that is open, else 0.
+type PrometheusCollector struct {
+	namespace           string
+	latencyBuckets      []float64
+	queueLatencyBuckets []float64
+
+	mu           sync.Mutex
+	operations   map[operationKey]uint64
+	durations    map[durationKey]*histogram
+	queueLatency map[string]*histogram
+	queueSize    map[string]int
+	breakers     map[string]core.CircuitStateReporter
+}
+
+var (
+	_ core.MetricsCollector = (*PrometheusCollector)(nil)
+	_ http.Handler          = (*PrometheusCollector)(nil)
+)
+
+// PrometheusOption configures a PrometheusCollector.
+type PrometheusOption func(*PrometheusCollector)
+
+// WithNamespace sets the prefix of the metric names, DefaultNamespace by default.
+func WithNamespace(namespace string) PrometheusOption {
+	return func(c *PrometheusCollector) {
+		c.namespace = namespace
+	}
+}
+
+// WithLatencyBuckets sets the upper bounds, in seconds, of the send latency histogram.
+func WithLatencyBuckets(buckets ...float64) PrometheusOption {
+	return func(c *PrometheusCollector) {
+		if len(buckets) > 0 {
+			c.latencyBuckets = sortedBuckets(buckets)
+		}
+	}
+}
+
+// WithQueueLatencyBuckets sets the upper bounds, in seconds, of the queue latency histogram.
+func WithQueueLatencyBuckets(buckets ...float64) PrometheusOption {
+	return func(c *PrometheusCollector) {
+		if len(buckets) > 0 {
+			c.queueLatencyBuckets = sortedBuckets(buckets)
+		}
+	}
+}
+
+// NewPrometheusCollector creates a PrometheusCollector.
+func NewPrometheusCollector(opts ...PrometheusOption) *PrometheusCollector {
+	c := &PrometheusCollector{
+		namespace:           DefaultNamespace,
+		latencyBuckets:      DefaultLatencyBuckets,
+		queueLatencyBuckets: DefaultQueueLatencyBuckets,
+		operations:          make(map[operationKey]uint64),
+		durations:           make(map[durationKey]*histogram),
+		queueLatency:        make(map[string]*histogram),
+		queueSize:           make(map[string]int),
+		breakers:            make(map[string]core.CircuitStateReporter),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ObserveBreaker exposes the state of breaker under name. Breakers that do not
+// implement core.CircuitStateReporter cannot be observed and are ignored.
+func (c *PrometheusCollector) ObserveBreaker(name string, breaker core.CircuitBreaker) {
+	reporter, ok := breaker.(core.CircuitStateReporter)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.breakers[name] = reporter
+}
+
+// RecordSendResult records the outcome of an operation, see core.MetricsCollector.
+func (c *PrometheusCollector) RecordSendResult(data core.MetricsData) {
+	outcome := "success"
+	if !data.Success {
+		outcome = "failure"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.operations[operationKey{
+		provider:  data.Provider,
+		account:   data.Account,
+		subType:   data.SubType,
+		operation: data.Operation,
+		outcome:   outcome,
+		errorCode: data.ErrorType,
+	}]++
+
+	switch data.Operation {
+	case core.OperationSent:
+		key := durationKey{provider: data.Provider, account: data.Account, subType: data.SubType, outcome: outcome}
+		observe(c.durations, key, c.latencyBuckets, data.Duration.Seconds())
+	case core.OperationDequeue:
+		observe(c.queueLatency, data.Provider, c.queueLatencyBuckets, data.QueueLatency.Seconds())
+		c.queueSize[data.Provider] = data.QueueSize
+	case core.OperationEnqueue:
+		c.queueSize[data.Provider] = data.QueueSize
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *PrometheusCollector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format to w.
+func (c *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	c.mu.Lock()
+	operations := c.sortedOperations()
+	durations := c.sortedDurations()
+	queueProviders := sortedKeys(c.queueLatency)
+	sizeProviders := sortedKeys(c.queueSize)
+	breakerNames := sortedKeys(c.breakers)
+
+	name := c.namespace + "_operations_total"
+	cw.header(name, "counter", "Operations of the providers by outcome.")
+	for _, key := range operations {
+		cw.sample(name, key.labels(), strconv.FormatUint(c.operations[key], 10))
+	}
+
+	name = c.namespace + "_send_duration_seconds"
+	cw.header(name, "histogram", "Duration of sends, retries included.")
+	for _, key := range durations {
+		cw.histogram(name, key.labels(), c.latencyBuckets, c.durations[key])
+	}
+
+	name = c.namespace + "_queue_latency_seconds"
+	cw.header(name, "histogram", "Time messages waited in the queue.")
+	for _, provider := range queueProviders {
+		cw.histogram(name, []label{{"provider", provider}}, c.queueLatencyBuckets, c.queueLatency[provider])
+	}
+
+	name = c.namespace + "_queue_size"
+	cw.header(name, "gauge", "Messages in the queue of the providers.")
+	for _, provider := range sizeProviders {
+		cw.sample(name, []label{{"provider", provider}}, strconv.Itoa(c.queueSize[provider]))
+	}
+
+	breakers := make([]core.CircuitStateReporter, len(breakerNames))
+	for i, breaker := range breakerNames {
+		breakers[i] = c.breakers[breaker]
+	}
+	c.mu.Unlock()
+
+	// Breakers take their own locks, so they are queried outside of c.mu.
+	name = c.namespace + "_circuit_breaker_open"
+	cw.header(name, "gauge", "Whether the circuit breakers are open.")
+	for i, breaker := range breakers {
+		value := "0"
+		if breaker.IsOpen() {
+			value = "1"
+		}
+		cw.sample(name, []label{{"breaker", breakerNames[i]}}, value)
+	}
+
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// operationKey identifies a series of the operations counter.
+type operationKey struct {
+	provider, account, subType, operation, outcome, errorCode string
+}
+
+func (k operationKey) labels() []label {
+	return []label{
+		{"provider", k.provider},
+		{"account", k.account},
+		{"sub_provider", k.subType},
+		{"operation", k.operation},
+		{"outcome", k.outcome},
+		{"error_code", k.errorCode},
+	}
+}
+
+// durationKey identifies a series of the send duration histogram.
+type durationKey struct {
+	provider, account, subType, outcome string
+}
+
+func (k durationKey) labels() []label {
+	return []label{
+		{"provider", k.provider},
+		{"account", k.account},
+		{"sub_provider", k.subType},
+		{"outcome", k.outcome},
+	}
+}
+
+func (c *PrometheusCollector) sortedOperations() []operationKey {
+	keys := make([]operationKey, 0, len(c.operations))
+	for key := range c.operations {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b operationKey) int { return compareLabels(a.labels(), b.labels()) })
+	return keys
+}
+
+func (c *PrometheusCollector) sortedDurations() []durationKey {
+	keys := make([]durationKey, 0, len(c.durations))
+	for key := range c.durations {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b durationKey) int { return compareLabels(a.labels(), b.labels()) })
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func sortedBuckets(buckets []float64) []float64 {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return slices.Compact(buckets)
+}
+
+// histogram counts observations per bucket; counts[i] holds the observations
+// above bounds[i-1] and up to bounds[i], the last entry those above all bounds.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func observe[K comparable](m map[K]*histogram, key K, bounds []float64, value float64) {
+	h, ok := m[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(bounds)+1)}
+		m[key] = h
+	}
+	h.counts[sort.SearchFloat64s(bounds, value)]++
+	h.count++
+	h.sum += value
+}
+
+type label struct {
+	name, value string
+}
+
+func compareLabels(a, b []label) int {
+	for i := range min(len(a), len(b)) {
+		if c := strings.Compare(a[i].value, b[i].value); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+//
+//nolint:gochecknoglobals // stateless replacer
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// countingWriter writes the exposition format and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) write(s string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.WriteString(s)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countingWriter) header(name, typ, help string) {
+	cw.write("# HELP " + name + " " + help + "\n# TYPE " + name + " " + typ + "\n")
+}
+
+func (cw *countingWriter) sample(name string, labels []label, value string) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(l.name + `="` + labelEscaper.Replace(l.value) + `"`)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteString(" " + value + "\n")
+	cw.write(b.String())
+}
+
+func (cw *countingWriter) histogram(name string, labels []label, bounds []float64, h *histogram) {
+	var cumulative uint64
+	for i, bound := range bounds {
+		cumulative += h.counts[i]
+		le := label{"le", strconv.FormatFloat(bound, 'g', -1, 64)}
+		cw.sample(name+"_bucket", append(slices.Clone(labels), le), strconv.FormatUint(cumulative, 10))
+	}
+	cw.sample(name+"_bucket", append(slices.Clone(labels), label{"le", "+Inf"}), strconv.FormatUint(h.count, 10))
+	cw.sample(name+"_sum", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	cw.sample(name+"_count", labels, strconv.FormatUint(h.count, 10))
+}
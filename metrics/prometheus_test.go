@@ -0,0 +1,89 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/circuitbreaker"
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/metrics"
+)
+
+func scrape(t *testing.T, c *metrics.PrometheusCollector) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestPrometheusCollector_Exposition(t *testing.T) {
+	c := metrics.NewPrometheusCollector(metrics.WithLatencyBuckets(0.1, 1))
+	sent := core.MetricsData{
+		Provider:  "sms",
+		Account:   "aliyun-1",
+		SubType:   "aliyun",
+		Operation: core.OperationSent,
+		Success:   true,
+		Duration:  50 * time.Millisecond,
+	}
+	c.RecordSendResult(sent)
+	c.RecordSendResult(sent)
+	sent.Success, sent.ErrorType, sent.Duration = false, "2101", 2*time.Second
+	c.RecordSendResult(sent)
+	c.RecordSendResult(core.MetricsData{
+		Provider:     "sms",
+		Operation:    core.OperationDequeue,
+		Success:      true,
+		QueueLatency: 3 * time.Second,
+		QueueSize:    7,
+	})
+
+	body := scrape(t, c)
+	for _, want := range []string{
+		"# TYPE gosender_operations_total counter\n",
+		`gosender_operations_total{provider="sms",account="aliyun-1",sub_provider="aliyun",operation="sent",outcome="success",error_code=""} 2`,
+		`gosender_operations_total{provider="sms",account="aliyun-1",sub_provider="aliyun",operation="sent",outcome="failure",error_code="2101"} 1`,
+		`gosender_send_duration_seconds_bucket{provider="sms",account="aliyun-1",sub_provider="aliyun",outcome="success",le="0.1"} 2`,
+		`gosender_send_duration_seconds_bucket{provider="sms",account="aliyun-1",sub_provider="aliyun",outcome="failure",le="1"} 0`,
+		`gosender_send_duration_seconds_bucket{provider="sms",account="aliyun-1",sub_provider="aliyun",outcome="failure",le="+Inf"} 1`,
+		`gosender_send_duration_seconds_count{provider="sms",account="aliyun-1",sub_provider="aliyun",outcome="success"} 2`,
+		`gosender_queue_latency_seconds_bucket{provider="sms",le="5"} 1`,
+		`gosender_queue_latency_seconds_sum{provider="sms"} 3`,
+		`gosender_queue_size{provider="sms"} 7`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestPrometheusCollector_EscapesLabels(t *testing.T) {
+	c := metrics.NewPrometheusCollector(metrics.WithNamespace("app"))
+	c.RecordSendResult(core.MetricsData{Provider: "a\"b\\c\nd", Operation: core.OperationEnqueue, Success: true})
+	if body := scrape(t, c); !strings.Contains(body, `app_operations_total{provider="a\"b\\c\nd",`) {
+		t.Errorf("label not escaped:\n%s", body)
+	}
+}
+
+func TestPrometheusCollector_BreakerGauge(t *testing.T) {
+	c := metrics.NewPrometheusCollector()
+	cb := circuitbreaker.NewMemoryCircuitBreaker("sms", 1, time.Minute)
+	c.ObserveBreaker("sms", cb)
+	if body := scrape(t, c); !strings.Contains(body, `gosender_circuit_breaker_open{breaker="sms"} 0`) {
+		t.Errorf("expected a closed breaker:\n%s", body)
+	}
+	_ = cb.Execute(context.Background(), func() error { return errors.New("down") })
+	if body := scrape(t, c); !strings.Contains(body, `gosender_circuit_breaker_open{breaker="sms"} 1`) {
+		t.Errorf("expected an open breaker:\n%s", body)
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"math"
+	"slices"
+)
+
+// sketchAccuracy is the relative error of the quantiles of a sketch.
+const sketchAccuracy = 0.01
+
+// sketchMinValue is the smallest value told apart from zero.
+const sketchMinValue = 1e-9
+
+//nolint:gochecknoglobals // derived constants of the sketch
+var (
+	sketchGamma    = (1 + sketchAccuracy) / (1 - sketchAccuracy)
+	sketchLogGamma = math.Log(sketchGamma)
+)
+
+// sketch estimates quantiles of positive values within sketchAccuracy of their
+// true value, in the manner of DDSketch: values are counted in logarithmic bins,
+// so sketches of different periods merge exactly by adding their bins.
+type sketch struct {
+	bins  map[int]uint64
+	zeros uint64
+	count uint64
+	sum   float64
+	max   float64
+}
+
+func (s *sketch) add(v float64) {
+	s.count++
+	s.sum += v
+	s.max = max(s.max, v)
+	if v < sketchMinValue {
+		s.zeros++
+		return
+	}
+	if s.bins == nil {
+		s.bins = make(map[int]uint64)
+	}
+	s.bins[int(math.Ceil(math.Log(v)/sketchLogGamma))]++
+}
+
+func (s *sketch) merge(o *sketch) {
+	if o.count == 0 {
+		return
+	}
+	if s.bins == nil {
+		s.bins = make(map[int]uint64, len(o.bins))
+	}
+	for idx, n := range o.bins {
+		s.bins[idx] += n
+	}
+	s.zeros += o.zeros
+	s.count += o.count
+	s.sum += o.sum
+	s.max = max(s.max, o.max)
+}
+
+// quantile returns the estimated q-quantile, 0 for an empty sketch.
+func (s *sketch) quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(s.count-1))
+	if rank < s.zeros {
+		return 0
+	}
+	seen := s.zeros
+	indexes := make([]int, 0, len(s.bins))
+	for idx := range s.bins {
+		indexes = append(indexes, idx)
+	}
+	slices.Sort(indexes)
+	for _, idx := range indexes {
+		seen += s.bins[idx]
+		if seen > rank {
+			// The bin holds (gamma^(idx-1), gamma^idx]; its center is within the accuracy.
+			return min(2*math.Pow(sketchGamma, float64(idx))/(sketchGamma+1), s.max)
+		}
+	}
+	return s.max
+}
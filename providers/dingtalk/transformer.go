@@ -59,13 +59,14 @@ func (dt *dingTalkTransformer) transform(
 
 func newDingTalkTransformer() *dingTalkTransformer {
 	respCfg := &core.ResponseHandlerConfig{
-		BodyType:  core.BodyTypeJSON,
-		CheckBody: true,
-		Path:      "errcode",
-		Expect:    "0",
-		Mode:      core.MatchEq,
-		CodePath:  "errcode",
-		MsgPath:   "errmsg",
+		BodyType:   core.BodyTypeJSON,
+		CheckBody:  true,
+		Path:       "errcode",
+		Expect:     "0",
+		Mode:       core.MatchEq,
+		CodePath:   "errcode",
+		MsgPath:    "errmsg",
+		ErrorKinds: errorKinds,
 	}
 
 	dt := &dingTalkTransformer{}
@@ -77,3 +78,14 @@ func newDingTalkTransformer() *dingTalkTransformer {
 	)
 	return dt
 }
+
+// errorKinds classifies the errcode of the custom robot API, see core.VendorError.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var errorKinds = core.ErrorKinds{
+	"-1":     core.ErrorKindTransient,      // 系统繁忙
+	"300001": core.ErrorKindAuthentication, // token is not exist
+	"310000": core.ErrorKindAuthentication, // 安全设置校验失败：关键词、加签或 IP
+	"130101": core.ErrorKindRateLimited,    // send too fast
+	"410100": core.ErrorKindRateLimited,    // 发送速度太快而限流
+}
@@ -3,6 +3,7 @@ package email
 import (
 	"errors"
 	"net/mail"
+	"strings"
 
 	"github.com/shellvon/go-sender/core"
 )
@@ -25,10 +26,11 @@ type Message struct {
 	Attachments []string // List of attachment file paths
 }
 
-// Compile-time assertion: Message implements core.Message and core.Validatable.
+// Compile-time assertion: Message implements core.Message, core.Validatable and core.RoutingKeyer.
 var (
-	_ core.Message     = (*Message)(nil)
-	_ core.Validatable = (*Message)(nil)
+	_ core.Message      = (*Message)(nil)
+	_ core.Validatable  = (*Message)(nil)
+	_ core.RoutingKeyer = (*Message)(nil)
 )
 
 // NewMessage creates a new email message with required fields only.
@@ -66,6 +68,18 @@ func (m *Message) Validate() error {
 	return nil
 }
 
+// RoutingKey implements core.RoutingKeyer: a message to a single recipient is
+// routed by that address, so keyed strategies keep it on the same account.
+func (m *Message) RoutingKey() string {
+	if len(m.To) != 1 {
+		return ""
+	}
+	if addr, err := mail.ParseAddress(m.To[0]); err == nil {
+		return strings.ToLower(addr.Address)
+	}
+	return m.To[0]
+}
+
 // validateEmail checks if an email address is valid.
 func validateEmail(email string) error {
 	if email == "" {
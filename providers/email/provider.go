@@ -29,7 +29,10 @@ type Provider struct {
 	config *Config
 }
 
-var _ core.Provider = (*Provider)(nil)
+var (
+	_ core.Provider = (*Provider)(nil)
+	_ core.Prober   = (*Provider)(nil)
+)
 
 // New creates a new email provider instance.
 func New(config *Config) (*Provider, error) {
@@ -87,7 +90,9 @@ func (p *Provider) Send(
 	if err != nil {
 		return nil, err
 	}
-	err = p.doSendEmail(ctx, account, emailMsg)
+	err = p.config.Execute(ctx, account, func() error {
+		return p.doSendEmail(ctx, account, emailMsg)
+	})
 
 	return &core.SendResult{StatusCode: 0}, err
 }
@@ -178,15 +183,36 @@ func (p *Provider) doSendEmail(ctx context.Context, account *Account, emailMsg *
 
 	defer client.Close()
 
+	ctx, span := core.StartSpan(ctx, core.SpanSMTP,
+		core.Attr("account", account.Name),
+		core.Attr("smtp.host", account.Host),
+	)
 	err = client.DialAndSendWithContext(ctx, m)
 	var sendErr *mail.SendError
 	// https://github.com/wneessen/go-mail/issues/463
 	if err != nil && errors.As(err, &sendErr) && sendErr.Reason == mail.ErrSMTPReset {
 		err = nil
 	}
+	core.FinishSpan(span, err)
 	return err
 }
 
+// Probe implements core.Prober: it connects to the SMTP server of every enabled
+// account, greets it with EHLO, negotiates TLS and authenticates, then quits
+// without sending anything.
+func (p *Provider) Probe(ctx context.Context, _ *core.ProviderSendOptions) []core.ProbeResult {
+	return p.config.ProbeItems(ctx, func(ctx context.Context, account *Account) (map[string]interface{}, error) {
+		client, err := mail.NewClient(account.Host, buildMailOptions(account)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create mail client: %w", err)
+		}
+		if err = client.DialWithContext(ctx); err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", account.Host, err)
+		}
+		return nil, client.Close()
+	})
+}
+
 func (p *Provider) Name() string {
 	return string(core.ProviderTypeEmail)
 }
@@ -1,8 +1,14 @@
 package email_test
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/shellvon/go-sender/core"
 	"github.com/shellvon/go-sender/providers/email"
@@ -320,3 +326,81 @@ func TestConfigMethods(t *testing.T) {
 		t.Errorf("Expected GetStrategy() to return StrategyRandom, got %s", strategy)
 	}
 }
+
+// fakeSMTPServer accepts SMTP sessions, advertising AUTH PLAIN and accepting only
+// the password "secret". It returns the address it listens on.
+func fakeSMTPServer(t *testing.T) (string, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, acceptErr := ln.Accept()
+			if acceptErr != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				fmt.Fprint(conn, "220 fake ESMTP\r\n")
+				for {
+					line, readErr := r.ReadString('\n')
+					if readErr != nil {
+						return
+					}
+					switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+					case strings.HasPrefix(cmd, "EHLO"):
+						fmt.Fprint(conn, "250-fake\r\n250 AUTH PLAIN\r\n")
+					case strings.HasPrefix(cmd, "AUTH PLAIN"):
+						creds, _ := base64.StdEncoding.DecodeString(strings.Fields(strings.TrimSpace(line))[2])
+						if strings.HasSuffix(string(creds), "\x00secret") {
+							fmt.Fprint(conn, "235 ok\r\n")
+						} else {
+							fmt.Fprint(conn, "535 authentication failed\r\n")
+						}
+					case strings.HasPrefix(cmd, "QUIT"):
+						fmt.Fprint(conn, "221 bye\r\n")
+						return
+					default:
+						fmt.Fprint(conn, "250 ok\r\n")
+					}
+				}
+			}()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestProviderProbe(t *testing.T) {
+	host, port := fakeSMTPServer(t)
+	provider, err := email.NewProvider([]*email.Account{
+		email.NewAccount(host, port, "user@example.com", "secret", email.Name("good")),
+		email.NewAccount(host, port, "user@example.com", "wrong", email.Name("bad-password")),
+		email.NewAccount(host, 1, "user@example.com", "secret", email.Name("unreachable")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	results := make(map[string]error)
+	for _, result := range provider.Probe(ctx, nil) {
+		results[result.Account] = result.Err
+	}
+	if results["good"] != nil {
+		t.Errorf("expected the probe to log in, got %v", results["good"])
+	}
+	if results["bad-password"] == nil {
+		t.Error("expected the wrong password to fail the probe")
+	}
+	if results["unreachable"] == nil {
+		t.Error("expected the unreachable server to fail the probe")
+	}
+}
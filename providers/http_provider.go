@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"net/url"
 
 	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/ratelimiter"
 	"github.com/shellvon/go-sender/utils"
 )
 
@@ -19,6 +21,13 @@ type HTTPProvider[T core.Selectable] struct {
 
 // NewHTTPProvider creates a new HTTP Provider from a config object.
 // The config must implement Validate, GetItems, and GetStrategy.
+//
+// Unless the config already has an AccountGuard or sets DisableQuotas, a guard
+// enforcing the built-in vendor quotas of name is attached (see
+// ratelimiter.NewQuotaGuard). Messages implementing core.RecipientLister are
+// limited per recipient, each of them; otherwise messages implementing
+// core.RoutingKeyer are limited by their routing key. Over-quota sends fail with
+// ErrCodeRateLimitExceeded unless the sender's RateLimitWait lets them wait.
 func NewHTTPProvider[T core.Selectable](
 	name string,
 	transformer core.HTTPTransformer[T],
@@ -30,6 +39,10 @@ func NewHTTPProvider[T core.Selectable](
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
+	// Enforce the vendor's quotas unless a guard was attached explicitly.
+	if config.AccountGuard() == nil && !config.GetProviderMeta().DisableQuotas {
+		config.SetAccountGuard(ratelimiter.NewQuotaGuard(name))
+	}
 	return &HTTPProvider[T]{
 		name:        name,
 		config:      config,
@@ -46,6 +59,17 @@ func (p *HTTPProvider[T]) Send(
 	ctx context.Context,
 	msg core.Message,
 	opts *core.ProviderSendOptions,
+) (*core.SendResult, error) {
+	return p.SendFiltered(ctx, msg, opts, nil)
+}
+
+// SendFiltered is like Send, but only selects among the items accepted by accept.
+// A nil accept accepts every item.
+func (p *HTTPProvider[T]) SendFiltered(
+	ctx context.Context,
+	msg core.Message,
+	opts *core.ProviderSendOptions,
+	accept func(T) bool,
 ) (*core.SendResult, error) {
 	if opts == nil {
 		opts = &core.ProviderSendOptions{}
@@ -57,7 +81,18 @@ func (p *HTTPProvider[T]) Send(
 		if subProviderMsg, ok := msg.(interface{ GetSubProvider() string }); ok {
 			sub = subProviderMsg.GetSubProvider()
 		}
-		return sub == "" || item.GetType() == sub
+		return (sub == "" || item.GetType() == sub) && (accept == nil || accept(item))
+	}
+
+	if lister, ok := msg.(core.RecipientLister); ok {
+		ctx = core.WithRecipients(ctx, lister.Recipients()...)
+	} else if keyer, ok := msg.(core.RoutingKeyer); ok {
+		ctx = core.WithRecipient(ctx, keyer.RoutingKey())
+	}
+
+	estimator, _ := msg.(core.CostEstimator)
+	if estimator != nil {
+		ctx = core.WithCostEstimator(ctx, estimator)
 	}
 
 	selectedConfig, err := p.config.Select(ctx, filter)
@@ -71,19 +106,54 @@ func (p *HTTPProvider[T]) Send(
 		return nil, fmt.Errorf("failed to transform message: %w", err)
 	}
 
-	// Execute HTTP request capturing detailed response
-	result, err := p.ExecuteHTTPRequest(ctx, reqSpec, handler, opts)
+	// Execute HTTP request capturing detailed response, guarded by the
+	// account's circuit breaker when one is configured.
+	var result *core.SendResult
+	err = p.config.Execute(ctx, selectedConfig, func() error {
+		var execErr error
+		result, execErr = p.ExecuteHTTPRequest(ctx, reqSpec, handler, opts)
+		return execErr
+	})
 	if result != nil {
 		result.Config = selectedConfig // attach config for observability
+		if estimator != nil {
+			result.Cost, _ = estimator.EstimateCost(selectedConfig)
+		}
 	}
 	return result, err
 }
 
+// ExecuteHTTPRequest sends reqSpec and checks the response with handler, in a
+// core.SpanHTTP span.
 func (p *HTTPProvider[T]) ExecuteHTTPRequest(
 	ctx context.Context,
 	reqSpec *core.HTTPRequestSpec,
 	handler core.SendResultHandler,
 	opts *core.ProviderSendOptions,
+) (*core.SendResult, error) {
+	// Only the host is recorded: webhook paths and queries often hold credentials.
+	var host string
+	if u, err := url.Parse(reqSpec.URL); err == nil {
+		host = u.Host
+	}
+	ctx, span := core.StartSpan(ctx, core.SpanHTTP,
+		core.Attr("provider", p.name),
+		core.Attr("http.method", reqSpec.Method),
+		core.Attr("http.host", host),
+	)
+	result, err := p.executeHTTPRequest(ctx, reqSpec, handler, opts)
+	if result != nil {
+		span.SetAttributes(core.Attr("http.status_code", result.StatusCode))
+	}
+	core.FinishSpan(span, err)
+	return result, err
+}
+
+func (p *HTTPProvider[T]) executeHTTPRequest(
+	ctx context.Context,
+	reqSpec *core.HTTPRequestSpec,
+	handler core.SendResultHandler,
+	opts *core.ProviderSendOptions,
 ) (*core.SendResult, error) {
 	// Prepare headers map (ensure non-nil so we can mutate)
 	headers := make(map[string]string)
@@ -132,6 +202,19 @@ func (p *HTTPProvider[T]) ExecuteHTTPRequest(
 	return result, nil
 }
 
+// Execute runs fn, a send using item, through item's circuit breaker if the
+// config has an AccountGuard.
+func (p *HTTPProvider[T]) Execute(ctx context.Context, item T, fn func() error) error {
+	return p.config.Execute(ctx, item, fn)
+}
+
+// ProbeItems runs probe on every enabled item and records the results for
+// selection, see core.BaseConfig.ProbeItems. Providers implementing core.Prober
+// use it with a request that checks the account without sending a message.
+func (p *HTTPProvider[T]) ProbeItems(ctx context.Context, probe core.ProbeFunc[T]) []core.ProbeResult {
+	return p.config.ProbeItems(ctx, probe)
+}
+
 // Name returns the provider name.
 func (p *HTTPProvider[T]) Name() string {
 	return p.name
@@ -64,13 +64,14 @@ func (lt *larkTransformer) transform(
 
 func newLarkTransformer() *larkTransformer {
 	respCfg := &core.ResponseHandlerConfig{
-		BodyType:  core.BodyTypeJSON,
-		CheckBody: true,
-		Path:      "code",
-		Expect:    "0",
-		Mode:      core.MatchEq,
-		CodePath:  "code",
-		MsgPath:   "msg",
+		BodyType:   core.BodyTypeJSON,
+		CheckBody:  true,
+		Path:       "code",
+		Expect:     "0",
+		Mode:       core.MatchEq,
+		CodePath:   "code",
+		MsgPath:    "msg",
+		ErrorKinds: errorKinds,
 	}
 
 	lt := &larkTransformer{}
@@ -82,3 +83,15 @@ func newLarkTransformer() *larkTransformer {
 	)
 	return lt
 }
+
+// errorKinds classifies the code of the custom bot API, see core.VendorError.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var errorKinds = core.ErrorKinds{
+	"9499":  core.ErrorKindValidation,     // Bad Request
+	"19001": core.ErrorKindValidation,     // param invalid
+	"19024": core.ErrorKindValidation,     // Key Words Not Found
+	"19021": core.ErrorKindAuthentication, // sign match fail or timestamp is not within one hour
+	"19022": core.ErrorKindAuthentication, // Ip Not Allowed
+	"11232": core.ErrorKindRateLimited,    // frequency limited
+}
@@ -3,6 +3,7 @@ package sms
 import (
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/shellvon/go-sender/core"
 )
@@ -11,7 +12,7 @@ import (
 // It follows the three-tier design: AccountMeta + Credentials + extra
 //   - AccountMeta: Name, Weight, Disabled (from core.BaseAccount)
 //   - Credentials: APIKey, APISecret (from core.BaseAccount)
-//   - Extra: SignName, Region, Callback (SMS-specific configuration)
+//   - Extra: SignName, Region, Callback, Pricing, region codes (SMS-specific configuration)
 //
 // It embeds core.BaseAccount so it automatically satisfies core.BasicAccount
 // and core.Selectable interfaces.
@@ -22,6 +23,15 @@ type Account struct {
 	SignName string `json:"sign_name,omitempty"` // SMS signature/sign name
 	Region   string `json:"region,omitempty"`    // SMS service region (e.g. cn-hangzhou)
 	Callback string `json:"callback,omitempty"`  // Callback URL for delivery reports
+
+	// Pricing is used to estimate send costs and by the "cheapest" strategy.
+	Pricing *PriceTable `json:"pricing,omitempty"`
+
+	// RegionCodes lists the E.164 country codes (e.g. 86, 852, 1) the account can
+	// send to. Empty means every region not in DeniedRegionCodes.
+	RegionCodes []int `json:"region_codes,omitempty"`
+	// DeniedRegionCodes lists the country codes the account must not send to.
+	DeniedRegionCodes []int `json:"denied_region_codes,omitempty"`
 }
 
 // AccountOption represents a function that modifies SMS Account configuration.
@@ -72,6 +82,14 @@ func (a *Account) Password() string { return a.GetCredentials().APISecret }
 // SubProvider returns the SMS sub-provider name (e.g., "aliyun", "tencent").
 func (a *Account) SubProvider() string { return a.AccountMeta.SubType }
 
+// ServesRegion reports whether the account may send to the E.164 country code.
+func (a *Account) ServesRegion(regionCode int) bool {
+	if slices.Contains(a.DeniedRegionCodes, regionCode) {
+		return false
+	}
+	return len(a.RegionCodes) == 0 || slices.Contains(a.RegionCodes, regionCode)
+}
+
 // Validate validates the SMS account configuration.
 func (a *Account) Validate() error {
 	// First run the base validation
@@ -110,6 +128,27 @@ func WithCallback(callback string) AccountOption {
 	}
 }
 
+// WithPricing sets the price table used for cost estimates.
+func WithPricing(pricing *PriceTable) AccountOption {
+	return func(a *Account) {
+		a.Pricing = pricing
+	}
+}
+
+// WithRegionCodes restricts the account to the given E.164 country codes.
+func WithRegionCodes(codes ...int) AccountOption {
+	return func(a *Account) {
+		a.RegionCodes = codes
+	}
+}
+
+// WithDeniedRegionCodes forbids the account to send to the given E.164 country codes.
+func WithDeniedRegionCodes(codes ...int) AccountOption {
+	return func(a *Account) {
+		a.DeniedRegionCodes = codes
+	}
+}
+
 // Re-exported core account options for cleaner API
 // These provide convenient aliases: sms.Name("test") instead of core.WithName[*sms.Account]("test").
 var (
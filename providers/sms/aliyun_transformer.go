@@ -55,13 +55,14 @@ func newAliyunTransformer() *aliyunTransformer {
 	transformer.BaseTransformer = NewBaseTransformer(
 		string(SubProviderAliyun),
 		&core.ResponseHandlerConfig{
-			BodyType:  core.BodyTypeJSON,
-			CheckBody: true,
-			Path:      "Code",
-			Expect:    "OK",
-			Mode:      core.MatchEq,
-			CodePath:  "Code",
-			MsgPath:   "Message",
+			BodyType:   core.BodyTypeJSON,
+			CheckBody:  true,
+			Path:       "Code",
+			Expect:     "OK",
+			Mode:       core.MatchEq,
+			CodePath:   "Code",
+			MsgPath:    "Message",
+			ErrorKinds: aliyunErrorKinds,
 		},
 		HTTPOptions{
 			AddBeforeHook(func(_ context.Context, msg *Message, account *Account) error {
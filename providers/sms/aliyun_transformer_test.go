@@ -2,6 +2,8 @@ package sms_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/shellvon/go-sender/core"
@@ -86,3 +88,27 @@ func TestAliyunTransformer_Transform_VoiceWithVoiceParam(t *testing.T) {
 		t.Fatal("Transform should return spec and handler for voice")
 	}
 }
+
+func TestAliyunTransformer_ClassifiesErrors(t *testing.T) {
+	tr := mustGetAliyunTransformer(t)
+	msg := sms.Aliyun().To("13800138000").Content("hi").SignName("sign").Build()
+	acc := &sms.Account{BaseAccount: core.BaseAccount{Credentials: core.Credentials{APIKey: "ak", APISecret: "sk"}}}
+	_, handler, err := tr.Transform(context.Background(), msg, acc)
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+
+	body := `{"Code":"isv.MOBILE_NUMBER_ILLEGAL","Message":"非法手机号"}`
+	err = handler(&core.SendResult{StatusCode: 200, Body: []byte(body), Headers: http.Header{"Content-Type": {"application/json"}}})
+	var validation core.ValidationError
+	if !errors.As(err, &validation) || core.GetSenderErrorCode(err) != core.ErrCodeValidationFailed {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	var vendorErr *core.VendorError
+	if !errors.As(err, &vendorErr) || vendorErr.Code != "isv.MOBILE_NUMBER_ILLEGAL" || vendorErr.Message != "非法手机号" {
+		t.Errorf("expected the vendor code and message, got %+v", vendorErr)
+	}
+	if core.NewDefaultErrorClassifier().IsRetryableError(err) {
+		t.Error("an invalid number should not be retried")
+	}
+}
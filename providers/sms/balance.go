@@ -0,0 +1,48 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// balanceQuery builds the request querying the balance of account, and the
+// function reading it from the response body. The latter fails when the
+// account cannot send anymore.
+type balanceQuery func(account *Account) (*core.HTTPRequestSpec, func(body []byte) (map[string]interface{}, error))
+
+// balanceQueries holds the balance queries of the sub-providers offering one.
+//
+//nolint:gochecknoglobals // Registry filled by the init functions of the sub-providers.
+var balanceQueries = make(map[string]balanceQuery)
+
+// registerBalanceQuery registers the balance query of a sub-provider.
+func registerBalanceQuery(subProvider string, query balanceQuery) {
+	balanceQueries[subProvider] = query
+}
+
+var _ core.Prober = (*Provider)(nil)
+
+// Probe implements core.Prober by querying the balance of every enabled account.
+// It is supported by smsbao, yunpian and luosimao; the accounts of the other
+// sub-providers are reported with core.ErrProbeUnsupported. An account whose
+// balance is exhausted is unhealthy, and the balance is reported in the details.
+func (p *Provider) Probe(ctx context.Context, opts *core.ProviderSendOptions) []core.ProbeResult {
+	if opts == nil {
+		opts = &core.ProviderSendOptions{}
+	}
+	return p.ProbeItems(ctx, func(ctx context.Context, account *Account) (map[string]interface{}, error) {
+		query, ok := balanceQueries[account.GetType()]
+		if !ok {
+			return nil, core.ErrProbeUnsupported
+		}
+		reqSpec, parse := query(account)
+		var details map[string]interface{}
+		_, err := p.ExecuteHTTPRequest(ctx, reqSpec, func(result *core.SendResult) error {
+			var parseErr error
+			details, parseErr = parse(result.Body)
+			return parseErr
+		}, opts)
+		return details, err
+	})
+}
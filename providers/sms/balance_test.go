@@ -0,0 +1,79 @@
+package sms_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/providers/sms"
+)
+
+// redirectTransport sends every request to the test server, keeping the vendor's
+// host in the path so that the handler can tell vendors apart.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Path = "/" + req.URL.Host + req.URL.Path
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProvider_ProbeQueriesBalance(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api.smsbao.com/query":
+			if r.URL.Query().Get("u") == "wrong" {
+				_, _ = w.Write([]byte("30"))
+				return
+			}
+			_, _ = w.Write([]byte("0\n120,880"))
+		case "/sms.yunpian.com/v2/user/get.json":
+			_, _ = w.Write([]byte(`{"nick":"test","balance":0}`))
+		case "/sms-api.luosimao.com/v1/status.json":
+			_, _ = w.Write([]byte(`{"error":0,"deposit":"500"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	target, _ := url.Parse(ts.URL)
+
+	provider, err := sms.NewProvider([]*sms.Account{
+		sms.NewAccount(string(sms.SubProviderSmsbao), "user", "pass", sms.Name("smsbao")),
+		sms.NewAccount(string(sms.SubProviderSmsbao), "wrong", "pass", sms.Name("smsbao-wrong")),
+		sms.NewAccount(string(sms.SubProviderYunpian), "", "apikey", sms.Name("yunpian")),
+		sms.NewAccount(string(sms.SubProviderLuosimao), "", "apikey", sms.Name("luosimao")),
+		sms.NewAccount(string(sms.SubProviderAliyun), "key", "secret", sms.Name("aliyun")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &core.ProviderSendOptions{HTTPClient: &http.Client{Transport: redirectTransport{target: target}}}
+	results := make(map[string]core.ProbeResult)
+	for _, result := range provider.Probe(context.Background(), opts) {
+		results[result.Account] = result
+	}
+
+	if r := results["smsbao"]; r.Err != nil || r.Details["balance"] != 880 {
+		t.Errorf("smsbao: expected a balance of 880, got %+v", r)
+	}
+	if r := results["smsbao-wrong"]; r.Err == nil {
+		t.Error("smsbao: expected the wrong password to fail the probe")
+	}
+	if r := results["yunpian"]; r.Err == nil || r.Details["balance"] != 0.0 {
+		t.Errorf("yunpian: expected an exhausted balance to fail the probe, got %+v", r)
+	}
+	if r := results["luosimao"]; r.Err != nil || r.Details["balance"] != int64(500) {
+		t.Errorf("luosimao: expected a balance of 500, got %+v", r)
+	}
+	if r := results["aliyun"]; !r.Healthy() || r.Err == nil {
+		t.Errorf("aliyun: expected an unsupported probe, got %+v", r)
+	}
+}
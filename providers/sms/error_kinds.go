@@ -0,0 +1,77 @@
+package sms
+
+import "github.com/shellvon/go-sender/core"
+
+// Error code tables of the vendors, see core.VendorError.
+//
+//nolint:gochecknoglobals // Read-only lookup tables.
+var (
+	// https://help.aliyun.com/document_detail/101346.html
+	aliyunErrorKinds = core.ErrorKinds{
+		"isv.MOBILE_NUMBER_ILLEGAL":       core.ErrorKindValidation,
+		"isv.MOBILE_COUNT_OVER_LIMIT":     core.ErrorKindValidation,
+		"isv.INVALID_PARAMETERS":          core.ErrorKindValidation,
+		"isv.INVALID_JSON_PARAM":          core.ErrorKindValidation,
+		"isv.PARAM_LENGTH_LIMIT":          core.ErrorKindValidation,
+		"isv.TEMPLATE_MISSING_PARAMETERS": core.ErrorKindValidation,
+		"isv.SMS_TEMPLATE_ILLEGAL":        core.ErrorKindTemplateRejected,
+		"isv.SMS_SIGNATURE_ILLEGAL":       core.ErrorKindTemplateRejected,
+		"isv.BLACK_KEY_CONTROL_LIMIT":     core.ErrorKindTemplateRejected,
+		"isv.BUSINESS_LIMIT_CONTROL":      core.ErrorKindRateLimited,
+		"Throttling.User":                 core.ErrorKindRateLimited,
+		"isv.DAY_LIMIT_CONTROL":           core.ErrorKindQuotaExhausted,
+		"isv.AMOUNT_NOT_ENOUGH":           core.ErrorKindQuotaExhausted,
+		"isv.OUT_OF_SERVICE":              core.ErrorKindQuotaExhausted,
+		"isv.ACCOUNT_NOT_EXISTS":          core.ErrorKindAuthentication,
+		"isv.ACCOUNT_ABNORMAL":            core.ErrorKindAuthentication,
+		"isv.PRODUCT_UN_SUBSCRIPT":        core.ErrorKindAuthentication,
+		"isv.DENY_IP_RANGE":               core.ErrorKindAuthentication,
+		"isp.RAM_PERMISSION_DENY":         core.ErrorKindAuthentication,
+		"InvalidAccessKeyId.NotFound":     core.ErrorKindAuthentication,
+		"SignatureDoesNotMatch":           core.ErrorKindAuthentication,
+		"isp.SYSTEM_ERROR":                core.ErrorKindTransient,
+		"ServiceUnavailable":              core.ErrorKindTransient,
+	}
+
+	// https://cloud.tencent.com/document/product/382/52075
+	tencentErrorKinds = core.ErrorKinds{
+		"InvalidParameter":                                                core.ErrorKindValidation,
+		"InvalidParameterValue.IncorrectPhoneNumber":                      core.ErrorKindValidation,
+		"InvalidParameterValue.TemplateParameterFormatError":              core.ErrorKindValidation,
+		"UnsupportedOperation.ContainDomesticAndInternationalPhoneNumber": core.ErrorKindValidation,
+		"FailedOperation.TemplateIncorrectOrUnapproved":                   core.ErrorKindTemplateRejected,
+		"FailedOperation.SignatureIncorrectOrUnapproved":                  core.ErrorKindTemplateRejected,
+		"FailedOperation.ContainSensitiveWord":                            core.ErrorKindTemplateRejected,
+		"FailedOperation.PhoneNumberInBlacklist":                          core.ErrorKindRecipientBlocked,
+		"LimitExceeded.PhoneNumberThirtySecondLimit":                      core.ErrorKindRateLimited,
+		"LimitExceeded.PhoneNumberOneHourLimit":                           core.ErrorKindRateLimited,
+		"LimitExceeded.DeliveryFrequencyLimit":                            core.ErrorKindRateLimited,
+		"RequestLimitExceeded":                                            core.ErrorKindRateLimited,
+		"LimitExceeded.PhoneNumberDailyLimit":                             core.ErrorKindQuotaExhausted,
+		"LimitExceeded.DailyLimit":                                        core.ErrorKindQuotaExhausted,
+		"FailedOperation.InsufficientBalanceInSmsPackage":                 core.ErrorKindQuotaExhausted,
+		"AuthFailure.SecretIdNotFound":                                    core.ErrorKindAuthentication,
+		"AuthFailure.SignatureFailure":                                    core.ErrorKindAuthentication,
+		"UnauthorizedOperation.SmsSdkAppIdVerifyFail":                     core.ErrorKindAuthentication,
+		"InternalError":         core.ErrorKindTransient,
+		"InternalError.Timeout": core.ErrorKindTransient,
+	}
+
+	// https://www.yunpian.com/official/document/sms/zh_CN/returnvalue_common
+	yunpianErrorKinds = core.ErrorKinds{
+		"1":  core.ErrorKindValidation,       // 请求参数缺失
+		"2":  core.ErrorKindValidation,       // 请求参数格式错误
+		"3":  core.ErrorKindQuotaExhausted,   // 账户余额不足
+		"4":  core.ErrorKindTemplateRejected, // 关键词屏蔽
+		"5":  core.ErrorKindTemplateRejected, // 未找到对应id的模板
+		"8":  core.ErrorKindRateLimited,      // 同一手机号30秒内重复提交相同的内容
+		"9":  core.ErrorKindRateLimited,      // 同一手机号5分钟内重复提交相同的内容超过3次
+		"10": core.ErrorKindRecipientBlocked, // 手机号防骚扰名单过滤
+		"22": core.ErrorKindRateLimited,      // 验证码类短信1小时内同一手机号发送次数不能超过3次
+		"33": core.ErrorKindRateLimited,      // 同一个手机号发送频率太频繁
+		"43": core.ErrorKindQuotaExhausted,   // 一天内同一手机号发送次数超过限制
+		"-1": core.ErrorKindAuthentication,   // 非法的apikey
+		"-2": core.ErrorKindAuthentication,   // API没有权限
+		"-3": core.ErrorKindAuthentication,   // IP没有权限
+	}
+)
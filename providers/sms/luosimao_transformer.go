@@ -2,9 +2,11 @@ package sms
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,8 +49,11 @@ func newLuosimaoTransformer() *luosimaoTransformer {
 }
 
 func init() {
-	RegisterTransformer(string(SubProviderLuosimao), newLuosimaoTransformer())
+	transformer := newLuosimaoTransformer()
+	RegisterTransformer(string(SubProviderLuosimao), transformer)
+	registerBalanceQuery(string(SubProviderLuosimao), transformer.queryBalance)
 }
+
 func (t *luosimaoTransformer) buildLuosimaoRequestSpec(
 	params url.Values,
 	requestURL string,
@@ -125,3 +130,38 @@ func (t *luosimaoTransformer) transformVoice(
 		account,
 	)
 }
+
+// queryBalance queries the balance of account
+//   - 账户余额查询: GET /v1/status.json
+func (t *luosimaoTransformer) queryBalance(
+	account *Account,
+) (*core.HTTPRequestSpec, func(body []byte) (map[string]interface{}, error)) {
+	reqSpec := &core.HTTPRequestSpec{
+		Method:  http.MethodGet,
+		URL:     fmt.Sprintf("%s/v1/status.json", luosimaoSmsDefaultBaseURI),
+		Headers: map[string]string{"Authorization": "Basic " + utils.Base64EncodeBytes([]byte("api:key-"+account.APISecret))},
+	}
+	parse := func(body []byte) (map[string]interface{}, error) {
+		var resp struct {
+			Error   int         `json:"error"`
+			Msg     string      `json:"msg"`
+			Deposit json.Number `json:"deposit"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, NewProviderError(string(SubProviderLuosimao), "INVALID_RESPONSE", err.Error())
+		}
+		if resp.Error != 0 {
+			return nil, NewProviderError(string(SubProviderLuosimao), strconv.Itoa(resp.Error), resp.Msg)
+		}
+		deposit, err := resp.Deposit.Int64()
+		if err != nil {
+			return nil, NewProviderError(string(SubProviderLuosimao), "INVALID_RESPONSE", "invalid deposit in response")
+		}
+		details := map[string]interface{}{"balance": deposit}
+		if deposit <= 0 {
+			return details, NewProviderError(string(SubProviderLuosimao), "-20", "insufficient balance")
+		}
+		return details, nil
+	}
+	return reqSpec, parse
+}
@@ -78,9 +78,14 @@ func NewSMSMessage(subProvider string) *Message {
 
 // Compile-time assertion: Message implements Message interface.
 var (
-	_ core.Message          = (*Message)(nil)
-	_ core.Validatable      = (*Message)(nil)
-	_ core.SubProviderAware = (*Message)(nil)
+	_ core.Message           = (*Message)(nil)
+	_ core.Validatable       = (*Message)(nil)
+	_ core.SubProviderAware  = (*Message)(nil)
+	_ core.CategoryAware     = (*Message)(nil)
+	_ core.RoutingKeyer      = (*Message)(nil)
+	_ core.CostEstimator     = (*Message)(nil)
+	_ core.RateLimitWeighted = (*Message)(nil)
+	_ core.RecipientLister   = (*Message)(nil)
 )
 
 // Validate validates the SMS message.
@@ -156,6 +161,12 @@ func (mc MessageCategory) String() string {
 	}
 }
 
+// GetCategory implements core.CategoryAware.
+// Returns the lower-cased category name, e.g. "verification" or "promotion".
+func (m *Message) GetCategory() string {
+	return strings.ToLower(m.Category.String())
+}
+
 // IsIntl 判断是否为国际/港澳台短信（regionCode != 0 且 != 86）.
 func (m *Message) IsIntl() bool {
 	return m.RegionCode != 0 && m.RegionCode != 86
@@ -171,6 +182,27 @@ func (m *Message) HasMultipleRecipients() bool {
 	return len(m.Mobiles) > 1
 }
 
+// RateLimitTokens implements core.RateLimitWeighted: a message takes one token
+// per recipient, as vendors count their quotas per number.
+func (m *Message) RateLimitTokens() int {
+	return max(len(m.Mobiles), 1)
+}
+
+// RoutingKey implements core.RoutingKeyer: a message to a single mobile is
+// routed by that number, so keyed strategies keep it on the same account.
+func (m *Message) RoutingKey() string {
+	if len(m.Mobiles) != 1 {
+		return ""
+	}
+	return m.Mobiles[0]
+}
+
+// Recipients implements core.RecipientLister, so that the per-number quotas of
+// the vendor apply to every mobile of a multi-recipient message.
+func (m *Message) Recipients() []string {
+	return m.Mobiles
+}
+
 // SubProviderType returns the sub-provider type for this message.
 func (m *Message) SubProviderType() SubProviderType {
 	return SubProviderType(m.SubProvider)
@@ -0,0 +1,126 @@
+package sms
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// Segment sizes of concatenated SMS: a single message carries 160 GSM-7 or 70 UCS-2
+// characters; each part of a longer message loses a few to the concatenation header.
+const (
+	gsmSingleSegment  = 160
+	gsmMultiSegment   = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+// PriceRule is the price of one segment sent to one recipient. Zero-valued
+// fields act as wildcards.
+type PriceRule struct {
+	// RegionCode is the E.164 country code the rule applies to, e.g. 86 or 1.
+	RegionCode int `json:"region_code,omitempty" yaml:"region_code,omitempty"`
+	// Category is the message category the rule applies to: "verification",
+	// "notification" or "promotion".
+	Category string `json:"category,omitempty"    yaml:"category,omitempty"`
+	// PerSegment is the price of one segment to one recipient.
+	PerSegment float64 `json:"per_segment"          yaml:"per_segment"`
+}
+
+// PriceTable holds the prices of an account. The most specific matching rule
+// wins: a rule matching region and category beats one matching the region only,
+// which beats one matching the category only, which beats a wildcard rule.
+type PriceTable struct {
+	Currency string      `json:"currency" yaml:"currency"`
+	Rules    []PriceRule `json:"rules"    yaml:"rules"`
+}
+
+// Price returns the per-segment price for regionCode and category.
+func (t *PriceTable) Price(regionCode int, category string) (float64, bool) {
+	if t == nil {
+		return 0, false
+	}
+	best, bestScore := 0.0, -1
+	for _, rule := range t.Rules {
+		score := 0
+		switch rule.RegionCode {
+		case 0:
+		case regionCode:
+			score += 2
+		default:
+			continue
+		}
+		switch {
+		case rule.Category == "":
+		case strings.EqualFold(rule.Category, category):
+			score++
+		default:
+			continue
+		}
+		if score > bestScore {
+			best, bestScore = rule.PerSegment, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// Segments returns the number of segments each recipient of m is billed for.
+// The sign name, which Chinese carriers prepend as 【sign】, is counted. Template
+// messages without content count as one segment, since the rendered length is
+// only known to the vendor.
+func (m *Message) Segments() int {
+	if m.Type == Voice || m.Content == "" {
+		return 1
+	}
+	text := m.Content
+	if m.SignName != "" {
+		text = "【" + m.SignName + "】" + text
+	}
+
+	single, multi := gsmSingleSegment, gsmMultiSegment
+	if !isGSM7(text) {
+		single, multi = ucs2SingleSegment, ucs2MultiSegment
+	}
+	n := utf8.RuneCountInString(text)
+	if n <= single {
+		return 1
+	}
+	return (n + multi - 1) / multi
+}
+
+// EstimateCost implements core.CostEstimator using the PriceTable of an *Account.
+func (m *Message) EstimateCost(item core.Selectable) (*core.CostEstimate, bool) {
+	account, ok := item.(*Account)
+	if !ok || account.Pricing == nil {
+		return nil, false
+	}
+	regionCode := m.RegionCode
+	if regionCode == 0 {
+		regionCode = ChinaMainlandRegionCode
+	}
+	price, ok := account.Pricing.Price(regionCode, m.GetCategory())
+	if !ok {
+		return nil, false
+	}
+	units := m.Segments() * max(len(m.Mobiles), 1)
+	return &core.CostEstimate{
+		Account:  account.GetName(),
+		Currency: account.Pricing.Currency,
+		Amount:   price * float64(units),
+		Units:    units,
+		Unit:     "segment",
+	}, true
+}
+
+// isGSM7 reports whether s can be encoded in the GSM 7-bit alphabet. Only the
+// printable ASCII subset is accepted, which is enough to tell Latin text from
+// Chinese.
+func isGSM7(s string) bool {
+	for _, r := range s {
+		if r > '~' || (r < ' ' && r != '\n' && r != '\r') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package sms_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/providers/sms"
+)
+
+func TestMessage_Segments(t *testing.T) {
+	cases := []struct {
+		content string
+		sign    string
+		want    int
+	}{
+		{strings.Repeat("a", 160), "", 1},
+		{strings.Repeat("a", 161), "", 2},
+		{strings.Repeat("验", 70), "", 1},
+		{strings.Repeat("验", 67), "签名", 2}, // 【签名】 adds 4 characters
+		{strings.Repeat("验", 134), "", 2},
+		{strings.Repeat("验", 135), "", 3},
+	}
+	for _, tc := range cases {
+		msg := sms.Aliyun().To("13800138000").Content(tc.content).SignName(tc.sign).Build()
+		if got := msg.Segments(); got != tc.want {
+			t.Errorf("Segments(%d runes, sign %q) = %d, want %d", len([]rune(tc.content)), tc.sign, got, tc.want)
+		}
+	}
+}
+
+func TestPriceTable_MostSpecificRuleWins(t *testing.T) {
+	table := &sms.PriceTable{Currency: "CNY", Rules: []sms.PriceRule{
+		{PerSegment: 0.5},
+		{Category: "promotion", PerSegment: 0.06},
+		{RegionCode: 86, PerSegment: 0.045},
+		{RegionCode: 86, Category: "promotion", PerSegment: 0.055},
+	}}
+	cases := []struct {
+		region   int
+		category string
+		want     float64
+	}{
+		{86, "verification", 0.045},
+		{86, "promotion", 0.055},
+		{1, "promotion", 0.06},
+		{1, "verification", 0.5},
+	}
+	for _, tc := range cases {
+		if got, ok := table.Price(tc.region, tc.category); !ok || got != tc.want {
+			t.Errorf("Price(%d, %s) = %v, %v; want %v", tc.region, tc.category, got, ok, tc.want)
+		}
+	}
+}
+
+func TestMessage_EstimateCost(t *testing.T) {
+	account := sms.NewAccount("aliyun", "key", "secret", sms.Name("aliyun-main"),
+		sms.WithPricing(&sms.PriceTable{Currency: "CNY", Rules: []sms.PriceRule{{RegionCode: 86, PerSegment: 0.045}}}))
+	msg := sms.Aliyun().To("13800138000", "13800138001").Content(strings.Repeat("验", 100)).Build()
+
+	est, ok := msg.EstimateCost(account)
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if est.Units != 4 || math.Abs(est.Amount-0.18) > 1e-9 || est.Currency != "CNY" || est.Account != "aliyun-main" {
+		t.Errorf("unexpected estimate: %+v", est)
+	}
+
+	msg.RegionCode = 1
+	if _, ok := msg.EstimateCost(account); ok {
+		t.Error("expected no estimate for an unpriced region")
+	}
+}
+
+func TestCheapestStrategy_PicksCheapestAccount(t *testing.T) {
+	price := func(p float64) *sms.PriceTable {
+		return &sms.PriceTable{Currency: "CNY", Rules: []sms.PriceRule{{PerSegment: p}}}
+	}
+	config := &sms.Config{
+		ProviderMeta: core.ProviderMeta{Strategy: core.StrategyCheapest},
+		Items: []*sms.Account{
+			sms.NewAccount("aliyun", "k", "s", sms.Name("aliyun"), sms.WithPricing(price(0.045))),
+			sms.NewAccount("tencent", "k", "s", sms.Name("tencent"), sms.WithPricing(price(0.04))),
+			sms.NewAccount("huawei", "k", "s", sms.Name("huawei")),
+		},
+	}
+	msg := sms.Aliyun().To("13800138000").Content("hi").Build()
+	ctx := core.WithCostEstimator(context.Background(), msg)
+
+	for range 3 {
+		account, err := config.Select(ctx, nil)
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		if account.GetName() != "tencent" {
+			t.Errorf("expected tencent, got %s", account.GetName())
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/shellvon/go-sender/core"
 	"github.com/shellvon/go-sender/providers"
@@ -128,6 +129,83 @@ func (p *Provider) Name() string {
 	return string(core.ProviderTypeSMS)
 }
 
+// Send implements core.Provider. Only accounts serving the recipients' region
+// (see Account.ServesRegion) are selected. Recipients given in E.164 format
+// ("+44...", "0044...") are sent with their own country code; the others use
+// RegionCode. When recipients span several regions, the message is split into
+// one message per region, each sent with an account serving it, and the results
+// are returned in SendResult.Parts.
+func (p *Provider) Send(
+	ctx context.Context,
+	msg core.Message,
+	opts *core.ProviderSendOptions,
+) (*core.SendResult, error) {
+	smsMsg, ok := msg.(*Message)
+	if !ok {
+		return p.HTTPProvider.Send(ctx, msg, opts)
+	}
+
+	groups := smsMsg.regionGroups()
+	switch {
+	case len(groups) == 0:
+		return p.HTTPProvider.Send(ctx, msg, opts)
+	case len(groups) == 1 && slices.Equal(groups[0].mobiles, groups[0].original):
+		// No E.164 numbers: send the message as given.
+		return p.sendToRegion(ctx, smsMsg, groups[0].code, opts)
+	case len(groups) == 1:
+		return p.sendToRegion(ctx, smsMsg.forRegion(groups[0]), groups[0].code, opts)
+	}
+
+	combined := &core.SendResult{}
+	errs := make([]error, len(groups))
+	for i, g := range groups {
+		result, err := p.sendToRegion(ctx, smsMsg.forRegion(g), g.code, opts)
+		errs[i] = err
+		if result != nil {
+			combined.Parts = append(combined.Parts, result)
+		}
+	}
+	if len(combined.Parts) == len(groups) {
+		combined.Cost = sumCosts(combined.Parts)
+	}
+	return combined, joinPartErrors(groups, errs)
+}
+
+// sendToRegion sends msg with an account serving regionCode.
+func (p *Provider) sendToRegion(
+	ctx context.Context,
+	msg *Message,
+	regionCode int,
+	opts *core.ProviderSendOptions,
+) (*core.SendResult, error) {
+	serves := func(a *Account) bool { return a.ServesRegion(regionCode) }
+	if !slices.ContainsFunc(p.GetConfigs(), func(a *Account) bool {
+		return a.IsEnabled() && (msg.SubProvider == "" || a.GetType() == msg.SubProvider) && serves(a)
+	}) {
+		return nil, NewUnsupportedInternationalError(msg.SubProvider, fmt.Sprintf("region code %d", regionCode))
+	}
+	return p.SendFiltered(ctx, msg, opts, serves)
+}
+
+// sumCosts adds up the cost estimates of the parts of a split message. It
+// returns nil unless every part has an estimate in the same currency.
+func sumCosts(parts []*core.SendResult) *core.CostEstimate {
+	var sum *core.CostEstimate
+	for _, part := range parts {
+		switch {
+		case part.Cost == nil:
+			return nil
+		case sum == nil:
+			sum = &core.CostEstimate{Currency: part.Cost.Currency, Unit: part.Cost.Unit}
+		case sum.Currency != part.Cost.Currency:
+			return nil
+		}
+		sum.Amount += part.Cost.Amount
+		sum.Units += part.Cost.Units
+	}
+	return sum
+}
+
 // Re-exported core provider options for cleaner API
 // These provide convenient aliases: sms.Strategy(core.StrategyWeighted) instead of core.WithStrategy[*sms.Config](core.StrategyWeighted).
 var (
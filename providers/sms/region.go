@@ -0,0 +1,147 @@
+package sms
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// twoDigitCountryCodes lists the E.164 country calling codes of two digits. Codes
+// starting with 1 or 7 have one digit, and every other code has three.
+//
+//nolint:gochecknoglobals // constant lookup table
+var twoDigitCountryCodes = map[int]bool{
+	20: true, 27: true, 30: true, 31: true, 32: true, 33: true, 34: true, 36: true, 39: true,
+	40: true, 41: true, 43: true, 44: true, 45: true, 46: true, 47: true, 48: true, 49: true,
+	51: true, 52: true, 53: true, 54: true, 55: true, 56: true, 57: true, 58: true,
+	60: true, 61: true, 62: true, 63: true, 64: true, 65: true, 66: true,
+	81: true, 82: true, 84: true, 86: true, 90: true, 91: true, 92: true, 93: true, 94: true, 95: true, 98: true,
+}
+
+// ParseE164 splits an international number such as "+44 7700 900123" or
+// "0044 7700900123" into its country code and national number. It reports false
+// for numbers without a "+" or "00" prefix.
+func ParseE164(mobile string) (int, string, bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, mobile)
+	mobile = strings.TrimSpace(mobile)
+	switch {
+	case strings.HasPrefix(mobile, "+"):
+	case strings.HasPrefix(mobile, "00"):
+		digits = digits[2:]
+	default:
+		return 0, "", false
+	}
+	if len(digits) < 2 { //nolint:mnd // country code plus at least one digit
+		return 0, "", false
+	}
+
+	n := 3
+	switch {
+	case digits[0] == '1' || digits[0] == '7':
+		n = 1
+	default:
+		if code, _ := strconv.Atoi(digits[:2]); twoDigitCountryCodes[code] {
+			n = 2
+		}
+	}
+	if len(digits) <= n {
+		return 0, "", false
+	}
+	code, _ := strconv.Atoi(digits[:n])
+	return code, digits[n:], true
+}
+
+// regionGroup is the part of a message going to one region.
+type regionGroup struct {
+	code int
+	// mobiles are the national numbers of the recipients.
+	mobiles []string
+	// original are the recipients as given in the message.
+	original []string
+}
+
+// regionGroups groups the recipients of m by region. E.164 numbers are reduced
+// to their national number; other numbers belong to m.RegionCode. Groups are in
+// order of first appearance.
+func (m *Message) regionGroups() []regionGroup {
+	defaultCode := m.RegionCode
+	if defaultCode == 0 {
+		defaultCode = ChinaMainlandRegionCode
+	}
+
+	var groups []regionGroup
+	for _, mobile := range m.Mobiles {
+		code, national, ok := ParseE164(mobile)
+		if !ok {
+			code, national = defaultCode, mobile
+		}
+		i := slices.IndexFunc(groups, func(g regionGroup) bool { return g.code == code })
+		if i < 0 {
+			groups = append(groups, regionGroup{code: code})
+			i = len(groups) - 1
+		}
+		groups[i].mobiles = append(groups[i].mobiles, national)
+		groups[i].original = append(groups[i].original, mobile)
+	}
+	return groups
+}
+
+// forRegion returns a copy of m addressed to the recipients of g.
+func (m *Message) forRegion(g regionGroup) *Message {
+	part := *m
+	part.Mobiles = g.mobiles
+	part.RegionCode = g.code
+	return &part
+}
+
+// PartialSendError is returned when a message whose recipients span several
+// regions was only delivered to some of them. It is not retryable, since a retry
+// would resend the delivered parts; resend to FailedMobiles instead.
+type PartialSendError struct {
+	// FailedMobiles are the recipients of the parts that failed, as given in the message.
+	FailedMobiles []string
+	// Err joins the errors of the failed parts.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *PartialSendError) Error() string {
+	return "sms partially sent, failed for " + strings.Join(e.FailedMobiles, ",") + ": " + e.Err.Error()
+}
+
+// Unwrap returns the errors of the failed parts.
+func (e *PartialSendError) Unwrap() error { return e.Err }
+
+// IsRetryable implements core.RetryableError.
+func (e *PartialSendError) IsRetryable() bool { return false }
+
+var _ core.RetryableError = (*PartialSendError)(nil)
+
+// joinPartErrors returns the error of a split send: nil when every part succeeded,
+// the joined errors when all failed, and a PartialSendError otherwise.
+func joinPartErrors(groups []regionGroup, errs []error) error {
+	var failed []string
+	var failedErrs []error
+	for i, g := range groups {
+		if errs[i] != nil {
+			failed = append(failed, g.original...)
+			failedErrs = append(failedErrs, errs[i])
+		}
+	}
+	switch len(failedErrs) {
+	case 0:
+		return nil
+	case len(groups):
+		return errors.Join(failedErrs...)
+	default:
+		return &PartialSendError{FailedMobiles: failed, Err: errors.Join(failedErrs...)}
+	}
+}
@@ -0,0 +1,165 @@
+package sms_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/providers"
+	"github.com/shellvon/go-sender/providers/sms"
+)
+
+func TestParseE164(t *testing.T) {
+	cases := []struct {
+		mobile   string
+		code     int
+		national string
+		ok       bool
+	}{
+		{"+44 7700 900123", 44, "7700900123", true},
+		{"0086 13800138000", 86, "13800138000", true},
+		{"+1-202-555-0143", 1, "2025550143", true},
+		{"+85291234567", 852, "91234567", true},
+		{"13800138000", 0, "", false},
+		{"+4", 0, "", false},
+	}
+	for _, tc := range cases {
+		code, national, ok := sms.ParseE164(tc.mobile)
+		if code != tc.code || national != tc.national || ok != tc.ok {
+			t.Errorf("ParseE164(%q) = %d, %q, %v; want %d, %q, %v",
+				tc.mobile, code, national, ok, tc.code, tc.national, tc.ok)
+		}
+	}
+}
+
+func TestAccount_ServesRegion(t *testing.T) {
+	all := sms.NewAccount("aliyun", "k", "s")
+	intl := sms.NewAccount("aliyun", "k", "s", sms.WithRegionCodes(1, 44))
+	noUS := sms.NewAccount("aliyun", "k", "s", sms.WithDeniedRegionCodes(1))
+
+	if !all.ServesRegion(86) || !all.ServesRegion(44) {
+		t.Error("account without lists should serve every region")
+	}
+	if !intl.ServesRegion(44) || intl.ServesRegion(86) {
+		t.Error("allow list not honoured")
+	}
+	if noUS.ServesRegion(1) || !noUS.ServesRegion(86) {
+		t.Error("deny list not honoured")
+	}
+}
+
+// regionTransformer records the account and recipients of each request and
+// fails the regions listed in fail.
+type regionTransformer struct {
+	url  string
+	fail []int
+
+	mu   sync.Mutex
+	sent []string
+}
+
+func (f *regionTransformer) CanTransform(_ core.Message) bool { return true }
+
+func (f *regionTransformer) Transform(
+	_ context.Context,
+	msg core.Message,
+	account *sms.Account,
+) (*core.HTTPRequestSpec, core.SendResultHandler, error) {
+	m, _ := msg.(*sms.Message)
+	if slices.Contains(f.fail, m.RegionCode) {
+		return nil, nil, fmt.Errorf("region %d unavailable", m.RegionCode)
+	}
+	f.mu.Lock()
+	f.sent = append(f.sent, fmt.Sprintf("%s:%d:%s", account.GetName(), m.RegionCode, strings.Join(m.Mobiles, ",")))
+	f.mu.Unlock()
+	return &core.HTTPRequestSpec{Method: http.MethodPost, URL: f.url, BodyType: core.BodyTypeJSON},
+		func(*core.SendResult) error { return nil }, nil
+}
+
+func newRegionProvider(t *testing.T, tr *regionTransformer) *sms.Provider {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	tr.url = ts.URL
+
+	price := &sms.PriceTable{Currency: "CNY", Rules: []sms.PriceRule{{PerSegment: 0.05}}}
+	config := &sms.Config{Items: []*sms.Account{
+		sms.NewAccount("aliyun", "k", "s", sms.Name("domestic"), sms.WithRegionCodes(86), sms.WithPricing(price)),
+		sms.NewAccount("aliyun", "k", "s", sms.Name("intl"), sms.WithDeniedRegionCodes(86), sms.WithPricing(price)),
+	}}
+	httpProvider, err := providers.NewHTTPProvider("sms", tr, config)
+	if err != nil {
+		t.Fatalf("failed to create HTTP provider: %v", err)
+	}
+	return &sms.Provider{HTTPProvider: httpProvider}
+}
+
+func TestProvider_Send_SplitsByRegion(t *testing.T) {
+	tr := &regionTransformer{}
+	p := newRegionProvider(t, tr)
+
+	msg := sms.Aliyun().To("13800138000", "+44 7700900123", "0086 13800138001").Content("hi").Build()
+	result, err := p.Send(context.Background(), msg, nil)
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	want := []string{"domestic:86:13800138000,13800138001", "intl:44:7700900123"}
+	if !slices.Equal(tr.sent, want) {
+		t.Errorf("sent %v, want %v", tr.sent, want)
+	}
+	if len(result.Parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(result.Parts))
+	}
+	if result.Cost == nil || result.Cost.Units != 3 {
+		t.Errorf("expected a combined estimate of 3 segments, got %+v", result.Cost)
+	}
+	if len(msg.Mobiles) != 3 {
+		t.Error("the original message must not be modified")
+	}
+}
+
+func TestProvider_Send_PartialFailure(t *testing.T) {
+	tr := &regionTransformer{fail: []int{44}}
+	p := newRegionProvider(t, tr)
+
+	msg := sms.Aliyun().To("13800138000", "+44 7700900123").Content("hi").Build()
+	result, err := p.Send(context.Background(), msg, nil)
+
+	var partial *sms.PartialSendError
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected PartialSendError, got %v", err)
+	}
+	if !slices.Equal(partial.FailedMobiles, []string{"+44 7700900123"}) {
+		t.Errorf("unexpected failed mobiles: %v", partial.FailedMobiles)
+	}
+	if partial.IsRetryable() {
+		t.Error("a partial send must not be retried")
+	}
+	if result == nil || len(result.Parts) != 1 {
+		t.Errorf("expected the delivered part in the result, got %+v", result)
+	}
+}
+
+func TestProvider_Send_UnservedRegion(t *testing.T) {
+	tr := &regionTransformer{}
+	p := newRegionProvider(t, tr)
+	p.GetConfigs()[1].Disabled = true
+
+	msg := sms.Aliyun().To("+1 2025550143").Content("hi").Build()
+	if _, err := p.Send(context.Background(), msg, nil); err == nil {
+		t.Fatal("expected an error when no account serves the region")
+	}
+	if len(tr.sent) != 0 {
+		t.Errorf("nothing should be sent, got %v", tr.sent)
+	}
+}
@@ -49,7 +49,9 @@ func newSmsbaoTransformer() *smsbaoTransformer {
 
 // init 自动注册 Smsbao transformer.
 func init() {
-	RegisterTransformer(string(SubProviderSmsbao), newSmsbaoTransformer())
+	transformer := newSmsbaoTransformer()
+	RegisterTransformer(string(SubProviderSmsbao), transformer)
+	registerBalanceQuery(string(SubProviderSmsbao), transformer.queryBalance)
 }
 
 // transformSMS transforms SMS message to HTTP request
@@ -152,6 +154,42 @@ func (t *smsbaoTransformer) transformVoice(
 	}, t.handleSMSBaoResponse, nil
 }
 
+// queryBalance queries the remaining messages of account
+//   - 余额查询: GET /query
+//
+// 成功时返回两行: 状态码 0, 以及 "已发送条数,剩余条数".
+func (t *smsbaoTransformer) queryBalance(
+	account *Account,
+) (*core.HTTPRequestSpec, func(body []byte) (map[string]interface{}, error)) {
+	reqSpec := &core.HTTPRequestSpec{
+		Method: http.MethodGet,
+		URL:    fmt.Sprintf("%s/query", smsbaoDefaultBaseURI),
+		QueryParams: url.Values{
+			"u": {account.APIKey},
+			"p": {utils.HashHex(md5.New, []byte(account.APISecret))},
+		},
+	}
+	parse := func(body []byte) (map[string]interface{}, error) {
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		if err := t.handleSMSBaoResponse(&core.SendResult{Body: []byte(strings.TrimSpace(lines[0]))}); err != nil {
+			return nil, err
+		}
+		if len(lines) < 2 {
+			return nil, NewProviderError(string(SubProviderSmsbao), "INVALID_RESPONSE", "missing balance in response")
+		}
+		var sent, remaining int
+		if _, err := fmt.Sscanf(strings.TrimSpace(lines[1]), "%d,%d", &sent, &remaining); err != nil {
+			return nil, NewProviderError(string(SubProviderSmsbao), "INVALID_RESPONSE", err.Error())
+		}
+		details := map[string]interface{}{"sent": sent, "balance": remaining}
+		if remaining <= 0 {
+			return details, NewProviderError(string(SubProviderSmsbao), "41", "insufficient balance")
+		}
+		return details, nil
+	}
+	return reqSpec, parse
+}
+
 // handleSMSBaoResponse 处理短信宝 API 响应.
 func (t *smsbaoTransformer) handleSMSBaoResponse(result *core.SendResult) error {
 	subProvider := string(SubProviderSmsbao)
@@ -361,11 +361,7 @@ func (t *tencentTransformer) handleTencentResponse(result *core.SendResult) erro
 	}
 
 	if response.Response.Error != nil {
-		return &Error{
-			Code:     response.Response.Error.Code,
-			Message:  response.Response.Error.Message,
-			Provider: subProvider,
-		}
+		return tencentError(response.Response.Error.Code, response.Response.Error.Message)
 	}
 
 	if len(response.Response.SendStatusSet) == 0 {
@@ -378,12 +374,15 @@ func (t *tencentTransformer) handleTencentResponse(result *core.SendResult) erro
 
 	for _, status := range response.Response.SendStatusSet {
 		if status.Code != "OK" {
-			return &Error{
-				Code:     status.Code,
-				Message:  status.Message,
-				Provider: subProvider,
-			}
+			return tencentError(status.Code, status.Message)
 		}
 	}
 	return nil
 }
+
+// tencentError classifies a Tencent failure, keeping the *Error as its cause.
+func tencentError(code, message string) *core.VendorError {
+	vendorErr := core.NewVendorError(tencentErrorKinds, code, message)
+	vendorErr.Err = &Error{Code: code, Message: message, Provider: string(SubProviderTencent)}
+	return vendorErr
+}
@@ -2,6 +2,7 @@ package sms
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -33,12 +34,13 @@ func newYunpianTransformer() *yunpianTransformer {
 	transformer.BaseTransformer = NewBaseTransformer(
 		string(SubProviderYunpian),
 		&core.ResponseHandlerConfig{
-			BodyType:  core.BodyTypeJSON,
-			CheckBody: true,
-			Path:      "code",
-			Expect:    "0",
-			MsgPath:   "msg",
-			Mode:      core.MatchEq,
+			BodyType:   core.BodyTypeJSON,
+			CheckBody:  true,
+			Path:       "code",
+			Expect:     "0",
+			MsgPath:    "msg",
+			Mode:       core.MatchEq,
+			ErrorKinds: yunpianErrorKinds,
 		},
 		nil,
 		WithSMSHandler(transformer.transformSMS),
@@ -49,7 +51,9 @@ func newYunpianTransformer() *yunpianTransformer {
 }
 
 func init() {
-	RegisterTransformer(string(SubProviderYunpian), newYunpianTransformer())
+	transformer := newYunpianTransformer()
+	RegisterTransformer(string(SubProviderYunpian), transformer)
+	registerBalanceQuery(string(SubProviderYunpian), transformer.queryBalance)
 }
 
 // transformSMS transforms SMS message to HTTP request.
@@ -239,6 +243,39 @@ func (t *yunpianTransformer) yunpianEndpoint(service, path string) string {
 	return fmt.Sprintf("https://%s%s", domain, path)
 }
 
+// queryBalance queries the balance of account
+//   - 查账户信息: POST /v2/user/get.json
+func (t *yunpianTransformer) queryBalance(
+	account *Account,
+) (*core.HTTPRequestSpec, func(body []byte) (map[string]interface{}, error)) {
+	reqSpec, _, _ := t.buildRequest(
+		t.yunpianEndpoint("sms", "/v2/user/get.json"),
+		map[string]string{"apikey": account.APISecret},
+	)
+	parse := func(body []byte) (map[string]interface{}, error) {
+		var resp struct {
+			Code    *int     `json:"code"`
+			Msg     string   `json:"msg"`
+			Balance *float64 `json:"balance"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, NewProviderError(string(SubProviderYunpian), "INVALID_RESPONSE", err.Error())
+		}
+		if resp.Code != nil && *resp.Code != 0 {
+			return nil, NewProviderError(string(SubProviderYunpian), strconv.Itoa(*resp.Code), resp.Msg)
+		}
+		if resp.Balance == nil {
+			return nil, NewProviderError(string(SubProviderYunpian), "INVALID_RESPONSE", "missing balance in response")
+		}
+		details := map[string]interface{}{"balance": *resp.Balance}
+		if *resp.Balance <= 0 {
+			return details, NewProviderError(string(SubProviderYunpian), "3", "insufficient balance")
+		}
+		return details, nil
+	}
+	return reqSpec, parse
+}
+
 // buildRequest 构建HTTP请求.
 func (t *yunpianTransformer) buildRequest(
 	endpoint string,
@@ -41,9 +41,16 @@ type BaseMessage struct {
 
 // Compile-time assertion: BaseMessage implements Message interface.
 var (
-	_ core.Message = (*BaseMessage)(nil)
+	_ core.Message      = (*BaseMessage)(nil)
+	_ core.RoutingKeyer = (*BaseMessage)(nil)
 )
 
+// RoutingKey implements core.RoutingKeyer: messages are routed, and rate
+// limited, by their chat.
+func (m *BaseMessage) RoutingKey() string {
+	return m.ChatID
+}
+
 // GetMsgType Implements the Message interface.
 // Returns the message type.
 func (m *BaseMessage) GetMsgType() MessageType {
@@ -12,6 +12,11 @@
 package telegram
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
 	"github.com/shellvon/go-sender/core"
 	"github.com/shellvon/go-sender/providers"
 )
@@ -25,6 +30,7 @@ type Provider struct {
 
 var (
 	_ core.Provider = (*Provider)(nil)
+	_ core.Prober   = (*Provider)(nil)
 )
 
 // New creates a new Telegram provider instance.
@@ -71,6 +77,41 @@ func (p *Provider) Name() string {
 	return string(core.ProviderTypeTelegram)
 }
 
+// Probe implements core.Prober: it calls getMe with the token of every enabled
+// bot, and reports the bot's username in the details.
+func (p *Provider) Probe(ctx context.Context, opts *core.ProviderSendOptions) []core.ProbeResult {
+	if opts == nil {
+		opts = &core.ProviderSendOptions{}
+	}
+	handler := core.NewSendResultHandler(&core.ResponseHandlerConfig{
+		BodyType:   core.BodyTypeJSON,
+		CheckBody:  true,
+		Path:       "ok",
+		Expect:     "true",
+		CodePath:   "error_code",
+		MsgPath:    "description",
+		Mode:       core.MatchEq,
+		ErrorKinds: errorKinds,
+	})
+	return p.ProbeItems(ctx, func(ctx context.Context, account *Account) (map[string]interface{}, error) {
+		reqSpec := &core.HTTPRequestSpec{
+			Method: http.MethodGet,
+			URL:    fmt.Sprintf(telegramAPIURLTemplate, account.APIKey, endpointGetMe),
+		}
+		result, err := p.ExecuteHTTPRequest(ctx, reqSpec, handler, opts)
+		if err != nil {
+			return nil, err
+		}
+		var me struct {
+			Result User `json:"result"`
+		}
+		if err = json.Unmarshal(result.Body, &me); err != nil {
+			return nil, fmt.Errorf("failed to decode getMe response: %w", err)
+		}
+		return map[string]interface{}{"username": me.Result.Username}, nil
+	})
+}
+
 // Re-exported core provider options for cleaner API
 // These provide convenient aliases: telegram.Strategy(core.StrategyWeighted) instead of core.WithStrategy[*telegram.Config](core.StrategyWeighted).
 var (
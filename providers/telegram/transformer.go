@@ -32,6 +32,7 @@ const (
 	endpointSendPoll      = "sendPoll"
 	endpointSendDice      = "sendDice"
 	endpointSendVenue     = "sendVenue"
+	endpointGetMe         = "getMe" // returns the bot itself; used to check the token
 )
 
 const telegramAPIURLTemplate = "https://api.telegram.org/bot%s/%s"
@@ -96,12 +97,15 @@ func (tt *telegramTransformer) transform(
 // newTelegramTransformer creates a new Telegram transformer instance.
 func newTelegramTransformer() core.HTTPTransformer[*Account] {
 	respCfg := &core.ResponseHandlerConfig{
-		BodyType:  core.BodyTypeJSON,
-		CheckBody: true,
-		Path:      "ok",
-		Expect:    "true",
-		MsgPath:   "description",
-		Mode:      core.MatchEq,
+		BodyType:       core.BodyTypeJSON,
+		CheckBody:      true,
+		Path:           "ok",
+		Expect:         "true",
+		CodePath:       "error_code",
+		MsgPath:        "description",
+		Mode:           core.MatchEq,
+		ErrorKinds:     errorKinds,
+		RetryAfterPath: "parameters.retry_after",
 	}
 
 	tt := &telegramTransformer{}
@@ -114,3 +118,13 @@ func newTelegramTransformer() core.HTTPTransformer[*Account] {
 
 	return tt
 }
+
+// errorKinds classifies the error_code of failed Bot API calls, see core.VendorError.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var errorKinds = core.ErrorKinds{
+	"400": core.ErrorKindValidation,       // Bad Request: chat not found, message is too long…
+	"401": core.ErrorKindAuthentication,   // Unauthorized: invalid bot token
+	"403": core.ErrorKindRecipientBlocked, // Forbidden: bot was blocked by the user
+	"429": core.ErrorKindRateLimited,      // Too Many Requests: retry after N
+}
@@ -26,6 +26,23 @@ type Endpoint struct {
 
 	// Response handling configuration
 	ResponseConfig *ResponseConfig `json:"response_config,omitempty"` // Response handling configuration
+
+	// Probe configures the health probe of the endpoint; nil probes it with HEAD.
+	Probe *ProbeConfig `json:"probe,omitempty"`
+}
+
+// ProbeConfig defines how the health of an endpoint is checked without sending a
+// message. The probe carries the endpoint's headers, so that authenticated
+// endpoints can be probed.
+type ProbeConfig struct {
+	Disabled bool   `json:"disabled"`         // Do not probe the endpoint
+	Method   string `json:"method,omitempty"` // HTTP method, defaults to HEAD
+	// URL is the URL to probe, e.g. a health route of the receiving service.
+	// Defaults to the endpoint URL with its query parameters.
+	URL string `json:"url,omitempty"`
+	// ExpectStatus lists the status codes of a healthy endpoint. When empty, any
+	// response below 500 is healthy: a webhook answering HEAD with 405 is up.
+	ExpectStatus []int `json:"expect_status,omitempty"`
 }
 
 // Endpoint implements core.Selectable and core.Validatable.
@@ -174,6 +191,13 @@ func WithQueryParams(params map[string]string) EndpointOption {
 	}
 }
 
+// WithProbe sets how the health of the endpoint is checked.
+func WithProbe(config *ProbeConfig) EndpointOption {
+	return func(endpoint *Endpoint) {
+		endpoint.Probe = config
+	}
+}
+
 // WithResponseConfig sets the response handling configuration.
 func WithResponseConfig(config *ResponseConfig) EndpointOption {
 	return func(endpoint *Endpoint) {
@@ -16,6 +16,8 @@
 package webhook
 
 import (
+	"context"
+
 	"github.com/shellvon/go-sender/core"
 	"github.com/shellvon/go-sender/providers"
 )
@@ -23,21 +25,27 @@ import (
 // Provider implements the Webhook provider using generic base.
 type Provider struct {
 	*providers.HTTPProvider[*Endpoint]
+
+	transformer *webhookTransformer
 }
 
-var _ core.Provider = (*Provider)(nil)
+var (
+	_ core.Provider = (*Provider)(nil)
+	_ core.Prober   = (*Provider)(nil)
+)
 
 // New creates a new Webhook provider instance.
 func New(config *Config) (*Provider, error) {
+	transformer := newWebhookTransformer()
 	httpProvider, err := providers.NewHTTPProvider(
 		string(core.ProviderTypeWebhook),
-		newWebhookTransformer(),
+		transformer,
 		config,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &Provider{HTTPProvider: httpProvider}, nil
+	return &Provider{HTTPProvider: httpProvider, transformer: transformer}, nil
 }
 
 // ProviderOption represents a function that modifies Webhook Provider configuration.
@@ -71,6 +79,25 @@ func (p *Provider) Name() string {
 	return string(core.ProviderTypeWebhook)
 }
 
+// Probe implements core.Prober: it sends the probe request of every enabled
+// endpoint, HEAD on the endpoint URL unless configured otherwise with WithProbe.
+func (p *Provider) Probe(ctx context.Context, opts *core.ProviderSendOptions) []core.ProbeResult {
+	if opts == nil {
+		opts = &core.ProviderSendOptions{}
+	}
+	return p.ProbeItems(ctx, func(ctx context.Context, endpoint *Endpoint) (map[string]interface{}, error) {
+		reqSpec, handler, err := p.transformer.probeRequest(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		result, err := p.ExecuteHTTPRequest(ctx, reqSpec, handler, opts)
+		if result == nil {
+			return nil, err
+		}
+		return map[string]interface{}{"status_code": result.StatusCode}, err
+	})
+}
+
 // Re-exported core provider options for cleaner API
 // These provide convenient aliases: webhook.Strategy(core.StrategyWeighted) instead of core.WithStrategy[*webhook.Config](core.StrategyWeighted).
 var (
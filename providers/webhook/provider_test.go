@@ -347,3 +347,48 @@ func TestProvider_Send_InvalidMessageType(t *testing.T) {
 		t.Error("Expected error for invalid message type, got nil")
 	}
 }
+
+func TestProvider_Probe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		case r.Method != http.MethodHead || r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer ts.Close()
+
+	provider, err := webhook.NewProvider([]*webhook.Endpoint{
+		webhook.NewEndpoint(ts.URL+"/hook", webhook.Name("head"), webhook.WithHeader("Authorization", "Bearer token")),
+		webhook.NewEndpoint(ts.URL+"/down", webhook.Name("down")),
+		webhook.NewEndpoint(ts.URL+"/hook", webhook.Name("custom"),
+			webhook.WithProbe(&webhook.ProbeConfig{Method: http.MethodGet, URL: ts.URL + "/health", ExpectStatus: []int{204}})),
+		webhook.NewEndpoint(ts.URL+"/hook", webhook.Name("strict"),
+			webhook.WithProbe(&webhook.ProbeConfig{ExpectStatus: []int{http.StatusOK}})),
+		webhook.NewEndpoint(ts.URL+"/down", webhook.Name("skipped"), webhook.WithProbe(&webhook.ProbeConfig{Disabled: true})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	healthy := map[string]bool{}
+	for _, result := range provider.Probe(context.Background(), nil) {
+		healthy[result.Account] = result.Err == nil
+	}
+	want := map[string]bool{"head": true, "down": false, "custom": true, "strict": false, "skipped": false}
+	for name, ok := range want {
+		if healthy[name] != ok {
+			t.Errorf("probe of %s healthy = %v, want %v", name, healthy[name], ok)
+		}
+	}
+
+	check := core.HealthFromProbes(provider.Probe(context.Background(), nil))
+	if check.Status != core.HealthStatusDegraded || check.Checks["skipped"].Status != core.HealthStatusUnknown {
+		t.Errorf("unexpected health %s: %+v", check.Status, check.Checks)
+	}
+}
@@ -3,8 +3,10 @@ package webhook
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 
 	"github.com/shellvon/go-sender/core"
 	"github.com/shellvon/go-sender/transformer"
@@ -106,7 +108,47 @@ func (wt *webhookTransformer) transform(
 	return reqSpec, core.NewSendResultHandler(endpoint.ResponseConfig), nil
 }
 
-func newWebhookTransformer() core.HTTPTransformer[*Endpoint] {
+// probeRequest builds the request of the health probe of endpoint and the
+// handler checking its response.
+func (wt *webhookTransformer) probeRequest(endpoint *Endpoint) (*core.HTTPRequestSpec, core.SendResultHandler, error) {
+	probe := endpoint.Probe
+	if probe == nil {
+		probe = &ProbeConfig{}
+	}
+	if probe.Disabled {
+		return nil, nil, core.ErrProbeUnsupported
+	}
+
+	probeURL := probe.URL
+	if probeURL == "" {
+		baseURL, err := wt.buildEndpointURL(endpoint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build endpoint URL: %w", err)
+		}
+		probeURL = baseURL
+	}
+	method := probe.Method
+	if method == "" {
+		method = http.MethodHead
+	}
+
+	handler := func(result *core.SendResult) error {
+		if len(probe.ExpectStatus) == 0 && result.StatusCode < http.StatusInternalServerError {
+			return nil
+		}
+		if slices.Contains(probe.ExpectStatus, result.StatusCode) {
+			return nil
+		}
+		return fmt.Errorf("unexpected probe status %d", result.StatusCode)
+	}
+	return &core.HTTPRequestSpec{
+		Method:  method,
+		URL:     probeURL,
+		Headers: maps.Clone(endpoint.Headers),
+	}, handler, nil
+}
+
+func newWebhookTransformer() *webhookTransformer {
 	wt := &webhookTransformer{}
 	wt.BaseHTTPTransformer = transformer.NewSimpleHTTPTransformer(
 		core.ProviderTypeWebhook,
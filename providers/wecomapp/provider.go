@@ -35,7 +35,10 @@ type Provider struct {
 	transformer *wecomTransformer
 }
 
-var _ core.Provider = (*Provider)(nil)
+var (
+	_ core.Provider = (*Provider)(nil)
+	_ core.Prober   = (*Provider)(nil)
+)
 
 // uploadTarget 抽象需要自动上传媒体文件的消息接口.
 type uploadTarget interface {
@@ -161,8 +164,30 @@ func (p *Provider) Send(
 	// 包装handler以处理企业微信错误
 	handler = p.transformer.wrapHandler(ctx, selectedAccount, handler)
 
-	// 直接使用父类的公开方法发送HTTP请求
-	return p.ExecuteHTTPRequest(ctx, reqSpec, handler, opts)
+	// 直接使用父类的公开方法发送HTTP请求（经过账号级熔断器）
+	var result *core.SendResult
+	err = p.Execute(ctx, selectedAccount, func() error {
+		var execErr error
+		result, execErr = p.ExecuteHTTPRequest(ctx, reqSpec, handler, opts)
+		return execErr
+	})
+	return result, err
+}
+
+// Probe 实现 core.Prober: 为每个启用的账号重新获取 access token 以校验 corpid/secret,
+// 成功获取的 token 会写入缓存供后续发送使用.
+func (p *Provider) Probe(ctx context.Context, opts *core.ProviderSendOptions) []core.ProbeResult {
+	var httpClient *http.Client
+	if opts != nil {
+		httpClient = opts.HTTPClient
+	}
+	return p.ProbeItems(ctx, func(ctx context.Context, account *Account) (map[string]interface{}, error) {
+		token, err := p.transformer.refreshAccessToken(ctx, account, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get access token: %w", err)
+		}
+		return map[string]interface{}{"token_expires_at": token.ExpiresAt}, nil
+	})
 }
 
 // handleMediaUpload 手动处理媒体文件上传.
@@ -0,0 +1,86 @@
+package wecomapp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/providers/wecomapp"
+)
+
+// redirectTransport sends every request to the test server, keeping the API's
+// host in the path.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Path = "/" + req.URL.Host + req.URL.Path
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProvider_SendClassifiesErrors(t *testing.T) {
+	var tokens atomic.Int32
+	replies := []string{
+		`{"errcode":42001,"errmsg":"access_token expired"}`,
+		`{"errcode":45009,"errmsg":"api freq out of limit"}`,
+		`{"errcode":40008,"errmsg":"invalid message type"}`,
+		`{"errcode":0,"errmsg":"ok"}`,
+	}
+	var sends atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/qyapi.weixin.qq.com/cgi-bin/gettoken":
+			fmt.Fprintf(w, `{"errcode":0,"access_token":"token-%d","expires_in":7200}`, tokens.Add(1))
+		case "/qyapi.weixin.qq.com/cgi-bin/message/send":
+			_, _ = w.Write([]byte(replies[sends.Add(1)-1]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	target, _ := url.Parse(ts.URL)
+
+	provider, err := wecomapp.NewProvider([]*wecomapp.Account{wecomapp.NewAccount("corp", "secret", "1000002")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &core.ProviderSendOptions{HTTPClient: &http.Client{Transport: redirectTransport{target: target}}}
+	send := func() error {
+		_, sendErr := provider.Send(context.Background(), wecomapp.Text().ToUser("user").Content("hi").Build(), opts)
+		return sendErr
+	}
+
+	// An expired token is retryable: the cached token is dropped and fetched again.
+	err = send()
+	var apiErr *wecomapp.WecomAPIError
+	if core.VendorErrorKind(err) != core.ErrorKindTransient || !errors.As(err, &apiErr) || apiErr.ErrCode != 42001 {
+		t.Fatalf("expected a transient vendor error wrapping the API error, got %v", err)
+	}
+	if !core.NewDefaultErrorClassifier().IsRetryableError(err) {
+		t.Error("an expired token should be retryable")
+	}
+
+	// Throttling reaches adaptive rate limiters.
+	if err = send(); !core.IsThrottled(err) {
+		t.Errorf("expected 45009 to be throttled, got %v", err)
+	}
+	if tokens.Load() != 2 {
+		t.Errorf("expected the token to be fetched again, got %d fetches", tokens.Load())
+	}
+
+	if err = send(); core.GetSenderErrorCode(err) != core.ErrCodeValidationFailed {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+	if err = send(); err != nil {
+		t.Errorf("expected success, got %v", err)
+	}
+}
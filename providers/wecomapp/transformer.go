@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/shellvon/go-sender/core"
@@ -78,13 +79,14 @@ func newWecomTransformer(tokenCache core.Cache[*AccessToken]) *wecomTransformer
 	}
 
 	respCfg := &core.ResponseHandlerConfig{
-		BodyType:  core.BodyTypeJSON,
-		CheckBody: true,
-		Path:      "errcode",
-		Expect:    "0",
-		Mode:      core.MatchEq,
-		CodePath:  "errcode",
-		MsgPath:   "errmsg",
+		BodyType:   core.BodyTypeJSON,
+		CheckBody:  true,
+		Path:       "errcode",
+		Expect:     "0",
+		Mode:       core.MatchEq,
+		CodePath:   "errcode",
+		MsgPath:    "errmsg",
+		ErrorKinds: errorKinds,
 	}
 
 	t := &wecomTransformer{
@@ -177,18 +179,30 @@ func (t *wecomTransformer) GetValidAccessToken(
 	}
 
 	// 获取新token
-	newToken, err := t.fetchAccessToken(ctx, account, httpClient)
+	newToken, err := t.refreshAccessToken(ctx, account, httpClient)
 	if err != nil {
 		return "", err
 	}
+	return newToken.Token, nil
+}
+
+// refreshAccessToken 从API获取新的access token并写入缓存.
+func (t *wecomTransformer) refreshAccessToken(
+	ctx context.Context,
+	account *Account,
+	httpClient *http.Client,
+) (*AccessToken, error) {
+	newToken, err := t.fetchAccessToken(ctx, account, httpClient)
+	if err != nil {
+		return nil, err
+	}
 
 	// 直接缓存新token（TTL已经在fetchAccessToken中调整过了）
 	ttl := time.Until(newToken.ExpiresAt)
 	if ttl > 0 {
-		_ = t.tokenCache.Set(tokenKey, newToken, &ttl)
+		_ = t.tokenCache.Set(t.getTokenKey(account), newToken, &ttl)
 	}
-
-	return newToken.Token, nil
+	return newToken, nil
 }
 
 // fetchAccessToken 从API获取新的access token.
@@ -251,6 +265,8 @@ func (t *wecomTransformer) getTokenKey(account *Account) string {
 }
 
 // wrapHandler 包装响应处理器以支持认证错误处理.
+// 企业微信错误以 *core.VendorError 返回 (按 errorKinds 分类, Err 为 *WecomAPIError),
+// 认证错误会清除缓存的 access token, 以便重试时重新获取.
 func (t *wecomTransformer) wrapHandler(
 	_ context.Context,
 	account *Account,
@@ -275,7 +291,10 @@ func (t *wecomTransformer) wrapHandler(
 					tokenKey := t.getTokenKey(account)
 					_ = t.tokenCache.Delete(tokenKey)
 				}
-				return wecomErr
+				vendorErr := core.NewVendorError(errorKinds, strconv.Itoa(wecomErr.ErrCode), wecomErr.ErrMsg)
+				vendorErr.StatusCode = result.StatusCode
+				vendorErr.Err = wecomErr
+				return vendorErr
 			}
 		}
 
@@ -286,3 +305,25 @@ func (t *wecomTransformer) wrapHandler(
 		return nil
 	}
 }
+
+// errorKinds classifies the errcode of the application message API, see core.VendorError.
+// Invalid or expired access tokens are transient: wrapHandler drops the cached
+// token, so the next attempt fetches a new one.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var errorKinds = core.ErrorKinds{
+	"-1":    core.ErrorKindTransient,      // 系统繁忙
+	"40001": core.ErrorKindAuthentication, // 不合法的secret参数
+	"40014": core.ErrorKindTransient,      // 不合法的access_token
+	"41001": core.ErrorKindTransient,      // 缺少access_token参数
+	"42001": core.ErrorKindTransient,      // access_token已过期
+	"40056": core.ErrorKindAuthentication, // 不合法的agentid
+	"48002": core.ErrorKindAuthentication, // API接口无权限调用
+	"60020": core.ErrorKindAuthentication, // 不安全的访问IP
+	"40008": core.ErrorKindValidation,     // 不合法的消息类型
+	"44004": core.ErrorKindValidation,     // 文本消息content参数为空
+	"45002": core.ErrorKindValidation,     // 消息内容大小超过限制
+	"81013": core.ErrorKindValidation,     // UserID、部门ID、标签ID全部非法或无权限
+	"45009": core.ErrorKindRateLimited,    // 接口调用超过限制
+	"45033": core.ErrorKindRateLimited,    // 接口并发调用超过限制
+}
@@ -66,13 +66,14 @@ func (wt *wecombotTransformer) transform(
 // 返回值：core.HTTPTransformer[*Account] - 新创建的转换器实例，用于处理企业微信机器人消息。
 func newWecombotTransformer() core.HTTPTransformer[*Account] {
 	respCfg := &core.ResponseHandlerConfig{
-		BodyType:  core.BodyTypeJSON,
-		CheckBody: true,
-		Path:      "errcode",
-		Expect:    "0",
-		Mode:      core.MatchEq,
-		CodePath:  "errcode",
-		MsgPath:   "errmsg",
+		BodyType:   core.BodyTypeJSON,
+		CheckBody:  true,
+		Path:       "errcode",
+		Expect:     "0",
+		Mode:       core.MatchEq,
+		CodePath:   "errcode",
+		MsgPath:    "errmsg",
+		ErrorKinds: errorKinds,
 	}
 
 	wt := &wecombotTransformer{}
@@ -85,3 +86,16 @@ func newWecombotTransformer() core.HTTPTransformer[*Account] {
 
 	return wt
 }
+
+// errorKinds classifies the errcode of the group robot API, see core.VendorError.
+//
+//nolint:gochecknoglobals // Read-only lookup table.
+var errorKinds = core.ErrorKinds{
+	"-1":    core.ErrorKindTransient,      // 系统繁忙
+	"93000": core.ErrorKindAuthentication, // invalid webhook url
+	"40008": core.ErrorKindValidation,     // invalid message type
+	"40058": core.ErrorKindValidation,     // 不合法的参数
+	"44004": core.ErrorKindValidation,     // 文本消息content参数为空
+	"45002": core.ErrorKindValidation,     // 消息内容大小超过限制
+	"45009": core.ErrorKindRateLimited,    // 接口调用超过限制
+}
@@ -0,0 +1,299 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// DefaultLane is the lane used for items whose classifier result matches no configured lane.
+const DefaultLane = "default"
+
+// Lane describes one scheduling lane of a [FairQueue].
+type Lane struct {
+	// Name identifies the lane; it is matched against the result of the LaneFunc.
+	Name string
+	// Weight is the relative share of dequeues this lane receives while other lanes
+	// are also backlogged. Values <= 0 are treated as 1.
+	Weight int
+	// Reserved is the number of queue slots reserved for this lane. Other lanes
+	// cannot use them, so a flood in one lane never locks a critical lane out.
+	Reserved int
+	// MaxWait enables aging: once an item of the lane has been ready for this
+	// long, the lane is served next regardless of weights, and that item is
+	// dequeued ahead of higher-priority items of the lane. Among several aged
+	// lanes the one holding the oldest item goes first. Zero disables aging.
+	MaxWait time.Duration
+}
+
+// LaneFunc maps an item to the name of the lane it belongs to.
+type LaneFunc[T any] func(item T) string
+
+// FairQueue is an in-memory queue that splits items into named lanes and
+// dequeues across them with smooth weighted round-robin. Within a lane items
+// keep the [core.Comparable] order and scheduled items are delayed exactly as
+// in [MemoryQueue].
+//
+// Capacity: maxSize bounds the total number of items. The sum of all Lane.Reserved
+// is carved out of it; the remainder is shared by every lane on a first-come basis.
+type FairQueue[T core.Comparable[T]] struct {
+	mu       sync.Mutex
+	lanes    []*fairLane[T]
+	byName   map[string]*fairLane[T]
+	classify LaneFunc[T]
+	maxSize  int
+	shared   int
+
+	notifyChan chan struct{}
+	done       chan struct{}
+	closed     int32
+	closeOnce  sync.Once
+}
+
+type fairLane[T core.Comparable[T]] struct {
+	Lane
+	q *MemoryQueue[T]
+	// current is the smooth weighted round-robin counter.
+	current int
+}
+
+// NewFairQueue creates a lane-based fair queue.
+//
+//   - maxSize: total capacity, 0 means unlimited.
+//   - classify: maps each item to a lane name; unknown names and a nil classify
+//     fall back to [DefaultLane], which is created with weight 1 if not configured.
+//   - lanes: lane definitions; later definitions with a duplicate name are ignored.
+func NewFairQueue[T core.Comparable[T]](maxSize int, classify LaneFunc[T], lanes ...Lane) *FairQueue[T] {
+	fq := &FairQueue[T]{
+		byName:     make(map[string]*fairLane[T]),
+		classify:   classify,
+		maxSize:    maxSize,
+		notifyChan: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+
+	reserved := 0
+	for _, l := range lanes {
+		if _, exists := fq.byName[l.Name]; exists {
+			continue
+		}
+		fq.addLane(l)
+		reserved += l.Reserved
+	}
+	if _, exists := fq.byName[DefaultLane]; !exists {
+		fq.addLane(Lane{Name: DefaultLane, Weight: 1})
+	}
+
+	fq.shared = maxSize - reserved
+	if fq.shared < 0 {
+		fq.shared = 0
+	}
+	return fq
+}
+
+func (fq *FairQueue[T]) addLane(l Lane) {
+	if l.Weight <= 0 {
+		l.Weight = 1
+	}
+	mq := NewMemoryQueue[T](0)
+	mq.wake = fq.signal
+	lane := &fairLane[T]{Lane: l, q: mq}
+	fq.lanes = append(fq.lanes, lane)
+	fq.byName[l.Name] = lane
+}
+
+// Enqueue adds an item to its lane.
+func (fq *FairQueue[T]) Enqueue(ctx context.Context, item T) error {
+	if atomic.LoadInt32(&fq.closed) == 1 {
+		return ErrQueueClosed
+	}
+
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+
+	lane := fq.laneFor(item)
+	if !fq.hasRoomLocked(lane) {
+		return ErrQueueFull
+	}
+	return lane.q.Enqueue(ctx, item)
+}
+
+// EnqueueDelayed adds an item to its lane to be processed after the given delay.
+func (fq *FairQueue[T]) EnqueueDelayed(ctx context.Context, item T, delay time.Duration) error {
+	if atomic.LoadInt32(&fq.closed) == 1 {
+		return ErrQueueClosed
+	}
+
+	if schedulable, ok := any(item).(core.Schedulable); ok {
+		schedulable.SetScheduledAt(time.Now().Add(delay))
+	}
+
+	return fq.Enqueue(ctx, item)
+}
+
+// Dequeue blocks until an item is ready in any lane and returns the one chosen
+// by the fair scheduler.
+func (fq *FairQueue[T]) Dequeue(ctx context.Context) (T, error) {
+	var zero T
+
+	for {
+		if atomic.LoadInt32(&fq.closed) == 1 {
+			return zero, ErrQueueClosed
+		}
+
+		fq.mu.Lock()
+		item, ok, more := fq.pickLocked(time.Now())
+		fq.mu.Unlock()
+		if ok {
+			if more {
+				fq.signal()
+			}
+			return item, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-fq.done:
+			return zero, ErrQueueClosed
+		case <-fq.notifyChan:
+		}
+	}
+}
+
+// Size returns the total number of items across all lanes.
+func (fq *FairQueue[T]) Size() int {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	return fq.sizeLocked()
+}
+
+// LaneSizes returns the number of items per lane.
+func (fq *FairQueue[T]) LaneSizes() map[string]int {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	sizes := make(map[string]int, len(fq.lanes))
+	for _, lane := range fq.lanes {
+		sizes[lane.Name] = lane.q.Size()
+	}
+	return sizes
+}
+
+// Close shuts down the queue and all its lanes.
+func (fq *FairQueue[T]) Close() error {
+	fq.closeOnce.Do(func() {
+		atomic.StoreInt32(&fq.closed, 1)
+		for _, lane := range fq.lanes {
+			_ = lane.q.Close()
+		}
+		close(fq.done)
+	})
+	return nil
+}
+
+func (fq *FairQueue[T]) laneFor(item T) *fairLane[T] {
+	if fq.classify != nil {
+		if lane, ok := fq.byName[fq.classify(item)]; ok {
+			return lane
+		}
+	}
+	return fq.byName[DefaultLane]
+}
+
+// hasRoomLocked reports whether lane may accept one more item. Caller must hold mu.
+func (fq *FairQueue[T]) hasRoomLocked(lane *fairLane[T]) bool {
+	if fq.maxSize <= 0 {
+		return true
+	}
+	if lane.q.Size() < lane.Reserved {
+		return true
+	}
+	usedShared := 0
+	for _, l := range fq.lanes {
+		if over := l.q.Size() - l.Reserved; over > 0 {
+			usedShared += over
+		}
+	}
+	return usedShared < fq.shared
+}
+
+func (fq *FairQueue[T]) sizeLocked() int {
+	total := 0
+	for _, lane := range fq.lanes {
+		total += lane.q.Size()
+	}
+	return total
+}
+
+// pickLocked selects the lane to serve and pops its head, or its oldest item
+// once that has aged. It returns the item, whether one was found, and whether
+// further ready items remain. Caller must hold mu.
+func (fq *FairQueue[T]) pickLocked(now time.Time) (T, bool, bool) {
+	var zero T
+
+	ready := make([]*fairLane[T], 0, len(fq.lanes))
+	for _, lane := range fq.lanes {
+		if lane.q.ReadySize() > 0 {
+			ready = append(ready, lane)
+		}
+	}
+	if len(ready) == 0 {
+		return zero, false, false
+	}
+
+	chosen := fq.agedLane(ready, now)
+	if chosen == nil {
+		chosen = fq.weightedLane(ready)
+	}
+
+	item, ok := chosen.q.tryDequeueAged(now, chosen.MaxWait)
+	if !ok {
+		return zero, false, false
+	}
+	return item, true, len(ready) > 1 || chosen.q.ReadySize() > 0
+}
+
+// agedLane returns the ready lane holding the oldest item that has exceeded the
+// lane's MaxWait, if any.
+func (fq *FairQueue[T]) agedLane(ready []*fairLane[T], now time.Time) *fairLane[T] {
+	var chosen *fairLane[T]
+	var chosenSince time.Time
+	for _, lane := range ready {
+		if lane.MaxWait <= 0 {
+			continue
+		}
+		since, ok := lane.q.oldestReady(now)
+		if !ok || now.Sub(since) < lane.MaxWait {
+			continue
+		}
+		if chosen == nil || since.Before(chosenSince) {
+			chosen, chosenSince = lane, since
+		}
+	}
+	return chosen
+}
+
+// weightedLane applies smooth weighted round-robin across ready lanes.
+func (fq *FairQueue[T]) weightedLane(ready []*fairLane[T]) *fairLane[T] {
+	total := 0
+	var chosen *fairLane[T]
+	for _, lane := range ready {
+		lane.current += lane.Weight
+		total += lane.Weight
+		if chosen == nil || lane.current > chosen.current {
+			chosen = lane
+		}
+	}
+	chosen.current -= total
+	return chosen
+}
+
+func (fq *FairQueue[T]) signal() {
+	select {
+	case fq.notifyChan <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// Compile-time assertions: FairQueue of QueueItem can be managed and reports evictions.
+var (
+	_ core.QueueManager     = (*FairQueue[*core.QueueItem])(nil)
+	_ core.EvictionNotifier = (*FairQueue[*core.QueueItem])(nil)
+)
+
+// List returns the items matching filter across all lanes: ready items by
+// priority, then scheduled items by due time. The lane weights decide the
+// actual order in which ready items of different lanes are dequeued.
+func (fq *FairQueue[T]) List(_ context.Context, filter core.QueueFilter) ([]T, error) {
+	fq.mu.Lock()
+	now := time.Now()
+	var ready readyHeap[T]
+	var delayed scheduledHeap[T]
+	for _, lane := range fq.lanes {
+		r, d := lane.q.entries(now)
+		ready = append(ready, r...)
+		delayed = append(delayed, d...)
+	}
+	fq.mu.Unlock()
+
+	sort.SliceStable(ready, ready.Less)
+	sort.SliceStable(delayed, delayed.Less)
+	return collect(filter, append(ready, delayed...)), nil
+}
+
+// Peek returns the next item matching filter without removing it.
+func (fq *FairQueue[T]) Peek(ctx context.Context, filter core.QueueFilter) (T, error) {
+	filter.Limit = 1
+	items, _ := fq.List(ctx, filter)
+	if len(items) == 0 {
+		var zero T
+		return zero, ErrItemNotFound
+	}
+	return items[0], nil
+}
+
+// Get returns the item with the given ID from whichever lane holds it.
+func (fq *FairQueue[T]) Get(ctx context.Context, id string) (T, error) {
+	return eachLane(fq, func(q *MemoryQueue[T]) (T, error) { return q.Get(ctx, id) })
+}
+
+// Remove removes and returns the item with the given ID that matches filter.
+func (fq *FairQueue[T]) Remove(ctx context.Context, id string, filter core.QueueFilter) (T, error) {
+	return eachLane(fq, func(q *MemoryQueue[T]) (T, error) { return q.Remove(ctx, id, filter) })
+}
+
+// Reschedule moves the item with the given ID to a new scheduled time within its lane.
+func (fq *FairQueue[T]) Reschedule(ctx context.Context, id string, at time.Time) error {
+	_, err := eachLane(fq, func(q *MemoryQueue[T]) (T, error) {
+		var zero T
+		return zero, q.Reschedule(ctx, id, at)
+	})
+	return err
+}
+
+// Purge removes every *core.QueueItem of the given provider from all lanes and
+// returns the number removed. The removed items are reported to the
+// [FairQueue.OnEvict] handlers with [ErrItemPurged].
+func (fq *FairQueue[T]) Purge(ctx context.Context, provider string) (int, error) {
+	total := 0
+	for _, lane := range fq.lanes {
+		n, err := lane.q.Purge(ctx, provider)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// OnEvict registers a handler on every lane, see [MemoryQueue.OnEvict]. It
+// returns a function that unregisters the handler from all of them.
+func (fq *FairQueue[T]) OnEvict(fn func(item T, err error)) (unsubscribe func()) {
+	unsubscribes := make([]func(), 0, len(fq.lanes))
+	for _, lane := range fq.lanes {
+		unsubscribes = append(unsubscribes, lane.q.OnEvict(fn))
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// eachLane runs op on the lanes in turn until one finds the item, and returns
+// its result, or [ErrItemNotFound] when no lane has the item.
+func eachLane[T core.Comparable[T]](fq *FairQueue[T], op func(q *MemoryQueue[T]) (T, error)) (T, error) {
+	for _, lane := range fq.lanes {
+		item, err := op(lane.q)
+		if !errors.Is(err, ErrItemNotFound) {
+			return item, err
+		}
+	}
+	var zero T
+	return zero, ErrItemNotFound
+}
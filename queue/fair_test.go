@@ -0,0 +1,248 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/queue"
+)
+
+// laneOf puts negative ids into the "bulk" lane and the rest into "otp".
+func laneOf(item *testItem) string {
+	if item.id < 0 {
+		return "bulk"
+	}
+	return "otp"
+}
+
+func TestFairQueue_WeightedShare(t *testing.T) {
+	q := queue.NewFairQueue[*testItem](0, laneOf,
+		queue.Lane{Name: "otp", Weight: 3},
+		queue.Lane{Name: "bulk", Weight: 1},
+	)
+	defer q.Close()
+
+	for i := 1; i <= 40; i++ {
+		_ = q.Enqueue(context.Background(), &testItem{id: -i})
+		_ = q.Enqueue(context.Background(), &testItem{id: i})
+	}
+
+	otp := 0
+	for range 20 {
+		item, err := q.Dequeue(context.Background())
+		if err != nil {
+			t.Fatalf("dequeue failed: %v", err)
+		}
+		if item.id > 0 {
+			otp++
+		}
+	}
+	if otp != 15 {
+		t.Errorf("expected 15 of 20 dequeues from otp lane (3:1), got %d", otp)
+	}
+}
+
+func TestFairQueue_ReservedCapacity(t *testing.T) {
+	q := queue.NewFairQueue[*testItem](3, laneOf,
+		queue.Lane{Name: "otp", Reserved: 1},
+		queue.Lane{Name: "bulk"},
+	)
+	defer q.Close()
+
+	_ = q.Enqueue(context.Background(), &testItem{id: -1})
+	_ = q.Enqueue(context.Background(), &testItem{id: -2})
+	if err := q.Enqueue(context.Background(), &testItem{id: -3}); !errors.Is(err, queue.ErrQueueFull) {
+		t.Errorf("bulk should not use otp's reserved slot, got %v", err)
+	}
+	if err := q.Enqueue(context.Background(), &testItem{id: 1}); err != nil {
+		t.Errorf("otp should use its reserved slot, got %v", err)
+	}
+	if q.Size() != 3 {
+		t.Errorf("expected size 3, got %d", q.Size())
+	}
+}
+
+func TestFairQueue_Aging(t *testing.T) {
+	q := queue.NewFairQueue[*testItem](0, laneOf,
+		queue.Lane{Name: "otp", Weight: 1000},
+		queue.Lane{Name: "bulk", Weight: 1, MaxWait: 20 * time.Millisecond},
+	)
+	defer q.Close()
+
+	for i := 1; i <= 100; i++ {
+		_ = q.Enqueue(context.Background(), &testItem{id: i})
+	}
+	_ = q.Enqueue(context.Background(), &testItem{id: -1})
+
+	// The first pick registers bulk as waiting; smooth WRR may serve it once early.
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		item, err := q.Dequeue(context.Background())
+		if err != nil {
+			t.Fatalf("dequeue failed: %v", err)
+		}
+		if item.id < 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("bulk item starved despite aging")
+}
+
+func TestFairQueue_AgingWithinLane(t *testing.T) {
+	q := queue.NewFairQueue[*testItem](0, laneOf,
+		queue.Lane{Name: "otp", Weight: 1, MaxWait: 20 * time.Millisecond},
+	)
+	defer q.Close()
+
+	// A low-priority item is kept behind a steady stream of higher-priority ones
+	// until it has been ready for MaxWait.
+	_ = q.Enqueue(context.Background(), &testItem{id: 1000})
+	for i := 1; i <= 5; i++ {
+		_ = q.Enqueue(context.Background(), &testItem{id: i})
+		item, err := q.Dequeue(context.Background())
+		if err != nil || item.id != i {
+			t.Fatalf("expected item %d before aging, got %v, %v", i, item, err)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	_ = q.Enqueue(context.Background(), &testItem{id: 6})
+	item, err := q.Dequeue(context.Background())
+	if err != nil || item.id != 1000 {
+		t.Fatalf("expected the aged item first, got %v, %v", item, err)
+	}
+	if item, _ = q.Dequeue(context.Background()); item.id != 6 {
+		t.Errorf("expected item 6 next, got %d", item.id)
+	}
+}
+
+func TestFairQueue_DelayedAndDefaultLane(t *testing.T) {
+	q := queue.NewFairQueue[*testItem](0, func(*testItem) string { return "unknown" })
+	defer q.Close()
+
+	_ = q.EnqueueDelayed(context.Background(), &testItem{id: 1}, 20*time.Millisecond)
+	if sizes := q.LaneSizes(); sizes[queue.DefaultLane] != 1 {
+		t.Fatalf("expected item in default lane, got %v", sizes)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := q.Dequeue(ctx); err != nil {
+		t.Fatalf("dequeue failed: %v", err)
+	}
+	if time.Since(start) < 15*time.Millisecond {
+		t.Error("delayed item dequeued too early")
+	}
+}
+
+func TestFairQueue_Closed(t *testing.T) {
+	q := queue.NewFairQueue[*testItem](0, laneOf)
+	_ = q.Close()
+	if err := q.Enqueue(context.Background(), &testItem{id: 1}); !errors.Is(err, queue.ErrQueueClosed) {
+		t.Errorf("expected ErrQueueClosed, got %v", err)
+	}
+	if _, err := q.Dequeue(context.Background()); !errors.Is(err, queue.ErrQueueClosed) {
+		t.Errorf("expected ErrQueueClosed on dequeue, got %v", err)
+	}
+}
+
+func TestFairQueue_Manage(t *testing.T) {
+	q := queue.NewFairQueue[*core.QueueItem](0, queue.LaneByPriority(queue.PriorityBand{Lane: "otp", Max: 1}),
+		queue.Lane{Name: "otp"})
+	defer q.Close()
+	ctx := context.Background()
+
+	later := time.Now().Add(time.Hour)
+	for _, item := range []*core.QueueItem{
+		{ID: "a", Provider: "sms", Priority: 5, CreatedAt: time.Now()},
+		{ID: "b", Provider: "email", Priority: 1, CreatedAt: time.Now()},
+		{ID: "c", Provider: "sms", Priority: 1, CreatedAt: time.Now(), ScheduledAt: &later},
+	} {
+		if err := q.Enqueue(ctx, item); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+
+	var evicted []string
+	unsubscribe := q.OnEvict(func(item *core.QueueItem, err error) {
+		if errors.Is(err, queue.ErrItemPurged) {
+			evicted = append(evicted, item.ID)
+		}
+	})
+
+	items, _ := q.List(ctx, core.QueueFilter{})
+	if got := ids(items); len(got) != 3 || got[0] != "b" || got[1] != "a" || got[2] != "c" {
+		t.Errorf("expected [b a c] across lanes, got %v", got)
+	}
+	if next, err := q.Peek(ctx, core.QueueFilter{Provider: "sms"}); err != nil || next.ID != "a" {
+		t.Errorf("Peek(sms) = %v, %v; want a", next, err)
+	}
+	if _, err := q.Remove(ctx, "b", core.QueueFilter{Provider: "sms"}); !errors.Is(err, queue.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound for another provider's item, got %v", err)
+	}
+	if removed, err := q.Remove(ctx, "b", core.QueueFilter{Provider: "email"}); err != nil || removed.ID != "b" {
+		t.Errorf("Remove(b) = %v, %v", removed, err)
+	}
+	if err := q.Reschedule(ctx, "c", time.Now().Add(-time.Second)); err != nil {
+		t.Errorf("Reschedule failed: %v", err)
+	}
+	if item, err := q.Get(ctx, "c"); err != nil || item.ScheduledAt.After(time.Now()) {
+		t.Errorf("Get(c) = %v, %v; want it rescheduled", item, err)
+	}
+
+	if n, err := q.Purge(ctx, "sms"); err != nil || n != 2 {
+		t.Fatalf("Purge = %d, %v; want 2", n, err)
+	}
+	if len(evicted) != 2 {
+		t.Errorf("expected both purged items reported, got %v", evicted)
+	}
+	unsubscribe()
+	_ = q.Enqueue(ctx, &core.QueueItem{ID: "d", Provider: "sms", CreatedAt: time.Now()})
+	_, _ = q.Purge(ctx, "sms")
+	if len(evicted) != 2 {
+		t.Errorf("unsubscribed handler still called, got %v", evicted)
+	}
+}
+
+type categoryMessage struct{ category string }
+
+func (m *categoryMessage) ProviderType() core.ProviderType { return core.ProviderTypeSMS }
+func (m *categoryMessage) MsgID() string                   { return "id" }
+func (m *categoryMessage) GetCategory() string             { return m.category }
+
+func TestLaneFuncs(t *testing.T) {
+	item := &core.QueueItem{
+		Priority: 2,
+		Message:  &categoryMessage{category: "verification"},
+		Metadata: map[string]interface{}{"tenant": "acme", "shard": 7},
+	}
+
+	byPriority := queue.LaneByPriority(
+		queue.PriorityBand{Lane: "high", Min: 1, Max: 3},
+		queue.PriorityBand{Lane: "low", Min: 4, Max: 10},
+	)
+	if got := byPriority(item); got != "high" {
+		t.Errorf("LaneByPriority = %q, want high", got)
+	}
+	if got := byPriority(&core.QueueItem{Priority: 42}); got != queue.DefaultLane {
+		t.Errorf("LaneByPriority out of band = %q, want default", got)
+	}
+	if got := queue.LaneByCategory()(item); got != "verification" {
+		t.Errorf("LaneByCategory = %q, want verification", got)
+	}
+	if got := queue.LaneByMetadata("tenant")(item); got != "acme" {
+		t.Errorf("LaneByMetadata = %q, want acme", got)
+	}
+	if got := queue.LaneByMetadata("shard")(item); got != "7" {
+		t.Errorf("LaneByMetadata non-string = %q, want 7", got)
+	}
+	if got := queue.LaneByMetadata("missing")(item); got != queue.DefaultLane {
+		t.Errorf("LaneByMetadata missing = %q, want default", got)
+	}
+}
+
+var _ core.Queue = (*queue.FairQueue[*core.QueueItem])(nil)
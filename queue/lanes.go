@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"fmt"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// PriorityBand maps an inclusive [Min, Max] range of [core.QueueItem.Priority] to a lane.
+type PriorityBand struct {
+	Lane string
+	Min  int
+	Max  int
+}
+
+// LaneByPriority classifies queue items by priority band. Items outside every
+// band go to [DefaultLane].
+func LaneByPriority(bands ...PriorityBand) LaneFunc[*core.QueueItem] {
+	return func(item *core.QueueItem) string {
+		for _, b := range bands {
+			if item.Priority >= b.Min && item.Priority <= b.Max {
+				return b.Lane
+			}
+		}
+		return DefaultLane
+	}
+}
+
+// LaneByCategory classifies queue items by the category of their message
+// (see [core.CategoryAware]), e.g. "verification" or "promotion" for SMS.
+func LaneByCategory() LaneFunc[*core.QueueItem] {
+	return func(item *core.QueueItem) string {
+		if ca, ok := item.Message.(core.CategoryAware); ok {
+			return ca.GetCategory()
+		}
+		return DefaultLane
+	}
+}
+
+// LaneByMetadata classifies queue items by the value of a metadata key set via
+// core.WithSendMetadata.
+func LaneByMetadata(key string) LaneFunc[*core.QueueItem] {
+	return func(item *core.QueueItem) string {
+		v, ok := item.Metadata[key]
+		if !ok || v == nil {
+			return DefaultLane
+		}
+		if s, isStr := v.(string); isStr {
+			return s
+		}
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,239 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// ErrItemNotFound is returned by management operations when no item has the requested ID.
+//
+//nolint:gochecknoglobals // sentinel error
+var ErrItemNotFound = core.NewSenderError(core.ErrCodeQueueItemNotFound, "queue item not found", nil)
+
+// Compile-time assertion: MemoryQueue of QueueItem implements core.QueueManager.
+var _ core.QueueManager = (*MemoryQueue[*core.QueueItem])(nil)
+
+// List returns the items matching filter in dequeue order: ready items by
+// priority, then scheduled items by due time. Provider and priority constraints
+// only apply to *core.QueueItem; items of other types match on schedule alone.
+func (mq *MemoryQueue[T]) List(_ context.Context, filter core.QueueFilter) ([]T, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.promoteLocked(time.Now())
+
+	return collect(filter, mq.orderedLocked()), nil
+}
+
+// Peek returns the next item matching filter without removing it.
+func (mq *MemoryQueue[T]) Peek(ctx context.Context, filter core.QueueFilter) (T, error) {
+	filter.Limit = 1
+	items, _ := mq.List(ctx, filter)
+	if len(items) == 0 {
+		var zero T
+		return zero, ErrItemNotFound
+	}
+	return items[0], nil
+}
+
+// Get returns the item with the given ID. Items must implement [core.Identifiable].
+func (mq *MemoryQueue[T]) Get(_ context.Context, id string) (T, error) {
+	mq.mu.RLock()
+	defer mq.mu.RUnlock()
+
+	if e, _, _, ok := mq.findLocked(id, core.QueueFilter{}); ok {
+		return e.item, nil
+	}
+	var zero T
+	return zero, ErrItemNotFound
+}
+
+// Remove removes and returns the item with the given ID that matches filter,
+// e.g. to cancel a scheduled notification of one provider before it fires. The
+// item is looked up and removed under one lock, so it cannot be dequeued in
+// between.
+func (mq *MemoryQueue[T]) Remove(_ context.Context, id string, filter core.QueueFilter) (T, error) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	e, ready, idx, ok := mq.findLocked(id, filter)
+	if !ok {
+		var zero T
+		return zero, ErrItemNotFound
+	}
+	mq.removeAtLocked(ready, idx)
+	return e.item, nil
+}
+
+// Reschedule moves the item with the given ID to a new scheduled time. A time in
+// the past makes the item ready immediately. Items must implement [core.Schedulable].
+func (mq *MemoryQueue[T]) Reschedule(_ context.Context, id string, at time.Time) error {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+
+	e, ready, idx, ok := mq.findLocked(id, core.QueueFilter{})
+	if !ok {
+		return ErrItemNotFound
+	}
+	schedulable, isSchedulable := any(e.item).(core.Schedulable)
+	if !isSchedulable {
+		return core.NewSenderError(core.ErrCodeInvalidConfig, "queue item is not schedulable", nil)
+	}
+
+	mq.removeAtLocked(ready, idx)
+	schedulable.SetScheduledAt(at)
+	mq.pushLocked(e.item)
+	return nil
+}
+
+// Purge removes every *core.QueueItem of the given provider and returns the number
+// removed. The removed items are reported to the [MemoryQueue.OnEvict] handlers
+// with [ErrItemPurged], so that their senders learn they will never be processed.
+func (mq *MemoryQueue[T]) Purge(_ context.Context, provider string) (int, error) {
+	mq.mu.Lock()
+
+	keep := func(item T) bool {
+		qi, ok := any(item).(*core.QueueItem)
+		return !ok || qi.Provider != provider
+	}
+
+	var removed []T
+	ready := (*mq.items)[:0]
+	for _, e := range *mq.items {
+		if keep(e.item) {
+			ready = append(ready, e)
+		} else {
+			removed = append(removed, e.item)
+		}
+	}
+	clearTail(*mq.items, len(ready))
+	*mq.items = ready
+	heap.Init(mq.items)
+
+	delayed := (*mq.delayed)[:0]
+	for _, e := range *mq.delayed {
+		if keep(e.item) {
+			delayed = append(delayed, e)
+		} else {
+			removed = append(removed, e.item)
+		}
+	}
+	clearTail(*mq.delayed, len(delayed))
+	*mq.delayed = delayed
+	heap.Init(mq.delayed)
+
+	if len(removed) > 0 {
+		mq.signalSpace()
+	}
+	mq.mu.Unlock()
+
+	for _, item := range removed {
+		mq.notifyEvicted(item, ErrItemPurged)
+	}
+	return len(removed), nil
+}
+
+// orderedLocked returns a snapshot of all entries in dequeue order. Caller must hold mu.
+func (mq *MemoryQueue[T]) orderedLocked() []entry[T] {
+	ready, delayed := mq.entriesLocked()
+	return append(ready, delayed...)
+}
+
+// entries promotes the items due at now and returns snapshots of the ready and
+// scheduled entries.
+func (mq *MemoryQueue[T]) entries(now time.Time) (readyHeap[T], scheduledHeap[T]) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.promoteLocked(now)
+	return mq.entriesLocked()
+}
+
+// entriesLocked returns snapshots of the ready and scheduled entries, each in
+// dequeue order. Caller must hold mu.
+func (mq *MemoryQueue[T]) entriesLocked() (readyHeap[T], scheduledHeap[T]) {
+	ready := make(readyHeap[T], len(*mq.items))
+	copy(ready, *mq.items)
+	sort.Slice(ready, ready.Less)
+
+	delayed := make(scheduledHeap[T], len(*mq.delayed))
+	copy(delayed, *mq.delayed)
+	sort.Slice(delayed, delayed.Less)
+
+	return ready, delayed
+}
+
+// collect returns the items of entries matching filter, up to filter.Limit.
+func collect[T any](filter core.QueueFilter, entries []entry[T]) []T {
+	var out []T
+	for _, e := range entries {
+		if !matchFilter(filter, e.item) {
+			continue
+		}
+		out = append(out, e.item)
+		if filter.Limit > 0 && len(out) >= filter.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// findLocked locates the entry with the given ID that matches filter. Caller must hold mu.
+func (mq *MemoryQueue[T]) findLocked(id string, filter core.QueueFilter) (entry[T], bool, int, bool) {
+	if id == "" {
+		return entry[T]{}, false, -1, false
+	}
+	for i, e := range *mq.items {
+		if itemID(e.item) == id && matchFilter(filter, e.item) {
+			return e, true, i, true
+		}
+	}
+	for i, e := range *mq.delayed {
+		if itemID(e.item) == id && matchFilter(filter, e.item) {
+			return e, false, i, true
+		}
+	}
+	return entry[T]{}, false, -1, false
+}
+
+// removeAtLocked removes the entry at idx from the ready or scheduled heap. Caller must hold mu.
+func (mq *MemoryQueue[T]) removeAtLocked(ready bool, idx int) {
+	if ready {
+		heap.Remove(mq.items, idx)
+	} else {
+		heap.Remove(mq.delayed, idx)
+	}
+	mq.signalSpace()
+}
+
+func itemID[T any](item T) string {
+	if identifiable, ok := any(item).(core.Identifiable); ok {
+		return identifiable.GetID()
+	}
+	return ""
+}
+
+func matchFilter[T any](filter core.QueueFilter, item T) bool {
+	if qi, ok := any(item).(*core.QueueItem); ok {
+		return filter.Match(qi)
+	}
+	if filter.ScheduledAfter.IsZero() && filter.ScheduledBefore.IsZero() {
+		return true
+	}
+	at, ok := scheduledAt(item)
+	if !ok {
+		return false
+	}
+	return (filter.ScheduledAfter.IsZero() || !at.Before(filter.ScheduledAfter)) &&
+		(filter.ScheduledBefore.IsZero() || at.Before(filter.ScheduledBefore))
+}
+
+// clearTail zeroes the entries beyond n so removed items can be garbage collected.
+func clearTail[T any](entries []entry[T], n int) {
+	var zero entry[T]
+	for i := n; i < len(entries); i++ {
+		entries[i] = zero
+	}
+}
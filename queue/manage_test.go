@@ -0,0 +1,137 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/queue"
+)
+
+func newManagedQueue(t *testing.T) *queue.MemoryQueue[*core.QueueItem] {
+	t.Helper()
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	now := time.Now()
+	later := now.Add(time.Hour)
+	items := []*core.QueueItem{
+		{ID: "a", Provider: "sms", Priority: 2, CreatedAt: now},
+		{ID: "b", Provider: "email", Priority: 1, CreatedAt: now},
+		{ID: "c", Provider: "sms", Priority: 1, CreatedAt: now, ScheduledAt: &later},
+	}
+	for _, item := range items {
+		if err := q.Enqueue(context.Background(), item); err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+	}
+	return q
+}
+
+func ids(items []*core.QueueItem) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.ID)
+	}
+	return out
+}
+
+func TestMemoryQueue_ListAndPeek(t *testing.T) {
+	q := newManagedQueue(t)
+	ctx := context.Background()
+
+	all, _ := q.List(ctx, core.QueueFilter{})
+	if got := ids(all); len(got) != 3 || got[0] != "b" || got[1] != "a" || got[2] != "c" {
+		t.Errorf("expected dequeue order [b a c], got %v", got)
+	}
+
+	smsItems, _ := q.List(ctx, core.QueueFilter{Provider: "sms"})
+	if got := ids(smsItems); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("expected sms items [a c], got %v", got)
+	}
+
+	maxPriority := 1
+	high, _ := q.List(ctx, core.QueueFilter{MaxPriority: &maxPriority, Limit: 1})
+	if got := ids(high); len(got) != 1 || got[0] != "b" {
+		t.Errorf("expected [b] for priority<=1 limit 1, got %v", got)
+	}
+
+	scheduled, _ := q.List(ctx, core.QueueFilter{ScheduledAfter: time.Now()})
+	if got := ids(scheduled); len(got) != 1 || got[0] != "c" {
+		t.Errorf("expected scheduled [c], got %v", got)
+	}
+
+	next, err := q.Peek(ctx, core.QueueFilter{Provider: "sms"})
+	if err != nil || next.ID != "a" {
+		t.Errorf("Peek = %v, %v; want a", next, err)
+	}
+	if q.Size() != 3 {
+		t.Error("List/Peek must not remove items")
+	}
+	if _, err = q.Peek(ctx, core.QueueFilter{Provider: "push"}); !errors.Is(err, queue.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+}
+
+func TestMemoryQueue_GetRemoveReschedule(t *testing.T) {
+	q := newManagedQueue(t)
+	ctx := context.Background()
+
+	item, err := q.Get(ctx, "c")
+	if err != nil || item.ID != "c" {
+		t.Fatalf("Get(c) = %v, %v", item, err)
+	}
+
+	if err = q.Reschedule(ctx, "c", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Reschedule failed: %v", err)
+	}
+	if q.ReadySize() != 3 {
+		t.Errorf("rescheduled item should be ready, ready=%d", q.ReadySize())
+	}
+
+	removed, err := q.Remove(ctx, "b", core.QueueFilter{})
+	if err != nil || removed.ID != "b" {
+		t.Fatalf("Remove(b) = %v, %v", removed, err)
+	}
+	if _, err = q.Get(ctx, "b"); !errors.Is(err, queue.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound after remove, got %v", err)
+	}
+	if err = q.Reschedule(ctx, "missing", time.Now()); !errors.Is(err, queue.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+
+	next, _ := q.Dequeue(ctx)
+	if next.ID != "c" {
+		t.Errorf("expected c (priority 1) next, got %s", next.ID)
+	}
+}
+
+func TestMemoryQueue_RemoveScopedToFilter(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	ctx := context.Background()
+	_ = q.Enqueue(ctx, &core.QueueItem{ID: "x", Provider: "sms", CreatedAt: time.Now()})
+	_ = q.Enqueue(ctx, &core.QueueItem{ID: "x", Provider: "email", CreatedAt: time.Now()})
+
+	if _, err := q.Remove(ctx, "x", core.QueueFilter{Provider: "push"}); !errors.Is(err, queue.ErrItemNotFound) {
+		t.Fatalf("expected ErrItemNotFound for another provider, got %v", err)
+	}
+	removed, err := q.Remove(ctx, "x", core.QueueFilter{Provider: "email"})
+	if err != nil || removed.Provider != "email" {
+		t.Fatalf("Remove(x, email) = %v, %v", removed, err)
+	}
+	left, _ := q.Get(ctx, "x")
+	if q.Size() != 1 || left.Provider != "sms" {
+		t.Errorf("expected only the sms item left, size=%d left=%v", q.Size(), left)
+	}
+}
+
+func TestMemoryQueue_Purge(t *testing.T) {
+	q := newManagedQueue(t)
+	n, err := q.Purge(context.Background(), "sms")
+	if err != nil || n != 2 {
+		t.Fatalf("Purge = %d, %v; want 2", n, err)
+	}
+	if q.Size() != 1 {
+		t.Errorf("expected 1 item left, got %d", q.Size())
+	}
+}
@@ -13,64 +13,170 @@ import (
 
 var (
 	ErrQueueClosed = errors.New("queue is closed")
-	ErrQueueFull   = errors.New("queue is full")
+	ErrQueueFull   = core.NewSenderError(core.ErrCodeQueueFull, "queue is full", nil)
+	// ErrItemEvicted is reported to eviction handlers for items pushed out by a newer item.
+	ErrItemEvicted = core.NewSenderError(core.ErrCodeQueueItemEvicted, "queue item evicted", nil)
+	// ErrItemPurged is reported to eviction handlers for items removed by Purge.
+	ErrItemPurged = core.NewSenderError(core.ErrCodeQueueItemCancelled, "queue item purged", nil)
 )
 
-const notifyTimeout = 100 * time.Millisecond
-
 // MemoryQueue is a generic in-memory queue implementation.
+//
+// Items are kept in two heaps:
+//   - a ready heap ordered by [core.Comparable.Compare], from which Dequeue pops;
+//   - a scheduled heap ordered by scheduled time, holding items that implement
+//     [core.Schedulable] and are not due yet.
+//
+// A single timer is armed for the earliest scheduled item. When it fires, every
+// due item is promoted to the ready heap and waiting consumers are woken up, so
+// neither polling nor one goroutine per delayed item is needed.
+//
+// What happens when the queue is full is controlled by its [OverflowPolicy].
 type MemoryQueue[T core.Comparable[T]] struct {
-	items      *PriorityQueue[T]
+	items      *readyHeap[T]
+	delayed    *scheduledHeap[T]
 	mu         sync.RWMutex
 	notifyChan chan struct{}
+	spaceChan  chan struct{}
+	done       chan struct{}
 	closed     int32
 	closeOnce  sync.Once
 	maxSize    int
+
+	// timer fires when the earliest scheduled item becomes due; timerAt is the
+	// deadline it is currently armed for (zero when idle).
+	timer   *time.Timer
+	timerAt time.Time
+	seq     uint64
+
+	// wake, when set, is called whenever a consumer would be signalled. It lets
+	// composite queues (see [FairQueue]) wait on several MemoryQueues at once.
+	wake func()
+
+	policy     OverflowPolicy
+	spill      Enqueuer[T]
+	onEvict    []*evictHandler[T]
+	stats      OverflowStats
+	statsMutex sync.Mutex
 }
 
 // NewMemoryQueue creates a new in-memory queue with the specified maximum size.
-func NewMemoryQueue[T core.Comparable[T]](maxSize int) *MemoryQueue[T] {
-	pq := &PriorityQueue[T]{}
-	heap.Init(pq)
+// By default a full queue rejects new items with [ErrQueueFull]; see
+// [WithOverflowPolicy] for alternatives.
+func NewMemoryQueue[T core.Comparable[T]](maxSize int, opts ...MemoryQueueOption) *MemoryQueue[T] {
+	options := &memoryQueueOptions{policy: OverflowReject}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(options)
+		}
+	}
 
 	return &MemoryQueue[T]{
-		items:      pq,
+		items:      &readyHeap[T]{},
+		delayed:    &scheduledHeap[T]{},
 		maxSize:    maxSize,
 		notifyChan: make(chan struct{}, 1),
+		spaceChan:  make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		policy:     options.policy,
 	}
 }
 
-func (mq *MemoryQueue[T]) Enqueue(_ context.Context, item T) error {
-	if atomic.LoadInt32(&mq.closed) == 1 {
-		return ErrQueueClosed
-	}
+// Enqueue adds an item to the queue. When the queue is full the configured
+// [OverflowPolicy] decides whether the call fails, blocks, evicts or spills.
+func (mq *MemoryQueue[T]) Enqueue(ctx context.Context, item T) error {
+	for {
+		if atomic.LoadInt32(&mq.closed) == 1 {
+			return ErrQueueClosed
+		}
 
-	mq.mu.Lock()
-	defer mq.mu.Unlock()
+		mq.mu.Lock()
+		if mq.maxSize <= 0 || mq.sizeLocked() < mq.maxSize {
+			mq.pushLocked(item)
+			mq.mu.Unlock()
+			return nil
+		}
 
-	// Check capacity limit
-	if mq.maxSize > 0 && mq.items.Len() >= mq.maxSize {
-		return ErrQueueFull
+		switch mq.policy {
+		case OverflowBlock:
+			mq.mu.Unlock()
+			mq.countOverflow(func(s *OverflowStats) { s.Blocked++ })
+			if err := mq.waitForSpace(ctx); err != nil {
+				return err
+			}
+			continue
+		case OverflowEvictOldest, OverflowEvictLowest:
+			victim, ok := mq.evictLocked(item)
+			if !ok {
+				mq.mu.Unlock()
+				mq.countOverflow(func(s *OverflowStats) { s.Rejected++ })
+				return ErrQueueFull
+			}
+			mq.pushLocked(item)
+			mq.mu.Unlock()
+			mq.countOverflow(func(s *OverflowStats) { s.Evicted++ })
+			mq.notifyEvicted(victim, ErrItemEvicted)
+			return nil
+		case OverflowSpill:
+			spill := mq.spill
+			mq.mu.Unlock()
+			if spill == nil {
+				mq.countOverflow(func(s *OverflowStats) { s.Rejected++ })
+				return ErrQueueFull
+			}
+			if err := spill.Enqueue(ctx, item); err != nil {
+				mq.countOverflow(func(s *OverflowStats) { s.Rejected++ })
+				return err
+			}
+			mq.countOverflow(func(s *OverflowStats) { s.Spilled++ })
+			return nil
+		default:
+			mq.mu.Unlock()
+			mq.countOverflow(func(s *OverflowStats) { s.Rejected++ })
+			return ErrQueueFull
+		}
 	}
+}
 
-	heap.Push(mq.items, item)
-
-	// Non-blocking notification
-	select {
-	case mq.notifyChan <- struct{}{}:
-	default:
+// pushLocked stores an item in the ready or scheduled heap. Caller must hold mu.
+func (mq *MemoryQueue[T]) pushLocked(item T) {
+	mq.seq++
+	if at, ok := scheduledAt(item); ok && at.After(time.Now()) {
+		heap.Push(mq.delayed, entry[T]{item: item, at: at, seq: mq.seq})
+		if mq.timerAt.IsZero() || at.Before(mq.timerAt) {
+			mq.armTimerLocked(at)
+		}
+		return
 	}
 
-	return nil
+	heap.Push(mq.items, entry[T]{item: item, readyAt: time.Now(), seq: mq.seq})
+	mq.signal()
 }
 
-// Size returns the current number of elements in the queue.
+// Size returns the current number of elements in the queue, including
+// scheduled items that are not due yet.
 func (mq *MemoryQueue[T]) Size() int {
 	mq.mu.RLock()
 	defer mq.mu.RUnlock()
+	return mq.sizeLocked()
+}
+
+// ReadySize returns the number of elements that can be dequeued right now.
+func (mq *MemoryQueue[T]) ReadySize() int {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.promoteLocked(time.Now())
 	return mq.items.Len()
 }
 
+// DelayedSize returns the number of scheduled elements that are not due yet.
+func (mq *MemoryQueue[T]) DelayedSize() int {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.promoteLocked(time.Now())
+	return mq.delayed.Len()
+}
+
 // MaxSize returns the maximum capacity of the queue, 0 means unlimited.
 func (mq *MemoryQueue[T]) MaxSize() int {
 	return mq.maxSize
@@ -83,14 +189,14 @@ func (mq *MemoryQueue[T]) IsFull() bool {
 	}
 	mq.mu.RLock()
 	defer mq.mu.RUnlock()
-	return mq.items.Len() >= mq.maxSize
+	return mq.sizeLocked() >= mq.maxSize
 }
 
 // IsEmpty checks if the queue is empty.
 func (mq *MemoryQueue[T]) IsEmpty() bool {
 	mq.mu.RLock()
 	defer mq.mu.RUnlock()
-	return mq.items.Len() == 0
+	return mq.sizeLocked() == 0
 }
 
 func (mq *MemoryQueue[T]) EnqueueDelayed(ctx context.Context, item T, delay time.Duration) error {
@@ -107,6 +213,8 @@ func (mq *MemoryQueue[T]) EnqueueDelayed(ctx context.Context, item T, delay time
 	return mq.Enqueue(ctx, item)
 }
 
+// Dequeue blocks until a ready item is available, the context is cancelled or
+// the queue is closed.
 func (mq *MemoryQueue[T]) Dequeue(ctx context.Context) (T, error) {
 	var zero T
 
@@ -115,29 +223,18 @@ func (mq *MemoryQueue[T]) Dequeue(ctx context.Context) (T, error) {
 			return zero, ErrQueueClosed
 		}
 
-		mq.mu.Lock()
-		if mq.items.Len() > 0 {
-			// Check if the top element is ready
-			top := (*mq.items)[0]
-			if mq.isReady(top) {
-				item, ok := heap.Pop(mq.items).(T)
-				if !ok {
-					return zero, errors.New("heap pop failed")
-				}
-				mq.mu.Unlock()
-				return item, nil
-			}
+		if item, ok := mq.tryDequeue(); ok {
+			return item, nil
 		}
-		mq.mu.Unlock()
 
-		// Wait for new items or context cancellation
+		// Wait for new items, promoted items or context cancellation
 		select {
 		case <-ctx.Done():
 			return zero, ctx.Err()
+		case <-mq.done:
+			return zero, ErrQueueClosed
 		case <-mq.notifyChan:
 			// Continue checking
-		case <-time.After(notifyTimeout):
-			// Periodically check delayed items
 		}
 	}
 }
@@ -145,21 +242,164 @@ func (mq *MemoryQueue[T]) Dequeue(ctx context.Context) (T, error) {
 func (mq *MemoryQueue[T]) Close() error {
 	mq.closeOnce.Do(func() {
 		atomic.StoreInt32(&mq.closed, 1)
-		close(mq.notifyChan)
+		mq.mu.Lock()
+		if mq.timer != nil {
+			mq.timer.Stop()
+		}
+		mq.timerAt = time.Time{}
+		mq.mu.Unlock()
+		close(mq.done)
 	})
 	return nil
 }
 
-// isReady checks if an item is ready to be dequeued.
-func (mq *MemoryQueue[T]) isReady(item T) bool {
-	// If item implements Schedulable, check the scheduled time
+// sizeLocked returns the total number of ready and scheduled items. Caller must hold mu.
+func (mq *MemoryQueue[T]) sizeLocked() int {
+	return mq.items.Len() + mq.delayed.Len()
+}
+
+// signal wakes up one waiting consumer without blocking.
+func (mq *MemoryQueue[T]) signal() {
+	select {
+	case mq.notifyChan <- struct{}{}:
+	default:
+	}
+	if mq.wake != nil {
+		mq.wake()
+	}
+}
+
+// signalSpace wakes up one producer blocked on a full queue.
+func (mq *MemoryQueue[T]) signalSpace() {
+	select {
+	case mq.spaceChan <- struct{}{}:
+	default:
+	}
+}
+
+// waitForSpace blocks until an item leaves the queue, ctx is done or the queue is closed.
+func (mq *MemoryQueue[T]) waitForSpace(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-mq.done:
+		return ErrQueueClosed
+	case <-mq.spaceChan:
+		return nil
+	}
+}
+
+// tryDequeue pops the highest-priority ready item without blocking.
+func (mq *MemoryQueue[T]) tryDequeue() (T, bool) {
+	return mq.tryDequeueAged(time.Now(), 0)
+}
+
+// tryDequeueAged pops the item that has been ready the longest if it has waited
+// at least maxWait, and the highest-priority ready item otherwise. A maxWait of
+// zero disables aging.
+func (mq *MemoryQueue[T]) tryDequeueAged(now time.Time, maxWait time.Duration) (T, bool) {
+	var zero T
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.promoteLocked(now)
+	if mq.items.Len() == 0 {
+		return zero, false
+	}
+	idx := 0
+	if maxWait > 0 {
+		if oldest := mq.oldestReadyLocked(); now.Sub((*mq.items)[oldest].readyAt) >= maxWait {
+			idx = oldest
+		}
+	}
+	e, ok := heap.Remove(mq.items, idx).(entry[T])
+	if !ok {
+		return zero, false
+	}
+	// Pass the wake-ups on so that other consumers drain the remaining items
+	// and a blocked producer can use the freed slot.
+	if mq.items.Len() > 0 {
+		mq.signal()
+	}
+	mq.signalSpace()
+	return e.item, true
+}
+
+// oldestReady returns when the item that has been ready the longest became ready.
+func (mq *MemoryQueue[T]) oldestReady(now time.Time) (time.Time, bool) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.promoteLocked(now)
+	if mq.items.Len() == 0 {
+		return time.Time{}, false
+	}
+	return (*mq.items)[mq.oldestReadyLocked()].readyAt, true
+}
+
+// oldestReadyLocked returns the index of the ready entry that has been ready the
+// longest. The ready heap must not be empty. Caller must hold mu.
+func (mq *MemoryQueue[T]) oldestReadyLocked() int {
+	oldest := 0
+	for i, e := range *mq.items {
+		o := (*mq.items)[oldest]
+		if e.readyAt.Before(o.readyAt) || (e.readyAt.Equal(o.readyAt) && e.seq < o.seq) {
+			oldest = i
+		}
+	}
+	return oldest
+}
+
+// promoteLocked moves every scheduled item due at or before now to the ready heap.
+// It reports whether any item was promoted. Caller must hold mu.
+func (mq *MemoryQueue[T]) promoteLocked(now time.Time) bool {
+	promoted := false
+	for mq.delayed.Len() > 0 && !(*mq.delayed)[0].at.After(now) {
+		e, _ := heap.Pop(mq.delayed).(entry[T])
+		e.readyAt, e.at = e.at, time.Time{}
+		heap.Push(mq.items, e)
+		promoted = true
+	}
+	return promoted
+}
+
+// armTimerLocked (re)arms the promotion timer for the given deadline. Caller must hold mu.
+func (mq *MemoryQueue[T]) armTimerLocked(at time.Time) {
+	mq.timerAt = at
+	d := time.Until(at)
+	if mq.timer == nil {
+		mq.timer = time.AfterFunc(d, mq.onTimer)
+		return
+	}
+	mq.timer.Reset(d)
+}
+
+// onTimer promotes due items, re-arms the timer for the next scheduled item and
+// wakes up a consumer.
+func (mq *MemoryQueue[T]) onTimer() {
+	if atomic.LoadInt32(&mq.closed) == 1 {
+		return
+	}
+
+	mq.mu.Lock()
+	promoted := mq.promoteLocked(time.Now())
+	if mq.delayed.Len() > 0 {
+		mq.armTimerLocked((*mq.delayed)[0].at)
+	} else {
+		mq.timerAt = time.Time{}
+	}
+	if promoted || mq.items.Len() > 0 {
+		mq.signal()
+	}
+	mq.mu.Unlock()
+}
+
+// scheduledAt returns the scheduled time of an item implementing [core.Schedulable].
+func scheduledAt[T any](item T) (time.Time, bool) {
 	if schedulable, ok := any(item).(core.Schedulable); ok {
-		scheduledAt := schedulable.GetScheduledAt()
-		if scheduledAt != nil {
-			return time.Now().After(*scheduledAt)
+		if at := schedulable.GetScheduledAt(); at != nil {
+			return *at, true
 		}
 	}
-	return true // No scheduled time or not Schedulable, immediately available
+	return time.Time{}, false
 }
 
 // PriorityQueue is a priority queue implementation.
@@ -188,3 +428,81 @@ func (pq *PriorityQueue[T]) Pop() interface{} {
 	*pq = old[0 : n-1]
 	return item
 }
+
+// entry wraps a queued item with its due time, the time it became ready and an
+// insertion sequence number. seq keeps ordering stable for equal items and
+// identifies the oldest item.
+type entry[T any] struct {
+	item    T
+	at      time.Time
+	readyAt time.Time
+	seq     uint64
+}
+
+// readyHeap is a heap of ready entries ordered by [core.Comparable.Compare],
+// falling back to insertion order.
+type readyHeap[T core.Comparable[T]] []entry[T]
+
+func (h readyHeap[T]) Len() int { return len(h) }
+
+func (h readyHeap[T]) Less(i, j int) bool {
+	if h[i].item.Compare(h[j].item) {
+		return true
+	}
+	if h[j].item.Compare(h[i].item) {
+		return false
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h readyHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *readyHeap[T]) Push(x interface{}) {
+	if e, ok := x.(entry[T]); ok {
+		*h = append(*h, e)
+	}
+}
+
+func (h *readyHeap[T]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	var zero entry[T]
+	old[n-1] = zero
+	*h = old[0 : n-1]
+	return e
+}
+
+// scheduledHeap is a min-heap of scheduled entries ordered by due time.
+type scheduledHeap[T any] []entry[T]
+
+func (h scheduledHeap[T]) Len() int { return len(h) }
+
+func (h scheduledHeap[T]) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+func (h scheduledHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *scheduledHeap[T]) Push(x interface{}) {
+	if e, ok := x.(entry[T]); ok {
+		*h = append(*h, e)
+	}
+}
+
+func (h *scheduledHeap[T]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	var zero entry[T]
+	old[n-1] = zero
+	*h = old[0 : n-1]
+	return e
+}
@@ -0,0 +1,82 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/queue"
+)
+
+const pendingDelayed = 100_000
+
+// newLoadedQueue returns a queue holding pendingDelayed items scheduled far in the future.
+func newLoadedQueue(b *testing.B) *queue.MemoryQueue[*testItem] {
+	b.Helper()
+	q := queue.NewMemoryQueue[*testItem](0)
+	for i := range pendingDelayed {
+		if err := q.EnqueueDelayed(context.Background(), &testItem{id: -i - 1}, time.Hour+time.Duration(i)); err != nil {
+			b.Fatalf("enqueue delayed failed: %v", err)
+		}
+	}
+	return q
+}
+
+// BenchmarkMemoryQueue_ReadyWith100kDelayed measures enqueue+dequeue of ready
+// items while 100k delayed items are pending.
+func BenchmarkMemoryQueue_ReadyWith100kDelayed(b *testing.B) {
+	q := newLoadedQueue(b)
+	defer q.Close()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		if err := q.Enqueue(ctx, &testItem{id: i}); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := q.Dequeue(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMemoryQueue_EnqueueDelayedWith100kPending measures the cost of
+// scheduling one more item on top of 100k pending ones.
+func BenchmarkMemoryQueue_EnqueueDelayedWith100kPending(b *testing.B) {
+	q := newLoadedQueue(b)
+	defer q.Close()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		if err := q.EnqueueDelayed(ctx, &testItem{id: i}, 2*time.Hour); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMemoryQueue_PromotionLatency measures how late a delayed item is
+// handed to a blocked consumer while 100k other delayed items are pending.
+// The lateness is reported as the custom "late-ns/op" metric.
+func BenchmarkMemoryQueue_PromotionLatency(b *testing.B) {
+	q := newLoadedQueue(b)
+	defer q.Close()
+	ctx := context.Background()
+	const delay = time.Millisecond
+
+	var late time.Duration
+	b.ResetTimer()
+	for i := range b.N {
+		due := time.Now().Add(delay)
+		if err := q.EnqueueDelayed(ctx, &testItem{id: i}, delay); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := q.Dequeue(ctx); err != nil {
+			b.Fatal(err)
+		}
+		late += time.Since(due)
+	}
+	b.ReportMetric(float64(late.Nanoseconds())/float64(b.N), "late-ns/op")
+}
@@ -0,0 +1,51 @@
+//go:build unix
+
+package queue_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// cpuTime returns the user and system CPU time consumed by the process so far.
+func cpuTime(b *testing.B) time.Duration {
+	b.Helper()
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		b.Fatalf("getrusage failed: %v", err)
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
+
+// BenchmarkMemoryQueue_IdleCPUWith100kDelayed keeps a consumer blocked while 100k
+// delayed items are pending, and reports the CPU the process burns meanwhile:
+// "cpu-ns/op" per 10ms of idling and "cpu-%" of one core. Both the timer-driven
+// queue and the former polling one idle at well under 1% of a core with 100k
+// delayed items, so this benchmark guards against regressions rather than
+// showing the gain of timers. BenchmarkMemoryQueue_PromotionLatency shows it: a
+// polling queue hands a due item out up to a poll interval late.
+func BenchmarkMemoryQueue_IdleCPUWith100kDelayed(b *testing.B) {
+	q := newLoadedQueue(b)
+	defer q.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_, _ = q.Dequeue(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond) // let the consumer block
+
+	const idle = 10 * time.Millisecond
+	b.ResetTimer()
+	startCPU, start := cpuTime(b), time.Now()
+	for range b.N {
+		time.Sleep(idle)
+	}
+	cpu, wall := cpuTime(b)-startCPU, time.Since(start)
+	b.StopTimer()
+
+	b.ReportMetric(float64(cpu.Nanoseconds())/float64(b.N), "cpu-ns/op")
+	b.ReportMetric(100*cpu.Seconds()/wall.Seconds(), "cpu-%")
+}
@@ -127,3 +127,60 @@ func TestMemoryQueue_Getters(t *testing.T) {
 		t.Error("size after dequeue should be 1")
 	}
 }
+
+func TestMemoryQueue_DelayedDoesNotBlockReady(t *testing.T) {
+	q := queue.NewMemoryQueue[*testItem](0)
+	// id 0 has the highest priority but is scheduled in the future.
+	_ = q.EnqueueDelayed(context.Background(), &testItem{id: 0}, time.Hour)
+	_ = q.Enqueue(context.Background(), &testItem{id: 5})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	itemOut, err := q.Dequeue(ctx)
+	if err != nil || itemOut.id != 5 {
+		t.Fatalf("expected ready item 5, got %v, err=%v", itemOut, err)
+	}
+	if q.Size() != 1 || q.DelayedSize() != 1 || q.ReadySize() != 0 {
+		t.Errorf("unexpected sizes: size=%d delayed=%d ready=%d", q.Size(), q.DelayedSize(), q.ReadySize())
+	}
+}
+
+func TestMemoryQueue_DelayedWakesConsumer(t *testing.T) {
+	q := queue.NewMemoryQueue[*testItem](0)
+	defer q.Close()
+
+	start := time.Now()
+	_ = q.EnqueueDelayed(context.Background(), &testItem{id: 2}, 40*time.Millisecond)
+	_ = q.EnqueueDelayed(context.Background(), &testItem{id: 1}, 20*time.Millisecond)
+
+	first, err := q.Dequeue(context.Background())
+	if err != nil || first.id != 1 {
+		t.Fatalf("expected item 1 first, got %v, err=%v", first, err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > 60*time.Millisecond {
+		t.Errorf("item 1 promoted after %v, want ~20ms", elapsed)
+	}
+	second, err := q.Dequeue(context.Background())
+	if err != nil || second.id != 2 {
+		t.Fatalf("expected item 2 second, got %v, err=%v", second, err)
+	}
+}
+
+func TestMemoryQueue_CloseWakesConsumer(t *testing.T) {
+	q := queue.NewMemoryQueue[*testItem](0)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := q.Dequeue(context.Background())
+		errCh <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_ = q.Close()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, queue.ErrQueueClosed) {
+			t.Errorf("expected ErrQueueClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("consumer not woken by Close")
+	}
+}
@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"container/heap"
+	"context"
+	"slices"
+)
+
+// OverflowPolicy decides what [MemoryQueue.Enqueue] does when the queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowReject fails the enqueue with [ErrQueueFull]. This is the default.
+	OverflowReject OverflowPolicy = iota
+	// OverflowBlock waits until an item leaves the queue, the context is done or
+	// the queue is closed.
+	OverflowBlock
+	// OverflowEvictOldest removes the item that has been queued the longest to make room.
+	OverflowEvictOldest
+	// OverflowEvictLowest removes the item with the lowest priority to make room. If
+	// the new item would itself be the lowest, it is rejected with [ErrQueueFull].
+	OverflowEvictLowest
+	// OverflowSpill hands the item to the secondary queue configured with [MemoryQueue.SetSpillQueue].
+	OverflowSpill
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowReject:
+		return "reject"
+	case OverflowBlock:
+		return "block"
+	case OverflowEvictOldest:
+		return "evict_oldest"
+	case OverflowEvictLowest:
+		return "evict_lowest"
+	case OverflowSpill:
+		return "spill"
+	default:
+		return "unknown"
+	}
+}
+
+// Enqueuer is the minimal interface of a secondary queue used by [OverflowSpill].
+// Any core.Queue satisfies it for T = *core.QueueItem.
+type Enqueuer[T any] interface {
+	Enqueue(ctx context.Context, item T) error
+}
+
+// OverflowStats counts what happened to enqueues that hit a full queue.
+type OverflowStats struct {
+	// Rejected is the number of items refused with an error (dropped).
+	Rejected uint64 `json:"rejected"`
+	// Evicted is the number of queued items removed to make room for newer ones.
+	Evicted uint64 `json:"evicted"`
+	// Spilled is the number of items handed to the secondary queue.
+	Spilled uint64 `json:"spilled"`
+	// Blocked is the number of times a producer had to wait for space.
+	Blocked uint64 `json:"blocked"`
+}
+
+// MemoryQueueOption configures a [MemoryQueue].
+type MemoryQueueOption func(*memoryQueueOptions)
+
+type memoryQueueOptions struct {
+	policy OverflowPolicy
+}
+
+// WithOverflowPolicy sets the policy applied when the queue is full.
+// Use [MemoryQueue.SetSpillQueue] to configure [OverflowSpill].
+func WithOverflowPolicy(policy OverflowPolicy) MemoryQueueOption {
+	return func(o *memoryQueueOptions) {
+		o.policy = policy
+	}
+}
+
+// SetSpillQueue sets the secondary queue that receives items while the queue is
+// full and switches the overflow policy to [OverflowSpill].
+func (mq *MemoryQueue[T]) SetSpillQueue(spill Enqueuer[T]) {
+	mq.mu.Lock()
+	defer mq.mu.Unlock()
+	mq.spill = spill
+	mq.policy = OverflowSpill
+}
+
+// evictHandler wraps a handler registered with OnEvict, so that it can be found
+// again when unregistered.
+type evictHandler[T any] struct {
+	fn func(item T, err error)
+}
+
+// OnEvict registers a handler invoked, outside the queue lock, for every item
+// evicted by [OverflowEvictOldest] or [OverflowEvictLowest], with [ErrItemEvicted],
+// and for every item removed by Purge, with [ErrItemPurged]. It returns a
+// function that unregisters the handler.
+func (mq *MemoryQueue[T]) OnEvict(fn func(item T, err error)) (unsubscribe func()) {
+	if fn == nil {
+		return func() {}
+	}
+	handler := &evictHandler[T]{fn: fn}
+	mq.statsMutex.Lock()
+	defer mq.statsMutex.Unlock()
+	mq.onEvict = append(mq.onEvict, handler)
+	return func() {
+		mq.statsMutex.Lock()
+		defer mq.statsMutex.Unlock()
+		mq.onEvict = slices.DeleteFunc(mq.onEvict, func(h *evictHandler[T]) bool { return h == handler })
+	}
+}
+
+// OverflowPolicy returns the configured overflow policy.
+func (mq *MemoryQueue[T]) OverflowPolicy() OverflowPolicy {
+	mq.mu.RLock()
+	defer mq.mu.RUnlock()
+	return mq.policy
+}
+
+// Stats returns the overflow counters.
+func (mq *MemoryQueue[T]) Stats() OverflowStats {
+	mq.statsMutex.Lock()
+	defer mq.statsMutex.Unlock()
+	return mq.stats
+}
+
+func (mq *MemoryQueue[T]) countOverflow(update func(*OverflowStats)) {
+	mq.statsMutex.Lock()
+	defer mq.statsMutex.Unlock()
+	update(&mq.stats)
+}
+
+func (mq *MemoryQueue[T]) notifyEvicted(item T, err error) {
+	mq.statsMutex.Lock()
+	handlers := slices.Clone(mq.onEvict)
+	mq.statsMutex.Unlock()
+
+	for _, h := range handlers {
+		h.fn(item, err)
+	}
+}
+
+// evictLocked removes the victim chosen by the overflow policy to make room for
+// incoming. It returns false when nothing should be evicted. Caller must hold mu.
+func (mq *MemoryQueue[T]) evictLocked(incoming T) (T, bool) {
+	var zero T
+
+	// Locate the victim in either heap.
+	fromReady, idx := false, -1
+	var victim entry[T]
+	consider := func(e entry[T], ready bool, i int) {
+		if idx < 0 || mq.worseVictim(e, victim) {
+			victim, fromReady, idx = e, ready, i
+		}
+	}
+	for i, e := range *mq.items {
+		consider(e, true, i)
+	}
+	for i, e := range *mq.delayed {
+		consider(e, false, i)
+	}
+	if idx < 0 {
+		return zero, false
+	}
+	if mq.policy == OverflowEvictLowest && !incoming.Compare(victim.item) {
+		return zero, false
+	}
+
+	if fromReady {
+		heap.Remove(mq.items, idx)
+	} else {
+		heap.Remove(mq.delayed, idx)
+	}
+	return victim.item, true
+}
+
+// worseVictim reports whether a is a better eviction candidate than b under the current policy.
+func (mq *MemoryQueue[T]) worseVictim(a, b entry[T]) bool {
+	if mq.policy == OverflowEvictOldest {
+		return a.seq < b.seq
+	}
+	// OverflowEvictLowest: the item that sorts last; among equals, the newest.
+	if b.item.Compare(a.item) {
+		return true
+	}
+	if a.item.Compare(b.item) {
+		return false
+	}
+	return a.seq > b.seq
+}
@@ -0,0 +1,117 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/queue"
+)
+
+func TestMemoryQueue_OverflowBlock(t *testing.T) {
+	q := queue.NewMemoryQueue[*testItem](1, queue.WithOverflowPolicy(queue.OverflowBlock))
+	defer q.Close()
+	_ = q.Enqueue(context.Background(), &testItem{id: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Enqueue(ctx, &testItem{id: 2}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected blocked enqueue to honour ctx, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- q.Enqueue(context.Background(), &testItem{id: 3}) }()
+	time.Sleep(10 * time.Millisecond)
+	if _, err := q.Dequeue(context.Background()); err != nil {
+		t.Fatalf("dequeue failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("blocked enqueue should succeed once space frees, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked enqueue not released")
+	}
+	if q.Stats().Blocked == 0 {
+		t.Error("expected Blocked counter to be incremented")
+	}
+}
+
+func TestMemoryQueue_OverflowEvictOldest(t *testing.T) {
+	q := queue.NewMemoryQueue[*testItem](2, queue.WithOverflowPolicy(queue.OverflowEvictOldest))
+	var evicted []int
+	q.OnEvict(func(item *testItem, err error) {
+		if !errors.Is(err, queue.ErrItemEvicted) {
+			t.Errorf("unexpected eviction error %v", err)
+		}
+		evicted = append(evicted, item.id)
+	})
+
+	_ = q.Enqueue(context.Background(), &testItem{id: 5})
+	_ = q.Enqueue(context.Background(), &testItem{id: 1})
+	if err := q.Enqueue(context.Background(), &testItem{id: 9}); err != nil {
+		t.Fatalf("enqueue should evict instead of failing, got %v", err)
+	}
+	if len(evicted) != 1 || evicted[0] != 5 {
+		t.Errorf("expected oldest item 5 evicted, got %v", evicted)
+	}
+	if q.Size() != 2 || q.Stats().Evicted != 1 {
+		t.Errorf("unexpected size %d / stats %+v", q.Size(), q.Stats())
+	}
+}
+
+func TestMemoryQueue_OnEvictUnsubscribe(t *testing.T) {
+	q := queue.NewMemoryQueue[*testItem](1, queue.WithOverflowPolicy(queue.OverflowEvictOldest))
+	var kept, dropped int
+	q.OnEvict(func(*testItem, error) { kept++ })
+	unsubscribe := q.OnEvict(func(*testItem, error) { dropped++ })
+	unsubscribe()
+	unsubscribe()
+
+	_ = q.Enqueue(context.Background(), &testItem{id: 1})
+	_ = q.Enqueue(context.Background(), &testItem{id: 2})
+	if kept != 1 || dropped != 0 {
+		t.Errorf("expected only the remaining handler to run, got %d and %d calls", kept, dropped)
+	}
+}
+
+func TestMemoryQueue_OverflowEvictLowest(t *testing.T) {
+	q := queue.NewMemoryQueue[*testItem](2, queue.WithOverflowPolicy(queue.OverflowEvictLowest))
+	var evicted []int
+	q.OnEvict(func(item *testItem, _ error) { evicted = append(evicted, item.id) })
+
+	_ = q.Enqueue(context.Background(), &testItem{id: 1})
+	_ = q.EnqueueDelayed(context.Background(), &testItem{id: 8}, time.Hour)
+	if err := q.Enqueue(context.Background(), &testItem{id: 3}); err != nil {
+		t.Fatalf("enqueue should evict lowest, got %v", err)
+	}
+	if len(evicted) != 1 || evicted[0] != 8 {
+		t.Errorf("expected lowest item 8 evicted, got %v", evicted)
+	}
+	// An item lower than everything queued is rejected instead.
+	if err := q.Enqueue(context.Background(), &testItem{id: 99}); !errors.Is(err, queue.ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull for lowest incoming item, got %v", err)
+	}
+	if q.Stats().Rejected != 1 {
+		t.Errorf("expected 1 rejection, got %+v", q.Stats())
+	}
+}
+
+func TestMemoryQueue_OverflowSpill(t *testing.T) {
+	secondary := queue.NewMemoryQueue[*testItem](0)
+	q := queue.NewMemoryQueue[*testItem](1)
+	q.SetSpillQueue(secondary)
+
+	_ = q.Enqueue(context.Background(), &testItem{id: 1})
+	if err := q.Enqueue(context.Background(), &testItem{id: 2}); err != nil {
+		t.Fatalf("enqueue should spill, got %v", err)
+	}
+	if q.Size() != 1 || secondary.Size() != 1 || q.Stats().Spilled != 1 {
+		t.Errorf("unexpected sizes %d/%d stats %+v", q.Size(), secondary.Size(), q.Stats())
+	}
+	if q.OverflowPolicy() != queue.OverflowSpill {
+		t.Errorf("expected spill policy, got %v", q.OverflowPolicy())
+	}
+}
@@ -0,0 +1,175 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// Defaults of AIMDRateLimiter.
+const (
+	DefaultAIMDIncrease = 0.1             // requests per second added per successful send
+	DefaultAIMDDecrease = 0.5             // factor the rate is multiplied by when throttled
+	DefaultAIMDCooldown = 1 * time.Second // minimum time between two rate cuts
+)
+
+// AIMDRateLimiter is a token bucket that learns the rate a vendor sustains:
+// every successful send raises the rate by a constant (additive increase), and
+// every send the vendor throttles (see core.IsThrottled) multiplies it by a
+// factor below one (multiplicative decrease). Other errors leave it unchanged.
+//
+// It implements core.AdaptiveRateLimiter. Give every account its own instance
+// through an AccountGuard to learn their rates separately:
+//
+//	guard := core.NewAccountGuard(core.WithAccountLimiters(func(string) core.RateLimiter {
+//		return ratelimiter.NewAIMDRateLimiter(5)
+//	}))
+type AIMDRateLimiter struct {
+	mu       sync.Mutex
+	limiter  *rate.Limiter
+	rate     float64
+	minRate  float64
+	maxRate  float64
+	increase float64
+	decrease float64
+	cooldown time.Duration
+	burst    int
+	lastCut  time.Time
+}
+
+var (
+	_ core.AdaptiveRateLimiter  = (*AIMDRateLimiter)(nil)
+	_ core.ReservingRateLimiter = (*AIMDRateLimiter)(nil)
+	_ core.BurstLimiter         = (*AIMDRateLimiter)(nil)
+)
+
+// AIMDOption configures an AIMDRateLimiter.
+type AIMDOption func(*AIMDRateLimiter)
+
+// WithRateBounds keeps the rate, in requests per second, between lower and upper.
+// By default it stays between a hundredth and a hundred times the initial rate.
+func WithRateBounds(lower, upper float64) AIMDOption {
+	return func(l *AIMDRateLimiter) {
+		if lower > 0 && upper >= lower {
+			l.minRate, l.maxRate = lower, upper
+		}
+	}
+}
+
+// WithAdditiveIncrease sets the requests per second added after each successful send.
+func WithAdditiveIncrease(step float64) AIMDOption {
+	return func(l *AIMDRateLimiter) {
+		if step > 0 {
+			l.increase = step
+		}
+	}
+}
+
+// WithMultiplicativeDecrease sets the factor, between 0 and 1, the rate is
+// multiplied by when the vendor throttles a send.
+func WithMultiplicativeDecrease(factor float64) AIMDOption {
+	return func(l *AIMDRateLimiter) {
+		if factor > 0 && factor < 1 {
+			l.decrease = factor
+		}
+	}
+}
+
+// WithDecreaseCooldown sets the minimum time between two rate cuts, so that the
+// sends already in flight when the vendor starts throttling cut the rate once
+// rather than once each.
+func WithDecreaseCooldown(d time.Duration) AIMDOption {
+	return func(l *AIMDRateLimiter) {
+		if d >= 0 {
+			l.cooldown = d
+		}
+	}
+}
+
+// WithAIMDBurst sets how many requests may pass at once, one by default.
+func WithAIMDBurst(burst int) AIMDOption {
+	return func(l *AIMDRateLimiter) {
+		if burst > 0 {
+			l.burst = burst
+		}
+	}
+}
+
+// NewAIMDRateLimiter creates an AIMDRateLimiter starting at initial requests per
+// second.
+func NewAIMDRateLimiter(initial float64, opts ...AIMDOption) *AIMDRateLimiter {
+	l := &AIMDRateLimiter{
+		rate:     initial,
+		minRate:  initial / 100, //nolint:mnd // default bounds span four orders of magnitude
+		maxRate:  initial * 100, //nolint:mnd // default bounds span four orders of magnitude
+		increase: DefaultAIMDIncrease,
+		decrease: DefaultAIMDDecrease,
+		cooldown: DefaultAIMDCooldown,
+		burst:    1,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.rate = min(max(l.rate, l.minRate), l.maxRate)
+	l.limiter = rate.NewLimiter(rate.Limit(l.rate), l.burst)
+	return l
+}
+
+// Observe adapts the rate to the outcome of a send, see core.AdaptiveRateLimiter.
+func (l *AIMDRateLimiter) Observe(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		l.rate = min(l.rate+l.increase, l.maxRate)
+	case core.IsThrottled(err):
+		if !l.lastCut.IsZero() && now.Sub(l.lastCut) < l.cooldown {
+			return
+		}
+		l.rate = max(l.rate*l.decrease, l.minRate)
+		l.lastCut = now
+	default:
+		return
+	}
+	l.limiter.SetLimitAt(now, rate.Limit(l.rate))
+}
+
+// Rate returns the current rate in requests per second.
+func (l *AIMDRateLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Allow checks if a request is allowed to pass.
+func (l *AIMDRateLimiter) Allow() bool {
+	return l.limiter.Allow()
+}
+
+// Wait waits until a request can pass.
+func (l *AIMDRateLimiter) Wait(ctx context.Context) error {
+	return l.limiter.Wait(ctx)
+}
+
+// ReserveN reserves n tokens, see core.ReservingRateLimiter. The reservation is
+// not OK when n exceeds the burst.
+func (l *AIMDRateLimiter) ReserveN(n int) core.Reservation {
+	now := time.Now()
+	return &tokenBucketReservation{Reservation: l.limiter.ReserveN(now, n), at: now}
+}
+
+// Burst returns the burst size, see core.BurstLimiter.
+func (l *AIMDRateLimiter) Burst() int {
+	return l.limiter.Burst()
+}
+
+// Close shuts down the rate limiter (no-op).
+func (l *AIMDRateLimiter) Close() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package ratelimiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/ratelimiter"
+)
+
+func TestAIMDRateLimiter_AdaptsToThrottling(t *testing.T) {
+	l := ratelimiter.NewAIMDRateLimiter(10,
+		ratelimiter.WithAdditiveIncrease(1),
+		ratelimiter.WithMultiplicativeDecrease(0.5),
+		ratelimiter.WithDecreaseCooldown(0),
+	)
+
+	l.Observe(nil)
+	if r := l.Rate(); r != 11 {
+		t.Errorf("success should add 1, rate = %v", r)
+	}
+	l.Observe(core.ThrottledError{Err: errors.New("HTTP 429")})
+	if r := l.Rate(); r != 5.5 {
+		t.Errorf("throttling should halve the rate, rate = %v", r)
+	}
+	l.Observe(errors.New("invalid template"))
+	if r := l.Rate(); r != 5.5 {
+		t.Errorf("other errors should not change the rate, rate = %v", r)
+	}
+}
+
+func TestAIMDRateLimiter_CooldownAndBounds(t *testing.T) {
+	l := ratelimiter.NewAIMDRateLimiter(8,
+		ratelimiter.WithRateBounds(3, 9),
+		ratelimiter.WithAdditiveIncrease(5),
+		ratelimiter.WithDecreaseCooldown(time.Hour),
+	)
+	throttled := core.NewSenderError(core.ErrCodeProviderRateLimited, "slow down", nil)
+
+	l.Observe(throttled)
+	l.Observe(throttled)
+	if r := l.Rate(); r != 4 {
+		t.Errorf("throttling within the cooldown should cut once, rate = %v", r)
+	}
+	l.Observe(nil)
+	l.Observe(nil)
+	if r := l.Rate(); r != 9 {
+		t.Errorf("the rate should stop at the upper bound, rate = %v", r)
+	}
+}
+
+func TestAIMDRateLimiter_LearnsPerAccount(t *testing.T) {
+	guard := core.NewAccountGuard(core.WithAccountLimiters(func(string) core.RateLimiter {
+		return ratelimiter.NewAIMDRateLimiter(10, ratelimiter.WithDecreaseCooldown(0))
+	}))
+	main := &core.BaseAccount{AccountMeta: core.AccountMeta{Name: "main"}}
+	backup := &core.BaseAccount{AccountMeta: core.AccountMeta{Name: "backup"}}
+
+	_ = guard.Execute(context.Background(), main, func() error {
+		return core.ThrottledError{Err: errors.New("errcode 130101")}
+	})
+	_ = guard.Execute(context.Background(), backup, func() error { return nil })
+
+	if r := guard.Limiter(main).(*ratelimiter.AIMDRateLimiter).Rate(); r != 5 {
+		t.Errorf("main was throttled, rate = %v", r)
+	}
+	if r := guard.Limiter(backup).(*ratelimiter.AIMDRateLimiter).Rate(); r <= 10 {
+		t.Errorf("backup succeeded, rate = %v", r)
+	}
+}
@@ -0,0 +1,302 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// DefaultIdleTTL is how long a keyed limiter may go unused before it is evicted.
+const DefaultIdleTTL = 10 * time.Minute
+
+// KeyFunc returns the key a dimension limits a send by, e.g. its recipient. An
+// empty key exempts the send from the dimension.
+type KeyFunc func(req *core.RateLimitRequest) string
+
+// ProviderKey keys sends by provider type.
+func ProviderKey(req *core.RateLimitRequest) string { return string(req.Provider) }
+
+// AccountKey keys sends by "provider/account". Only sends with an account chosen
+// through core.WithSendAccount have one; limit the accounts the provider picks
+// itself with an AccountGuard or vendor quotas instead.
+func AccountKey(req *core.RateLimitRequest) string {
+	if req.Account == "" {
+		return ""
+	}
+	return string(req.Provider) + "/" + req.Account
+}
+
+// RecipientKey keys sends by the recipient of the message (see core.RoutingKeyer).
+func RecipientKey(req *core.RateLimitRequest) string { return req.Recipient }
+
+// MetadataKey keys sends by the value of a SendOptions Metadata entry, such as
+// "tenant_id".
+func MetadataKey(field string) KeyFunc {
+	return func(req *core.RateLimitRequest) string {
+		v, ok := req.Metadata[field]
+		if !ok || v == nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// KeyedRateLimiter holds one limiter per key, created on first use. Limiters idle
+// for longer than the idle TTL are evicted, so that keys such as phone numbers
+// do not pile up. The TTL should exceed the longest window of the limiters,
+// otherwise a key could start afresh while still limited.
+type KeyedRateLimiter struct {
+	mu         sync.Mutex
+	newLimiter func(key string) core.ReservingRateLimiter
+	idleTTL    time.Duration
+	entries    map[string]*keyedEntry
+	lastSweep  time.Time
+}
+
+type keyedEntry struct {
+	limiter  core.ReservingRateLimiter
+	lastUsed time.Time
+}
+
+// NewKeyedRateLimiter creates a KeyedRateLimiter. A non-positive idleTTL uses
+// DefaultIdleTTL.
+func NewKeyedRateLimiter(newLimiter func(key string) core.ReservingRateLimiter, idleTTL time.Duration) *KeyedRateLimiter {
+	if idleTTL <= 0 {
+		idleTTL = DefaultIdleTTL
+	}
+	return &KeyedRateLimiter{
+		newLimiter: newLimiter,
+		idleTTL:    idleTTL,
+		entries:    make(map[string]*keyedEntry),
+		lastSweep:  time.Now(),
+	}
+}
+
+// Get returns the limiter of key, creating it if needed. It returns nil when the
+// factory has no limiter for key.
+func (k *KeyedRateLimiter) Get(key string) core.ReservingRateLimiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	k.sweep(now)
+	entry, ok := k.entries[key]
+	if !ok {
+		limiter := k.newLimiter(key)
+		if limiter == nil {
+			return nil
+		}
+		entry = &keyedEntry{limiter: limiter}
+		k.entries[key] = entry
+	}
+	entry.lastUsed = now
+	return entry.limiter
+}
+
+// Len returns the number of limiters held.
+func (k *KeyedRateLimiter) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sweep(time.Now())
+	return len(k.entries)
+}
+
+// Close closes and drops every limiter.
+func (k *KeyedRateLimiter) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, entry := range k.entries {
+		_ = entry.limiter.Close()
+	}
+	clear(k.entries)
+	return nil
+}
+
+// sweep evicts idle limiters, at most twice per idle TTL.
+func (k *KeyedRateLimiter) sweep(now time.Time) {
+	if now.Sub(k.lastSweep) < k.idleTTL/2 {
+		return
+	}
+	for key, entry := range k.entries {
+		if now.Sub(entry.lastUsed) > k.idleTTL {
+			_ = entry.limiter.Close()
+			delete(k.entries, key)
+		}
+	}
+	k.lastSweep = now
+}
+
+// dimension is one limit of a CompositeRateLimiter.
+type dimension struct {
+	name     string
+	key      KeyFunc // nil for a single, unkeyed limiter
+	global   core.ReservingRateLimiter
+	limiters *KeyedRateLimiter
+}
+
+func (d *dimension) limiter(req *core.RateLimitRequest) (core.ReservingRateLimiter, string) {
+	if d.key == nil {
+		return d.global, ""
+	}
+	key := d.key(req)
+	if key == "" {
+		return nil, ""
+	}
+	return d.limiters.Get(key), key
+}
+
+// CompositeRateLimiter limits sends along several dimensions at once, such as
+// globally, per provider, per recipient and per tenant. A send passes only if
+// every dimension applying to it grants its tokens; otherwise none are taken and
+// the send fails with a *core.RateLimitRejection naming the dimension.
+//
+// Used as SenderMiddleware.RateLimiter, it receives the message and its
+// SendOptions (see core.RequestRateLimiter). Called through the plain
+// RateLimiter methods, it only applies the unkeyed dimensions.
+//
+// The reservations of the limiters of this package can be undone entirely;
+// dimension limiters returning plain *rate.Reservation values may keep tokens
+// granted at once when another dimension rejects the send.
+type CompositeRateLimiter struct {
+	mu         sync.Mutex
+	dimensions []*dimension
+	idleTTL    time.Duration
+}
+
+var _ core.RequestRateLimiter = (*CompositeRateLimiter)(nil)
+
+// CompositeOption configures a CompositeRateLimiter.
+type CompositeOption func(*CompositeRateLimiter)
+
+// WithGlobalLimit adds an unkeyed dimension named name, limiting all sends with limiter.
+func WithGlobalLimit(name string, limiter core.ReservingRateLimiter) CompositeOption {
+	return func(c *CompositeRateLimiter) {
+		c.dimensions = append(c.dimensions, &dimension{name: name, global: limiter})
+	}
+}
+
+// WithKeyedLimit adds a dimension named name, limiting the sends of each key
+// with their own limiter from newLimiter. newLimiter may return nil to leave a
+// key unlimited.
+func WithKeyedLimit(name string, key KeyFunc, newLimiter func(key string) core.ReservingRateLimiter) CompositeOption {
+	return func(c *CompositeRateLimiter) {
+		c.dimensions = append(c.dimensions, &dimension{name: name, key: key, limiters: NewKeyedRateLimiter(newLimiter, 0)})
+	}
+}
+
+// WithIdleTTL sets how long keyed limiters may go unused before they are
+// evicted, DefaultIdleTTL by default.
+func WithIdleTTL(ttl time.Duration) CompositeOption {
+	return func(c *CompositeRateLimiter) {
+		c.idleTTL = ttl
+	}
+}
+
+// NewCompositeRateLimiter creates a CompositeRateLimiter. Dimensions are checked
+// in the order they are given.
+func NewCompositeRateLimiter(opts ...CompositeOption) *CompositeRateLimiter {
+	c := &CompositeRateLimiter{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.idleTTL > 0 {
+		for _, d := range c.dimensions {
+			if d.limiters != nil {
+				d.limiters.idleTTL = c.idleTTL
+			}
+		}
+	}
+	return c
+}
+
+// ReserveRequest reserves req.Tokens in every dimension applying to req, or in
+// none, see core.RequestRateLimiter.
+func (c *CompositeRateLimiter) ReserveRequest(req *core.RateLimitRequest, maxWait time.Duration) (core.Reservation, error) {
+	n := max(req.Tokens, 1)
+
+	// Reserving under one lock keeps concurrent sends from each taking the
+	// last tokens of a different dimension and then all failing.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reservation := &compositeReservation{}
+	for _, d := range c.dimensions {
+		limiter, key := d.limiter(req)
+		if limiter == nil {
+			continue
+		}
+		r := limiter.ReserveN(n)
+		if !r.OK() {
+			reservation.Cancel()
+			return nil, &core.RateLimitRejection{Dimension: d.name, Key: key}
+		}
+		if delay := r.Delay(); maxWait >= 0 && delay > maxWait {
+			r.Cancel()
+			reservation.Cancel()
+			return nil, &core.RateLimitRejection{Dimension: d.name, Key: key, Delay: delay}
+		}
+		reservation.parts = append(reservation.parts, r)
+	}
+	return reservation, nil
+}
+
+// Allow takes a token from every unkeyed dimension, or from none.
+func (c *CompositeRateLimiter) Allow() bool {
+	_, err := c.ReserveRequest(&core.RateLimitRequest{}, 0)
+	return err == nil
+}
+
+// Wait waits for a token from every unkeyed dimension.
+func (c *CompositeRateLimiter) Wait(ctx context.Context) error {
+	r, err := c.ReserveRequest(&core.RateLimitRequest{}, -1)
+	if err != nil {
+		return err
+	}
+	timer := time.NewTimer(r.Delay())
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Close closes every limiter of every dimension.
+func (c *CompositeRateLimiter) Close() error {
+	for _, d := range c.dimensions {
+		if d.limiters != nil {
+			_ = d.limiters.Close()
+		} else {
+			_ = d.global.Close()
+		}
+	}
+	return nil
+}
+
+// compositeReservation holds the reservations of every dimension of a send.
+type compositeReservation struct {
+	parts []core.Reservation
+}
+
+func (v *compositeReservation) OK() bool { return true }
+
+// Delay returns the longest delay of the dimensions.
+func (v *compositeReservation) Delay() time.Duration {
+	var delay time.Duration
+	for _, r := range v.parts {
+		delay = max(delay, r.Delay())
+	}
+	return delay
+}
+
+func (v *compositeReservation) Cancel() {
+	for _, r := range v.parts {
+		r.Cancel()
+	}
+	v.parts = nil
+}
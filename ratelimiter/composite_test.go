@@ -0,0 +1,108 @@
+package ratelimiter_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/ratelimiter"
+)
+
+func perKey(limit int) func(string) core.ReservingRateLimiter {
+	return func(string) core.ReservingRateLimiter {
+		limiter, _ := ratelimiter.NewSlidingWindowRateLimiter(time.Minute, limit)
+		return limiter
+	}
+}
+
+func TestCompositeRateLimiter_ReportsRejectingDimension(t *testing.T) {
+	c := ratelimiter.NewCompositeRateLimiter(
+		ratelimiter.WithGlobalLimit("global", ratelimiter.NewTokenBucketRateLimiter(0.001, 10)),
+		ratelimiter.WithKeyedLimit("recipient", ratelimiter.RecipientKey, perKey(1)),
+		ratelimiter.WithKeyedLimit("tenant", ratelimiter.MetadataKey("tenant_id"), perKey(5)),
+	)
+	req := &core.RateLimitRequest{Recipient: "+8613800138000", Metadata: map[string]interface{}{"tenant_id": 7}}
+
+	if _, err := c.ReserveRequest(req, 0); err != nil {
+		t.Fatalf("first send should pass: %v", err)
+	}
+	_, err := c.ReserveRequest(req, 0)
+	var rejection *core.RateLimitRejection
+	if !errors.As(err, &rejection) || rejection.Dimension != "recipient" || rejection.Key != "+8613800138000" {
+		t.Fatalf("expected a recipient rejection, got %v", err)
+	}
+	if rejection.Delay <= 0 {
+		t.Errorf("a full window should report when it frees up, got %v", rejection.Delay)
+	}
+
+	other := &core.RateLimitRequest{Recipient: "+8613900139000", Metadata: map[string]interface{}{"tenant_id": 7}}
+	if _, err = c.ReserveRequest(other, 0); err != nil {
+		t.Errorf("another recipient should pass: %v", err)
+	}
+}
+
+func TestCompositeRateLimiter_AllOrNothing(t *testing.T) {
+	global := ratelimiter.NewTokenBucketRateLimiter(0.001, 3)
+	c := ratelimiter.NewCompositeRateLimiter(
+		ratelimiter.WithGlobalLimit("global", global),
+		ratelimiter.WithKeyedLimit("tenant", ratelimiter.MetadataKey("tenant"), perKey(1)),
+	)
+	limited := &core.RateLimitRequest{Metadata: map[string]interface{}{"tenant": "a"}}
+
+	_, _ = c.ReserveRequest(limited, 0)
+	for range 3 {
+		if _, err := c.ReserveRequest(limited, 0); err == nil {
+			t.Fatal("tenant a should be limited")
+		}
+	}
+	// The rejected sends must not have taken global tokens.
+	if !global.AllowN(2) {
+		t.Error("rejected sends consumed global tokens")
+	}
+}
+
+func TestCompositeRateLimiter_SkipsDimensionsWithoutKey(t *testing.T) {
+	c := ratelimiter.NewCompositeRateLimiter(
+		ratelimiter.WithKeyedLimit("account", ratelimiter.AccountKey, perKey(1)),
+	)
+	for range 3 {
+		if _, err := c.ReserveRequest(&core.RateLimitRequest{Provider: core.ProviderTypeSMS}, 0); err != nil {
+			t.Fatalf("sends without an explicit account should not be limited: %v", err)
+		}
+	}
+	if !c.Allow() {
+		t.Error("Allow has no unkeyed dimension to check")
+	}
+}
+
+func TestCompositeRateLimiter_WaitsWithinMaxWait(t *testing.T) {
+	c := ratelimiter.NewCompositeRateLimiter(
+		ratelimiter.WithGlobalLimit("global", ratelimiter.NewTokenBucketRateLimiter(20, 1)),
+	)
+	req := &core.RateLimitRequest{}
+	_, _ = c.ReserveRequest(req, 0)
+
+	r, err := c.ReserveRequest(req, time.Second)
+	if err != nil {
+		t.Fatalf("a token 50ms away should be reserved: %v", err)
+	}
+	if d := r.Delay(); d <= 0 || d > 60*time.Millisecond {
+		t.Errorf("unexpected delay %v", d)
+	}
+}
+
+func TestKeyedRateLimiter_EvictsIdleKeys(t *testing.T) {
+	k := ratelimiter.NewKeyedRateLimiter(perKey(1), 20*time.Millisecond)
+	for _, key := range []string{"a", "b", "c"} {
+		k.Get(key)
+	}
+	if n := k.Len(); n != 3 {
+		t.Fatalf("expected 3 limiters, got %d", n)
+	}
+	time.Sleep(30 * time.Millisecond)
+	k.Get("d")
+	if n := k.Len(); n != 1 {
+		t.Errorf("idle limiters should be evicted, %d left", n)
+	}
+}
@@ -0,0 +1,226 @@
+package ratelimiter
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+// quotaProfiles holds the documented limits of the vendors, keyed by provider
+// type or by "provider/sub-provider".
+//
+//nolint:gochecknoglobals // Global registry is acceptable for package-wide look-ups.
+var (
+	quotaProfilesMu sync.RWMutex
+	quotaProfiles   = map[string]core.QuotaProfile{
+		// Custom robots: 20 messages per minute per webhook.
+		string(core.ProviderTypeDingtalk): {Account: core.Quotas{{Limit: 20, Per: time.Minute}}},
+		string(core.ProviderTypeLark):     {Account: core.Quotas{{Limit: 20, Per: time.Minute}}},
+		string(core.ProviderTypeWecombot): {Account: core.Quotas{{Limit: 20, Per: time.Minute}}},
+		// Bots: 30 messages per second overall, one per second to the same chat.
+		string(core.ProviderTypeTelegram): {
+			Account:   core.Quotas{{Limit: 30, Per: time.Second}},
+			Recipient: core.Quotas{{Limit: 1, Per: time.Second}},
+		},
+		// Aliyun: one message per minute, five per hour and ten per day to the same number.
+		string(core.ProviderTypeSMS) + "/aliyun": {Recipient: core.Quotas{
+			{Limit: 1, Per: time.Minute},
+			{Limit: 5, Per: time.Hour},
+			{Limit: 10, Per: 24 * time.Hour},
+		}},
+		// Tencent: one message per 30 seconds and ten per day to the same number.
+		string(core.ProviderTypeSMS) + "/tencent": {Recipient: core.Quotas{
+			{Limit: 1, Per: 30 * time.Second},
+			{Limit: 10, Per: 24 * time.Hour},
+		}},
+	}
+)
+
+// RegisterQuotaProfile registers, or replaces, the quota profile of a provider
+// type ("telegram") or sub-provider ("sms/aliyun").
+func RegisterQuotaProfile(key string, profile core.QuotaProfile) {
+	quotaProfilesMu.Lock()
+	defer quotaProfilesMu.Unlock()
+	quotaProfiles[key] = profile
+}
+
+// LookupQuotaProfile returns the built-in quota profile for an account of
+// provider with the given sub-provider. A profile registered for the
+// sub-provider takes precedence over one for the whole provider.
+func LookupQuotaProfile(provider, subType string) (core.QuotaProfile, bool) {
+	quotaProfilesMu.RLock()
+	defer quotaProfilesMu.RUnlock()
+	if subType != "" {
+		if profile, ok := quotaProfiles[provider+"/"+subType]; ok {
+			return profile, true
+		}
+	}
+	profile, ok := quotaProfiles[provider]
+	return profile, ok
+}
+
+// QuotaFor returns the quotas applying to item: the built-in profile of provider,
+// overridden by the item's own Quota (see core.QuotaAware).
+func QuotaFor(provider string, item core.Selectable) core.QuotaProfile {
+	profile, _ := LookupQuotaProfile(provider, item.GetType())
+	if aware, ok := item.(core.QuotaAware); ok {
+		profile = profile.Override(aware.GetQuota())
+	}
+	return profile
+}
+
+// NewQuotaLimiter creates a limiter enforcing q. A sliding window is used, as
+// vendors count the messages of the last period rather than refilling tokens at
+// a steady rate. It returns nil for an invalid quota.
+func NewQuotaLimiter(q core.Quota) *SlidingWindowRateLimiter {
+	limiter, err := NewSlidingWindowRateLimiter(q.Per, q.Limit)
+	if err != nil {
+		return nil
+	}
+	return limiter
+}
+
+// QuotasLimiter enforces several quotas together, e.g. per minute, hour and day:
+// tokens are granted only when every quota has room for them.
+type QuotasLimiter struct {
+	mu      sync.Mutex
+	windows []*SlidingWindowRateLimiter
+}
+
+var (
+	_ core.ReservingRateLimiter = (*QuotasLimiter)(nil)
+	_ core.BurstLimiter         = (*QuotasLimiter)(nil)
+)
+
+// NewQuotasLimiter creates a limiter enforcing every quota of qs, skipping
+// invalid ones. It returns nil when no valid quota is left.
+func NewQuotasLimiter(qs core.Quotas) *QuotasLimiter {
+	l := &QuotasLimiter{}
+	for _, q := range qs {
+		if window := NewQuotaLimiter(q); window != nil {
+			l.windows = append(l.windows, window)
+		}
+	}
+	if len(l.windows) == 0 {
+		return nil
+	}
+	return l
+}
+
+// ReserveN reserves n tokens in every quota, or in none: the reservation is not
+// OK when n exceeds the limit of a quota.
+func (l *QuotasLimiter) ReserveN(n int) core.Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reservation := &compositeReservation{}
+	for _, window := range l.windows {
+		r := window.ReserveN(n)
+		if !r.OK() {
+			reservation.Cancel()
+			return r
+		}
+		reservation.parts = append(reservation.parts, r)
+	}
+	return reservation
+}
+
+// Allow takes a token from every quota if all have one now.
+func (l *QuotasLimiter) Allow() bool {
+	r := l.ReserveN(1)
+	if !r.OK() {
+		return false
+	}
+	if r.Delay() > 0 {
+		r.Cancel()
+		return false
+	}
+	return true
+}
+
+// Wait waits for a token from every quota.
+func (l *QuotasLimiter) Wait(ctx context.Context) error {
+	r := l.ReserveN(1)
+	if !r.OK() {
+		return ErrRateLimitExceeded
+	}
+	timer := time.NewTimer(r.Delay())
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Burst returns the smallest limit of the quotas, see core.BurstLimiter.
+func (l *QuotasLimiter) Burst() int {
+	burst := l.windows[0].Burst()
+	for _, window := range l.windows[1:] {
+		burst = min(burst, window.Burst())
+	}
+	return burst
+}
+
+// Close closes the limiter (no-op).
+func (l *QuotasLimiter) Close() error {
+	return nil
+}
+
+// newQuotasLimiter returns the limiter enforcing qs, or nil when qs has no valid
+// quota. A single quota needs no QuotasLimiter around its window.
+func newQuotasLimiter(qs core.Quotas) core.RateLimiter {
+	limiter := NewQuotasLimiter(qs)
+	switch {
+	case limiter == nil:
+		return nil
+	case len(limiter.windows) == 1:
+		return limiter.windows[0]
+	default:
+		return limiter
+	}
+}
+
+// longestRecipientPeriod returns the longest period of the built-in recipient
+// quotas of provider and its sub-providers.
+func longestRecipientPeriod(provider string) time.Duration {
+	quotaProfilesMu.RLock()
+	defer quotaProfilesMu.RUnlock()
+	var longest time.Duration
+	for key, profile := range quotaProfiles {
+		if key != provider && !strings.HasPrefix(key, provider+"/") {
+			continue
+		}
+		for _, q := range profile.Recipient {
+			longest = max(longest, q.Per)
+		}
+	}
+	return longest
+}
+
+// NewQuotaGuard creates an AccountGuard enforcing the quotas of provider (see
+// QuotaFor) on every account and, where the profile has recipient quotas, on
+// every recipient of an account. Recipient limiters are kept at least as long as
+// the longest built-in recipient quota, so that e.g. a daily cap per number is
+// not forgotten; pass core.WithRecipientIdleTTL for accounts overriding it with
+// longer quotas. opts may add circuit breakers.
+func NewQuotaGuard(provider string, opts ...core.AccountGuardOption) *core.AccountGuard {
+	quotaLimiter := func(pick func(core.QuotaProfile) core.Quotas) func(core.Selectable) core.RateLimiter {
+		return func(item core.Selectable) core.RateLimiter {
+			return newQuotasLimiter(pick(QuotaFor(provider, item)))
+		}
+	}
+	defaults := []core.AccountGuardOption{
+		core.WithItemLimiters(quotaLimiter(func(p core.QuotaProfile) core.Quotas { return p.Account })),
+		core.WithRecipientLimiters(quotaLimiter(func(p core.QuotaProfile) core.Quotas { return p.Recipient })),
+	}
+	if period := longestRecipientPeriod(provider); period > core.DefaultRecipientIdleTTL {
+		defaults = append(defaults, core.WithRecipientIdleTTL(period))
+	}
+	return core.NewAccountGuard(append(defaults, opts...)...)
+}
@@ -0,0 +1,122 @@
+package ratelimiter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/providers/sms"
+	"github.com/shellvon/go-sender/providers/telegram"
+	"github.com/shellvon/go-sender/ratelimiter"
+)
+
+func TestLookupQuotaProfile(t *testing.T) {
+	if p, ok := ratelimiter.LookupQuotaProfile("sms", "aliyun"); !ok || p.Recipient.String() != "1/1m,5/1h,10/24h" {
+		t.Errorf("expected the aliyun per-number quotas, got %+v, %v", p, ok)
+	}
+	if p, ok := ratelimiter.LookupQuotaProfile("sms", "tencent"); !ok || p.Recipient.String() != "1/30s,10/24h" {
+		t.Errorf("expected the tencent per-number quotas, got %+v, %v", p, ok)
+	}
+	if _, ok := ratelimiter.LookupQuotaProfile("sms", "huawei"); ok {
+		t.Error("huawei has no built-in profile")
+	}
+	if p, ok := ratelimiter.LookupQuotaProfile("dingtalk", ""); !ok || p.Account.String() != "20/1m" {
+		t.Errorf("expected 20/min for dingtalk, got %+v", p)
+	}
+}
+
+func TestQuotaFor_AccountOverride(t *testing.T) {
+	account := sms.NewAccount("aliyun", "k", "s")
+	account.Quota = &core.QuotaProfile{Recipient: core.Quotas{{Limit: 3, Per: time.Minute}}}
+
+	if q := ratelimiter.QuotaFor("sms", account); q.Recipient.String() != "3/1m" {
+		t.Errorf("expected the account override, got %v", q.Recipient)
+	}
+}
+
+func TestQuotaGuard_PerChat(t *testing.T) {
+	config := &core.BaseConfig[*telegram.Account]{
+		Items: []*telegram.Account{telegram.NewAccount("token")},
+	}
+	config.SetAccountGuard(ratelimiter.NewQuotaGuard("telegram"))
+
+	send := func(chat string) error {
+		_, err := config.Select(core.WithRecipient(context.Background(), chat), nil)
+		return err
+	}
+	if err := send("chat-1"); err != nil {
+		t.Fatalf("first message should pass: %v", err)
+	}
+	if err := send("chat-1"); err == nil {
+		t.Error("a second message to the same chat within a second should be limited")
+	}
+	if err := send("chat-2"); err != nil {
+		t.Errorf("another chat has its own quota: %v", err)
+	}
+}
+
+func TestQuotaGuard_EveryMobileOfABatch(t *testing.T) {
+	account := sms.NewAccount("aliyun", "k", "s")
+	account.Quota = &core.QuotaProfile{Recipient: core.Quotas{{Limit: 1, Per: time.Minute}}}
+	config := &core.BaseConfig[*sms.Account]{Items: []*sms.Account{account}}
+	config.SetAccountGuard(ratelimiter.NewQuotaGuard("sms"))
+
+	batch := &sms.Message{Mobiles: []string{"13800000001", "13800000002"}}
+	if _, err := config.Select(core.WithRecipients(context.Background(), batch.Recipients()...), nil); err != nil {
+		t.Fatalf("first batch should pass: %v", err)
+	}
+	if _, err := config.Select(core.WithRecipient(context.Background(), "13800000002"), nil); err == nil {
+		t.Error("every mobile of the batch should have used its per-number quota")
+	}
+}
+
+func TestQuotaGuard_EveryQuotaOfANumber(t *testing.T) {
+	account := sms.NewAccount("aliyun", "k", "s")
+	account.Quota = &core.QuotaProfile{Recipient: core.Quotas{
+		{Limit: 2, Per: 50 * time.Millisecond},
+		{Limit: 3, Per: time.Hour},
+	}}
+	config := &core.BaseConfig[*sms.Account]{Items: []*sms.Account{account}}
+	config.SetAccountGuard(ratelimiter.NewQuotaGuard("sms"))
+
+	send := func() error {
+		_, err := config.Select(core.WithRecipient(context.Background(), "13800000001"), nil)
+		return err
+	}
+	for i := range 2 {
+		if err := send(); err != nil {
+			t.Fatalf("message %d should pass: %v", i+1, err)
+		}
+	}
+	if err := send(); err == nil {
+		t.Fatal("the short quota should limit the third message")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := send(); err != nil {
+		t.Fatalf("the short quota has room again: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := send(); err == nil {
+		t.Error("the hourly quota should limit the fourth message")
+	}
+}
+
+func TestQuotasLimiter_AllOrNothing(t *testing.T) {
+	limiter := ratelimiter.NewQuotasLimiter(core.Quotas{{Limit: 2, Per: time.Hour}, {Limit: 3, Per: time.Hour}})
+	if limiter.Burst() != 2 {
+		t.Errorf("expected the smallest limit as burst, got %d", limiter.Burst())
+	}
+	if r := limiter.ReserveN(3); r.OK() {
+		t.Error("3 tokens exceed the first quota")
+	}
+	if !limiter.Allow() || !limiter.Allow() {
+		t.Fatal("two tokens should be available")
+	}
+	if limiter.Allow() {
+		t.Error("the first quota is used up")
+	}
+	if ratelimiter.NewQuotasLimiter(core.Quotas{{Limit: 0, Per: time.Hour}}) != nil {
+		t.Error("invalid quotas should give no limiter")
+	}
+}
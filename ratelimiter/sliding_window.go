@@ -5,8 +5,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
+
+	"github.com/shellvon/go-sender/core"
 )
 
 // ErrRateLimitExceeded is returned when the rate limit is exceeded.
@@ -28,6 +31,11 @@ type SlidingWindowRateLimiter struct {
 	cleanupInterval time.Duration
 }
 
+var (
+	_ core.ReservingRateLimiter = (*SlidingWindowRateLimiter)(nil)
+	_ core.BurstLimiter         = (*SlidingWindowRateLimiter)(nil)
+)
+
 // NewSlidingWindowRateLimiter creates a new sliding window rate limiter.
 func NewSlidingWindowRateLimiter(windowSize time.Duration, maxRequests int) (*SlidingWindowRateLimiter, error) {
 	if windowSize <= 0 {
@@ -47,13 +55,12 @@ func NewSlidingWindowRateLimiter(windowSize time.Duration, maxRequests int) (*Sl
 }
 
 // Allow checks if a request is allowed to pass.
-func (r *SlidingWindowRateLimiter) Allow(ctx context.Context) error {
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
+func (r *SlidingWindowRateLimiter) Allow() bool {
+	return r.AllowN(1)
+}
 
+// AllowN checks if n requests are allowed to pass at once.
+func (r *SlidingWindowRateLimiter) AllowN(n int) bool {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -63,23 +70,102 @@ func (r *SlidingWindowRateLimiter) Allow(ctx context.Context) error {
 	r.cleanup(now)
 
 	// Check if the limit is exceeded
-	if len(r.requests) >= r.maxRequests {
-		return fmt.Errorf("%w: %d requests in %v", ErrRateLimitExceeded, len(r.requests), r.windowSize)
+	if len(r.requests)+n > r.maxRequests {
+		return false
 	}
 
-	// Record the current request
-	r.requests = append(r.requests, now)
+	// Record the current requests, before any reserved for later
+	i, _ := slices.BinarySearchFunc(r.requests, now, time.Time.Compare)
+	r.requests = slices.Insert(r.requests, i, slices.Repeat([]time.Time{now}, n)...)
+	return true
+}
+
+// ReserveN reserves n requests, see core.ReservingRateLimiter. They are recorded
+// at the time the window has room for them, which may lie in the future. The
+// reservation is not OK when n exceeds maxRequests.
+func (r *SlidingWindowRateLimiter) ReserveN(n int) core.Reservation {
+	if n <= 0 || n > r.maxRequests {
+		return &slidingWindowReservation{}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	r.cleanup(now)
+
+	// Requests leave the window in order; n slots are free once the
+	// (len+n-max)-th oldest request has expired.
+	at := now
+	if excess := len(r.requests) + n - r.maxRequests; excess > 0 {
+		at = r.requests[excess-1].Add(r.windowSize)
+	}
+	if at.Before(now) {
+		at = now
+	}
+	i, _ := slices.BinarySearchFunc(r.requests, at, time.Time.Compare)
+	r.requests = slices.Insert(r.requests, i, slices.Repeat([]time.Time{at}, n)...)
+
+	return &slidingWindowReservation{limiter: r, at: at, n: n, ok: true}
+}
+
+// Burst returns the number of requests allowed per window, see core.BurstLimiter.
+func (r *SlidingWindowRateLimiter) Burst() int {
+	return r.maxRequests
+}
+
+// Close shuts down the rate limiter (no-op).
+func (r *SlidingWindowRateLimiter) Close() error {
 	return nil
 }
 
+// slidingWindowReservation implements core.Reservation.
+type slidingWindowReservation struct {
+	limiter *SlidingWindowRateLimiter
+	at      time.Time
+	n       int
+	ok      bool
+}
+
+func (v *slidingWindowReservation) OK() bool { return v.ok }
+
+func (v *slidingWindowReservation) Delay() time.Duration {
+	if !v.ok {
+		return 0
+	}
+	return max(time.Until(v.at), 0)
+}
+
+// Cancel removes the reserved requests, even those granted at once: the caller
+// must not have acted on the reservation.
+func (v *slidingWindowReservation) Cancel() {
+	if !v.ok {
+		return
+	}
+	r := v.limiter
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	i, found := slices.BinarySearchFunc(r.requests, v.at, time.Time.Compare)
+	if found {
+		j := i
+		for j < len(r.requests) && j-i < v.n && r.requests[j].Equal(v.at) {
+			j++
+		}
+		r.requests = slices.Delete(r.requests, i, j)
+	}
+	v.ok = false
+}
+
 // Wait waits until a request can be made or context is cancelled.
 func (r *SlidingWindowRateLimiter) Wait(ctx context.Context) error {
 	for {
-		if err := r.Allow(ctx); err == nil {
-			return nil
-		} else if !errors.Is(err, ErrRateLimitExceeded) {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if r.Allow() {
+			return nil
+		}
 
 		// Calculate how long to wait before the next request can be made
 		waitTime := r.calculateWaitTime()
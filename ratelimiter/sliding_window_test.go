@@ -13,29 +13,25 @@ func TestSlidingWindowRateLimiter_BasicFlow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("create failed: %v", err)
 	}
-	ctx := context.Background()
-	err2 := rl.Allow(ctx)
-	if err2 != nil {
-		t.Errorf("first allow should pass: %v", err2)
+	if !rl.Allow() {
+		t.Error("first allow should pass")
 	}
-	_ = rl.Allow(ctx)
-	// 超过限额应报错
-	err2 = rl.Allow(ctx)
-	if err2 == nil {
+	_ = rl.Allow()
+	// 超过限额应被拒绝
+	if rl.Allow() {
 		t.Error("should be rate limited")
 	}
 	// Wait 应能阻塞到可用
 	ctx2, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 	defer cancel()
-	err2 = rl.Wait(ctx2)
+	err2 := rl.Wait(ctx2)
 	if err2 != nil {
 		t.Errorf("Wait should eventually succeed, got %v", err2)
 	}
 	// Reset 应清空历史
 	rl.Reset()
-	err2 = rl.Allow(ctx)
-	if err2 != nil {
-		t.Errorf("allow after reset should pass: %v", err2)
+	if !rl.Allow() {
+		t.Error("allow after reset should pass")
 	}
 }
 
@@ -61,9 +57,31 @@ func TestSlidingWindowRateLimiter_Concurrency(_ *testing.T) {
 	for range 100 {
 		wg <- struct{}{}
 		go func() {
-			_ = rl.Allow(context.Background())
+			_ = rl.Allow()
 			<-wg
 		}()
 	}
 	time.Sleep(20 * time.Millisecond)
 }
+
+func TestSlidingWindowRateLimiter_ReserveN(t *testing.T) {
+	rl, _ := ratelimiter.NewSlidingWindowRateLimiter(50*time.Millisecond, 3)
+
+	if r := rl.ReserveN(2); !r.OK() || r.Delay() != 0 {
+		t.Fatalf("first reservation should be immediate, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+	r := rl.ReserveN(2)
+	if !r.OK() || r.Delay() <= 0 || r.Delay() > 50*time.Millisecond {
+		t.Fatalf("second reservation should wait for the window, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+	if rl.Allow() {
+		t.Error("reserved requests should count against the limit")
+	}
+	r.Cancel()
+	if !rl.Allow() {
+		t.Error("cancelling should free the reserved requests")
+	}
+	if rl.ReserveN(4).OK() {
+		t.Error("reserving more than maxRequests should not be OK")
+	}
+}
@@ -2,8 +2,11 @@ package ratelimiter
 
 import (
 	"context"
+	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/shellvon/go-sender/core"
 )
 
 // TokenBucketRateLimiter is a token bucket rate limiter based on golang.org/x/time/rate.
@@ -11,6 +14,11 @@ type TokenBucketRateLimiter struct {
 	limiter *rate.Limiter
 }
 
+var (
+	_ core.ReservingRateLimiter = (*TokenBucketRateLimiter)(nil)
+	_ core.BurstLimiter         = (*TokenBucketRateLimiter)(nil)
+)
+
 // NewTokenBucketRateLimiter creates a new token bucket rate limiter
 // qps: requests per second allowed
 // burst: burst capacity
@@ -30,7 +38,39 @@ func (r *TokenBucketRateLimiter) Wait(ctx context.Context) error {
 	return r.limiter.Wait(ctx)
 }
 
+// AllowN checks if n requests are allowed to pass at once.
+func (r *TokenBucketRateLimiter) AllowN(n int) bool {
+	return r.limiter.AllowN(time.Now(), n)
+}
+
+// WaitN waits until n requests can pass at once.
+func (r *TokenBucketRateLimiter) WaitN(ctx context.Context, n int) error {
+	return r.limiter.WaitN(ctx, n)
+}
+
+// ReserveN reserves n tokens, see core.ReservingRateLimiter. The reservation is
+// not OK when n exceeds the burst. Unlike a plain *rate.Reservation, cancelling
+// it gives back tokens that were granted at once too, so that a
+// CompositeRateLimiter can undo it.
+func (r *TokenBucketRateLimiter) ReserveN(n int) core.Reservation {
+	now := time.Now()
+	return &tokenBucketReservation{Reservation: r.limiter.ReserveN(now, n), at: now}
+}
+
+// Burst returns the burst size, see core.BurstLimiter.
+func (r *TokenBucketRateLimiter) Burst() int {
+	return r.limiter.Burst()
+}
+
 // Close shuts down the rate limiter (no-op).
 func (r *TokenBucketRateLimiter) Close() error {
 	return nil
 }
+
+// tokenBucketReservation is a *rate.Reservation cancelled as of the time it was made.
+type tokenBucketReservation struct {
+	*rate.Reservation
+	at time.Time
+}
+
+func (v *tokenBucketReservation) Cancel() { v.CancelAt(v.at) }
@@ -29,3 +29,18 @@ func TestTokenBucketRateLimiter_AllowAndWait(t *testing.T) {
 	_ = rl.Close()
 	_ = rl.Close() // 幂等
 }
+
+func TestTokenBucketRateLimiter_ReserveN(t *testing.T) {
+	rl := ratelimiter.NewTokenBucketRateLimiter(10, 3)
+	if !rl.AllowN(3) {
+		t.Fatal("a full bucket should allow its burst")
+	}
+	r := rl.ReserveN(2)
+	if !r.OK() || r.Delay() <= 0 {
+		t.Fatalf("expected a delayed reservation, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+	r.Cancel()
+	if rl.ReserveN(4).OK() {
+		t.Error("reserving more than the burst should not be OK")
+	}
+}
@@ -0,0 +1,237 @@
+// Package scheduler provides cron-style recurring notifications for the go-sender library.
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSpec is returned when a schedule expression cannot be parsed.
+var ErrInvalidSpec = errors.New("invalid schedule spec")
+
+// maxSearchYears bounds the search for the next activation of a schedule that
+// can never match, e.g. "0 0 30 2 *".
+const maxSearchYears = 5
+
+// Schedule computes the activation times of a job.
+type Schedule interface {
+	// Next returns the first activation strictly after t, or the zero time if there is none.
+	Next(t time.Time) time.Time
+}
+
+// CronSchedule is a standard five-field cron expression:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Fields accept "*", lists ("1,15"), ranges ("1-5"), steps ("*/15", "10-50/10")
+// and, for months and weekdays, three-letter names ("JAN", "MON-FRI"). As in
+// Vixie cron, when both day fields are restricted a day matching either one fires.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+	// loc is the time zone the expression is evaluated in; nil means the zone of
+	// the time passed to Next.
+	loc *time.Location
+}
+
+// everySchedule fires at a fixed interval, e.g. "@every 15m".
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+type fieldBounds struct {
+	min, max int
+	names    map[string]int
+}
+
+//nolint:gochecknoglobals // constant lookup tables
+var (
+	minuteBounds = fieldBounds{min: 0, max: 59}
+	hourBounds   = fieldBounds{min: 0, max: 23}
+	domBounds    = fieldBounds{min: 1, max: 31}
+	monthBounds  = fieldBounds{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = fieldBounds{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Parse parses a schedule expression. Besides five-field cron expressions it
+// accepts the descriptors @yearly, @monthly, @weekly, @daily and @hourly, and
+// "@every <duration>". A "CRON_TZ=<zone>" prefix evaluates the expression in
+// that IANA time zone, e.g. "CRON_TZ=Asia/Shanghai 0 9 * * MON-FRI".
+func Parse(spec string) (Schedule, error) {
+	return parseIn(spec, nil)
+}
+
+// parseIn parses spec, evaluating it in loc unless spec carries its own CRON_TZ prefix.
+func parseIn(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		zone, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: time zone %q: %w", ErrInvalidSpec, name, err)
+		}
+		loc, spec = zone, strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("%w: %q: interval must be a positive duration", ErrInvalidSpec, spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+	if expanded, ok := descriptors[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 { //nolint:mnd // minute hour dom month dow
+		return nil, fmt.Errorf("%w: %q: expected 5 fields, got %d", ErrInvalidSpec, spec, len(fields))
+	}
+
+	s := &CronSchedule{loc: loc}
+	var err error
+	for i, target := range []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow} {
+		bounds := []fieldBounds{minuteBounds, hourBounds, domBounds, monthBounds, dowBounds}[i]
+		if *target, err = parseField(fields[i], bounds); err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidSpec, spec, err)
+		}
+	}
+	// Sunday may be written as 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domAny = fields[2] == "*" || fields[2] == "?"
+	s.dowAny = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+// parseField parses one comma-separated cron field into a bit set.
+func parseField(field string, bounds fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		lo, hi := bounds.min, bounds.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			loPart, hiPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseValue(loPart, bounds); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(hiPart, bounds); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := parseValue(rangePart, bounds)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("range %q is reversed", part)
+		}
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, bounds fieldBounds) (int, error) {
+	if v, ok := bounds.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < bounds.min || v > bounds.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, bounds.min, bounds.max)
+	}
+	return v, nil
+}
+
+// Next returns the first activation strictly after t, in t's location.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	loc := s.loc
+	if loc == nil {
+		loc = t.Location()
+	}
+	origLoc := t.Location()
+
+	t = t.In(loc).Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + maxSearchYears
+
+	for t.Year() <= yearLimit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if !next.After(t) {
+				// Repeated hour at the end of daylight saving time.
+				next = t.Truncate(time.Hour).Add(time.Hour)
+			}
+			t = next
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t.In(origLoc)
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,77 @@
+package scheduler_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/scheduler"
+)
+
+func mustParse(t *testing.T, spec string) scheduler.Schedule {
+	t.Helper()
+	s, err := scheduler.Parse(spec)
+	if err != nil {
+		t.Fatalf("Parse(%q) failed: %v", spec, err)
+	}
+	return s
+}
+
+func TestParse_Next(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	// Friday 2025-01-03 10:30 Asia/Shanghai.
+	from := time.Date(2025, 1, 3, 10, 30, 0, 0, shanghai)
+
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2025, 1, 3, 10, 45, 0, 0, shanghai)},
+		{"0 9 * * MON-FRI", time.Date(2025, 1, 6, 9, 0, 0, 0, shanghai)},
+		{"0 9 * * 1-5", time.Date(2025, 1, 6, 9, 0, 0, 0, shanghai)},
+		{"30 10 3 1 *", time.Date(2026, 1, 3, 10, 30, 0, 0, shanghai)},
+		{"0 0 1,15 * *", time.Date(2025, 1, 15, 0, 0, 0, 0, shanghai)},
+		{"0 0 13 * FRI", time.Date(2025, 1, 10, 0, 0, 0, 0, shanghai)}, // either day field matches
+		{"0 12 * * 7", time.Date(2025, 1, 5, 12, 0, 0, 0, shanghai)},
+		{"@daily", time.Date(2025, 1, 4, 0, 0, 0, 0, shanghai)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+	for _, c := range cases {
+		got := mustParse(t, c.spec).Next(from)
+		if !got.Equal(c.want) {
+			t.Errorf("%q: Next = %s, want %s", c.spec, got, c.want)
+		}
+	}
+}
+
+func TestParse_TimeZonePrefix(t *testing.T) {
+	if _, err := time.LoadLocation("Asia/Shanghai"); err != nil {
+		t.Skip("tzdata not available")
+	}
+	s := mustParse(t, "CRON_TZ=Asia/Shanghai 0 9 * * *")
+	from := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC) // 08:00 in Shanghai
+	got := s.Next(from)
+	if want := time.Date(2025, 1, 3, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+	if got.Location() != time.UTC {
+		t.Errorf("Next should keep the caller's location, got %s", got.Location())
+	}
+}
+
+func TestParse_Impossible(t *testing.T) {
+	if next := mustParse(t, "0 0 30 2 *").Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected no activation for Feb 30, got %s", next)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * MON-XYZ", "5-1 * * * *", "*/0 * * * *", "@every -1m", "CRON_TZ=Nowhere/City * * * * *"} {
+		if _, err := scheduler.Parse(spec); !errors.Is(err, scheduler.ErrInvalidSpec) {
+			t.Errorf("Parse(%q): expected ErrInvalidSpec, got %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+)
+
+const (
+	// MetadataJobID is the send metadata key carrying the ID of the job that produced a message.
+	MetadataJobID = "scheduler_job_id"
+
+	// defaultMisfireThreshold is how late an activation may run before it counts as misfired.
+	defaultMisfireThreshold = time.Minute
+	// defaultMaxCatchUp caps the activations replayed by MisfireRunAll after a long downtime.
+	defaultMaxCatchUp = 100
+)
+
+var (
+	// ErrJobNotFound is returned when no job has the requested ID.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobExists is returned by Add when a job with the same ID is already scheduled.
+	ErrJobExists = errors.New("job already exists")
+	// ErrFactoryNotFound is returned by Add when the job's factory is not registered.
+	ErrFactoryNotFound = errors.New("message factory not registered")
+	// ErrStopJob can be returned by a MessageFactory to delete its job without
+	// sending anything, e.g. once a reminder has been acknowledged.
+	ErrStopJob = errors.New("stop job")
+	// ErrSchedulerRunning is returned by Start when the scheduler is already running.
+	ErrSchedulerRunning = errors.New("scheduler already running")
+)
+
+// MisfirePolicy decides what happens to activations missed while the scheduler
+// was down, or that ran later than the misfire threshold.
+type MisfirePolicy int
+
+const (
+	// MisfireRunOnce runs a single catch-up activation, then resumes the schedule. This is the default.
+	MisfireRunOnce MisfirePolicy = iota
+	// MisfireSkip drops missed activations and waits for the next one.
+	MisfireSkip
+	// MisfireRunAll replays every missed activation, up to the catch-up limit.
+	MisfireRunAll
+)
+
+func (p MisfirePolicy) String() string {
+	switch p {
+	case MisfireRunOnce:
+		return "run_once"
+	case MisfireSkip:
+		return "skip"
+	case MisfireRunAll:
+		return "run_all"
+	default:
+		return "unknown"
+	}
+}
+
+// Job is a recurring notification. Jobs are plain data so that a [Store] can
+// persist them; the message itself is built at each activation by the
+// [MessageFactory] registered under Factory.
+type Job struct {
+	// ID uniquely identifies the job.
+	ID string `json:"id"`
+	// Spec is the schedule expression, see [Parse].
+	Spec string `json:"spec"`
+	// Timezone is the IANA zone Spec is evaluated in. Empty uses the scheduler's
+	// location. A CRON_TZ prefix in Spec takes precedence.
+	Timezone string `json:"timezone,omitempty"`
+	// Factory is the name of the registered MessageFactory.
+	Factory string `json:"factory"`
+	// Params is passed to the factory on every activation.
+	Params map[string]string `json:"params,omitempty"`
+	// Misfire is the policy for missed activations.
+	Misfire MisfirePolicy `json:"misfire"`
+	// MaxRuns deletes the job after that many activations. Zero means unlimited.
+	MaxRuns int `json:"max_runs,omitempty"`
+	// EndAt deletes the job once no activation is left before it.
+	EndAt *time.Time `json:"end_at,omitempty"`
+	// Paused jobs keep their schedule but do not fire.
+	Paused bool `json:"paused"`
+
+	// NextRun is the next activation. It is maintained by the scheduler.
+	NextRun time.Time `json:"next_run"`
+	// LastRun is the scheduled time of the latest activation.
+	LastRun time.Time `json:"last_run,omitempty"`
+	// RunCount is the number of activations so far.
+	RunCount int `json:"run_count"`
+}
+
+func (j *Job) clone() *Job {
+	c := *j
+	if j.Params != nil {
+		c.Params = make(map[string]string, len(j.Params))
+		for k, v := range j.Params {
+			c.Params[k] = v
+		}
+	}
+	if j.EndAt != nil {
+		endAt := *j.EndAt
+		c.EndAt = &endAt
+	}
+	return &c
+}
+
+// Run describes one activation of a job.
+type Run struct {
+	JobID string
+	// ScheduledAt is the activation time, which is in the past for catch-up runs.
+	ScheduledAt time.Time
+	Params      map[string]string
+	// RunCount is the 1-based number of this activation.
+	RunCount int
+}
+
+// MessageFactory builds the message for an activation. Returning [ErrStopJob]
+// deletes the job; any other error skips this activation.
+type MessageFactory func(ctx context.Context, run Run) (core.Message, error)
+
+// Sender submits messages built by jobs. *gosender.Sender implements it.
+type Sender interface {
+	Send(ctx context.Context, message core.Message, opts ...core.SendOption) error
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithStore sets the persistent job store. The default is a [MemoryStore].
+func WithStore(store Store) Option {
+	return func(s *Scheduler) {
+		if store != nil {
+			s.store = store
+		}
+	}
+}
+
+// WithLocation sets the default time zone of job specs. The default is time.Local.
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) {
+		if loc != nil {
+			s.loc = loc
+		}
+	}
+}
+
+// WithLogger sets the logger. The default is [core.NoOpLogger].
+func WithLogger(logger core.Logger) Option {
+	return func(s *Scheduler) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithMisfireThreshold sets how late an activation may run before its job's
+// MisfirePolicy applies. The default is one minute.
+func WithMisfireThreshold(d time.Duration) Option {
+	return func(s *Scheduler) {
+		s.misfireThreshold = d
+	}
+}
+
+// WithMaxCatchUp caps the number of activations replayed by [MisfireRunAll]. The default is 100.
+func WithMaxCatchUp(n int) Option {
+	return func(s *Scheduler) {
+		if n > 0 {
+			s.maxCatchUp = n
+		}
+	}
+}
+
+// WithSendOptions sets options applied to every message sent by the scheduler.
+func WithSendOptions(opts ...core.SendOption) Option {
+	return func(s *Scheduler) {
+		s.sendOpts = append(s.sendOpts, opts...)
+	}
+}
+
+type entry struct {
+	job      *Job
+	schedule Schedule
+}
+
+// dueRun is an activation taken off the schedule, waiting to be fired.
+type dueRun struct {
+	factory string
+	run     Run
+}
+
+// Scheduler fires jobs on their schedules and submits the produced messages through a Sender.
+type Scheduler struct {
+	sender           Sender
+	store            Store
+	loc              *time.Location
+	logger           core.Logger
+	misfireThreshold time.Duration
+	maxCatchUp       int
+	sendOpts         []core.SendOption
+
+	mu        sync.Mutex
+	jobs      map[string]*entry
+	factories map[string]MessageFactory
+	wake      chan struct{}
+	cancel    context.CancelFunc
+	workers   sync.WaitGroup
+}
+
+// New creates a Scheduler that sends through sender. Call Start to begin firing jobs.
+func New(sender Sender, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		sender:           sender,
+		store:            NewMemoryStore(),
+		loc:              time.Local,
+		logger:           &core.NoOpLogger{},
+		misfireThreshold: defaultMisfireThreshold,
+		maxCatchUp:       defaultMaxCatchUp,
+		jobs:             make(map[string]*entry),
+		factories:        make(map[string]MessageFactory),
+		wake:             make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// RegisterFactory registers a message factory under name. Factories must be
+// registered before jobs referencing them are added or loaded from the store.
+func (s *Scheduler) RegisterFactory(name string, factory MessageFactory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.factories[name] = factory
+}
+
+// Add validates, persists and schedules a job. NextRun is computed from now
+// unless already set. The stored copy is returned.
+func (s *Scheduler) Add(ctx context.Context, job *Job) (*Job, error) {
+	if job == nil || job.ID == "" {
+		return nil, errors.New("job ID is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[job.ID]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrJobExists, job.ID)
+	}
+	if _, ok := s.factories[job.Factory]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrFactoryNotFound, job.Factory)
+	}
+	schedule, err := s.parseJob(job)
+	if err != nil {
+		return nil, err
+	}
+
+	job = job.clone()
+	if job.NextRun.IsZero() {
+		job.NextRun = schedule.Next(time.Now())
+	}
+	if err = s.store.Save(ctx, job); err != nil {
+		return nil, fmt.Errorf("save job %s: %w", job.ID, err)
+	}
+	s.jobs[job.ID] = &entry{job: job, schedule: schedule}
+	s.signal()
+	return job.clone(), nil
+}
+
+// Get returns a copy of the job with the given ID.
+func (s *Scheduler) Get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return e.job.clone(), nil
+}
+
+// List returns copies of all scheduled jobs.
+func (s *Scheduler) List() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, e := range s.jobs {
+		jobs = append(jobs, e.job.clone())
+	}
+	return jobs
+}
+
+// Pause stops a job from firing until it is resumed.
+func (s *Scheduler) Pause(ctx context.Context, id string) error {
+	return s.update(ctx, id, func(e *entry) {
+		e.job.Paused = true
+	})
+}
+
+// Resume re-enables a paused job. Activations missed while paused are skipped.
+func (s *Scheduler) Resume(ctx context.Context, id string) error {
+	return s.update(ctx, id, func(e *entry) {
+		if e.job.Paused {
+			e.job.Paused = false
+			e.job.NextRun = e.schedule.Next(time.Now())
+		}
+	})
+}
+
+// Delete unschedules a job and removes it from the store.
+func (s *Scheduler) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	delete(s.jobs, id)
+	return s.store.Delete(ctx, id)
+}
+
+// Start loads persisted jobs and begins firing them in the background. Jobs
+// whose activations passed while the scheduler was down are handled according
+// to their MisfirePolicy.
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return ErrSchedulerRunning
+	}
+
+	stored, err := s.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("load jobs: %w", err)
+	}
+	for _, job := range stored {
+		if _, exists := s.jobs[job.ID]; exists {
+			continue
+		}
+		schedule, parseErr := s.parseJob(job)
+		if parseErr != nil {
+			s.logWarn("skip stored job", "job_id", job.ID, "error", parseErr.Error())
+			continue
+		}
+		if job.NextRun.IsZero() {
+			job.NextRun = schedule.Next(time.Now())
+		}
+		s.jobs[job.ID] = &entry{job: job, schedule: schedule}
+	}
+
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.cancel = cancel
+	s.workers.Add(1)
+	go s.loop(runCtx)
+	return nil
+}
+
+// Stop stops firing jobs and waits for running activations to finish.
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.workers.Wait()
+	return nil
+}
+
+func (s *Scheduler) update(ctx context.Context, id string, fn func(*entry)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	fn(e)
+	s.signal()
+	return s.store.Save(ctx, e.job)
+}
+
+func (s *Scheduler) parseJob(job *Job) (Schedule, error) {
+	loc := s.loc
+	if job.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(job.Timezone); err != nil {
+			return nil, fmt.Errorf("%w: time zone %q: %w", ErrInvalidSpec, job.Timezone, err)
+		}
+	}
+	return parseIn(job.Spec, loc)
+}
+
+// signal wakes the loop after the schedule changed. Caller must hold mu.
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context) {
+	defer s.workers.Done()
+
+	for {
+		due, wait := s.takeDue(ctx, time.Now())
+		for _, d := range due {
+			s.workers.Add(1)
+			go s.fire(ctx, d)
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// takeDue advances every job whose NextRun is not after now and returns the
+// activations to fire, plus the time until the next one (-1 if none).
+func (s *Scheduler) takeDue(ctx context.Context, now time.Time) ([]dueRun, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []dueRun
+	wait := time.Duration(-1)
+	for id, e := range s.jobs {
+		job := e.job
+		if job.Paused || job.NextRun.IsZero() {
+			continue
+		}
+		if job.NextRun.After(now) {
+			if d := job.NextRun.Sub(now); wait < 0 || d < wait {
+				wait = d
+			}
+			continue
+		}
+
+		for _, at := range s.activations(e, now) {
+			if job.MaxRuns > 0 && job.RunCount >= job.MaxRuns {
+				break
+			}
+			job.RunCount++
+			job.LastRun = at
+			due = append(due, dueRun{factory: job.Factory, run: Run{
+				JobID: id, ScheduledAt: at, Params: job.clone().Params, RunCount: job.RunCount,
+			}})
+		}
+
+		if s.finished(job) {
+			delete(s.jobs, id)
+			if err := s.store.Delete(ctx, id); err != nil {
+				s.logWarn("delete finished job failed", "job_id", id, "error", err.Error())
+			}
+			continue
+		}
+		if err := s.store.Save(ctx, job); err != nil {
+			s.logWarn("save job failed", "job_id", id, "error", err.Error())
+		}
+		if d := job.NextRun.Sub(now); wait < 0 || d < wait {
+			wait = max(d, 0)
+		}
+	}
+	return due, wait
+}
+
+// activations returns the activation times to fire for a due job and moves its
+// NextRun past now. Caller must hold mu.
+func (s *Scheduler) activations(e *entry, now time.Time) []time.Time {
+	job := e.job
+	scheduled := job.NextRun
+
+	if now.Sub(scheduled) <= s.misfireThreshold {
+		job.NextRun = e.schedule.Next(scheduled)
+		return s.beforeEnd(job, scheduled)
+	}
+
+	s.logWarn("job misfired", "job_id", job.ID, "scheduled_at", scheduled.Format(time.RFC3339),
+		"policy", job.Misfire.String())
+	job.NextRun = e.schedule.Next(now)
+
+	switch job.Misfire {
+	case MisfireSkip:
+		return nil
+	case MisfireRunAll:
+		var times []time.Time
+		for at := scheduled; !at.IsZero() && !at.After(now) && len(times) < s.maxCatchUp; at = e.schedule.Next(at) {
+			times = append(times, s.beforeEnd(job, at)...)
+		}
+		return times
+	default: // MisfireRunOnce
+		return s.beforeEnd(job, scheduled)
+	}
+}
+
+func (s *Scheduler) beforeEnd(job *Job, at time.Time) []time.Time {
+	if job.EndAt != nil && at.After(*job.EndAt) {
+		return nil
+	}
+	return []time.Time{at}
+}
+
+// finished reports whether the job has no activations left.
+func (s *Scheduler) finished(job *Job) bool {
+	switch {
+	case job.NextRun.IsZero():
+		return true
+	case job.MaxRuns > 0 && job.RunCount >= job.MaxRuns:
+		return true
+	case job.EndAt != nil && job.NextRun.After(*job.EndAt):
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Scheduler) fire(ctx context.Context, d dueRun) {
+	defer s.workers.Done()
+
+	s.mu.Lock()
+	factory, ok := s.factories[d.factory]
+	s.mu.Unlock()
+	if !ok {
+		s.logWarn("message factory not registered", "job_id", d.run.JobID, "factory", d.factory)
+		return
+	}
+
+	msg, err := factory(ctx, d.run)
+	if errors.Is(err, ErrStopJob) {
+		if delErr := s.Delete(ctx, d.run.JobID); delErr != nil && !errors.Is(delErr, ErrJobNotFound) {
+			s.logWarn("delete stopped job failed", "job_id", d.run.JobID, "error", delErr.Error())
+		}
+		return
+	}
+	if err != nil {
+		s.logWarn("message factory failed", "job_id", d.run.JobID, "error", err.Error())
+		return
+	}
+
+	opts := append([]core.SendOption{core.WithSendMetadata(MetadataJobID, d.run.JobID)}, s.sendOpts...)
+	if err = s.sender.Send(ctx, msg, opts...); err != nil {
+		s.logWarn("scheduled send failed", "job_id", d.run.JobID, "error", err.Error())
+	}
+}
+
+func (s *Scheduler) logWarn(msg string, keyvals ...interface{}) {
+	_ = s.logger.Log(core.LevelWarn, append([]interface{}{"message", msg}, keyvals...)...)
+}
@@ -0,0 +1,192 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/scheduler"
+)
+
+type testMessage struct {
+	id string
+}
+
+func (m *testMessage) Validate() error                 { return nil }
+func (m *testMessage) ProviderType() core.ProviderType { return core.ProviderTypeSMS }
+func (m *testMessage) MsgID() string                   { return m.id }
+func (m *testMessage) GetMsgType() string              { return "" }
+func (m *testMessage) GetSubProvider() string          { return "" }
+
+type recordingSender struct {
+	mu       sync.Mutex
+	messages []core.Message
+}
+
+func (r *recordingSender) Send(_ context.Context, msg core.Message, _ ...core.SendOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func (r *recordingSender) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.messages)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func echoFactory(_ context.Context, run scheduler.Run) (core.Message, error) {
+	return &testMessage{id: run.JobID}, nil
+}
+
+func TestScheduler_FiresAndStopsAfterMaxRuns(t *testing.T) {
+	sender := &recordingSender{}
+	s := scheduler.New(sender)
+	s.RegisterFactory("echo", echoFactory)
+
+	ctx := context.Background()
+	if _, err := s.Add(ctx, &scheduler.Job{ID: "j1", Spec: "@every 10ms", Factory: "echo", MaxRuns: 3}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer s.Stop()
+
+	waitFor(t, func() bool { return sender.count() == 3 })
+	waitFor(t, func() bool { _, err := s.Get("j1"); return errors.Is(err, scheduler.ErrJobNotFound) })
+}
+
+func TestScheduler_PauseResumeDelete(t *testing.T) {
+	sender := &recordingSender{}
+	s := scheduler.New(sender)
+	s.RegisterFactory("echo", echoFactory)
+	ctx := context.Background()
+	_, _ = s.Add(ctx, &scheduler.Job{ID: "j2", Spec: "@every 10ms", Factory: "echo"})
+	_ = s.Start(ctx)
+	defer s.Stop()
+
+	waitFor(t, func() bool { return sender.count() > 0 })
+	if err := s.Pause(ctx, "j2"); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond) // let an in-flight activation land
+	paused := sender.count()
+	time.Sleep(50 * time.Millisecond)
+	if sender.count() != paused {
+		t.Errorf("paused job fired: %d -> %d", paused, sender.count())
+	}
+
+	if err := s.Resume(ctx, "j2"); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	waitFor(t, func() bool { return sender.count() > paused })
+
+	if err := s.Delete(ctx, "j2"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := s.Delete(ctx, "j2"); !errors.Is(err, scheduler.ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestScheduler_StopJobFromFactory(t *testing.T) {
+	sender := &recordingSender{}
+	s := scheduler.New(sender)
+	acked := make(chan struct{})
+	var once sync.Once
+	s.RegisterFactory("remind", func(_ context.Context, run scheduler.Run) (core.Message, error) {
+		if run.RunCount > 2 {
+			once.Do(func() { close(acked) })
+			return nil, scheduler.ErrStopJob
+		}
+		return &testMessage{id: run.JobID}, nil
+	})
+	ctx := context.Background()
+	_, _ = s.Add(ctx, &scheduler.Job{ID: "remind", Spec: "@every 10ms", Factory: "remind"})
+	_ = s.Start(ctx)
+	defer s.Stop()
+
+	<-acked
+	waitFor(t, func() bool { return len(s.List()) == 0 })
+	if sender.count() != 2 {
+		t.Errorf("expected 2 reminders before acknowledgement, got %d", sender.count())
+	}
+}
+
+func TestScheduler_MisfirePolicies(t *testing.T) {
+	cases := []struct {
+		policy scheduler.MisfirePolicy
+		want   int
+	}{
+		{scheduler.MisfireSkip, 0},
+		{scheduler.MisfireRunOnce, 1},
+		{scheduler.MisfireRunAll, 5},
+	}
+	for _, c := range cases {
+		t.Run(c.policy.String(), func(t *testing.T) {
+			ctx := context.Background()
+			store := scheduler.NewMemoryStore()
+			// Persisted before "downtime": five hourly activations were missed.
+			_ = store.Save(ctx, &scheduler.Job{
+				ID: "report", Spec: "@every 1h", Factory: "echo", Misfire: c.policy,
+				NextRun: time.Now().Add(-4*time.Hour - 30*time.Minute),
+			})
+
+			sender := &recordingSender{}
+			s := scheduler.New(sender, scheduler.WithStore(store))
+			s.RegisterFactory("echo", echoFactory)
+			if err := s.Start(ctx); err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+			time.Sleep(50 * time.Millisecond)
+			_ = s.Stop()
+
+			if sender.count() != c.want {
+				t.Errorf("expected %d catch-up sends, got %d", c.want, sender.count())
+			}
+			stored, _ := store.List(ctx)
+			if len(stored) != 1 || !stored[0].NextRun.After(time.Now()) {
+				t.Errorf("expected job rescheduled into the future, got %+v", stored)
+			}
+		})
+	}
+}
+
+func TestScheduler_AddValidation(t *testing.T) {
+	s := scheduler.New(&recordingSender{})
+	s.RegisterFactory("echo", echoFactory)
+	ctx := context.Background()
+
+	if _, err := s.Add(ctx, &scheduler.Job{ID: "a", Spec: "* * *", Factory: "echo"}); !errors.Is(err, scheduler.ErrInvalidSpec) {
+		t.Errorf("expected ErrInvalidSpec, got %v", err)
+	}
+	if _, err := s.Add(ctx, &scheduler.Job{ID: "a", Spec: "@hourly", Factory: "missing"}); !errors.Is(err, scheduler.ErrFactoryNotFound) {
+		t.Errorf("expected ErrFactoryNotFound, got %v", err)
+	}
+	job, err := s.Add(ctx, &scheduler.Job{ID: "a", Spec: "0 9 * * MON-FRI", Timezone: "UTC", Factory: "echo"})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if job.NextRun.Hour() != 9 || job.NextRun.Weekday() == time.Saturday || job.NextRun.Weekday() == time.Sunday {
+		t.Errorf("unexpected NextRun %s", job.NextRun)
+	}
+	if _, err = s.Add(ctx, &scheduler.Job{ID: "a", Spec: "@hourly", Factory: "echo"}); !errors.Is(err, scheduler.ErrJobExists) {
+		t.Errorf("expected ErrJobExists, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Store persists jobs so that schedules survive restarts. Implementations must
+// be safe for concurrent use.
+type Store interface {
+	// Save creates or replaces the job with the same ID.
+	Save(ctx context.Context, job *Job) error
+	// Delete removes the job. Deleting a missing job is not an error.
+	Delete(ctx context.Context, id string) error
+	// List returns all stored jobs.
+	List(ctx context.Context) ([]*Job, error)
+}
+
+// MemoryStore is an in-process Store. Schedules are lost when the process exits.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job.clone()
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// List implements Store. Jobs are ordered by ID.
+func (s *MemoryStore) List(_ context.Context) ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.clone())
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs, nil
+}
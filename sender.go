@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"sync"
 	"time"
@@ -20,6 +21,20 @@ type Sender struct {
 	closed     bool
 	// defaultHTTPClient is the global default HTTP client for all HTTP-based providers. SMTP/email is not affected.
 	defaultHTTPClient *http.Client
+	// monitor probes the providers in the background, see StartHealthMonitor.
+	monitor *core.HealthMonitor
+	// probeMu guards probes, the results of the probes run by HealthCheck itself.
+	probeMu sync.Mutex
+	probes  map[core.ProviderType]cachedProbe
+}
+
+// healthCacheTTL is how long HealthCheck reuses the results of its own probes.
+const healthCacheTTL = 5 * time.Second
+
+// cachedProbe is the health of a provider, as probed by HealthCheck.
+type cachedProbe struct {
+	provider *core.ProviderDecorator
+	check    *core.HealthCheck
 }
 
 // Option defines a function type for configuring Sender.
@@ -54,21 +69,26 @@ func NewSender(opts ...Option) *Sender {
 	return s
 }
 
-// RegisterProvider registers a provider with the sender.
+// RegisterProvider registers a provider with the sender. A provider already
+// registered for providerType is replaced and closed.
 func (s *Sender) RegisterProvider(
 	providerType core.ProviderType,
 	provider core.Provider,
 	middleware *core.SenderMiddleware,
 ) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if middleware == nil {
 		copyMiddleware := *s.middleware
 		middleware = &copyMiddleware
 	}
 
+	replaced := s.providers[providerType]
 	s.providers[providerType] = core.NewProviderDecorator(provider, middleware, s.logger)
+	if prober, ok := provider.(core.Prober); ok && s.monitor != nil {
+		s.monitor.Register(string(providerType), prober)
+	}
+	s.mu.Unlock()
+
 	_ = s.logger.Log(
 		core.LevelInfo,
 		"message",
@@ -78,23 +98,45 @@ func (s *Sender) RegisterProvider(
 		"type",
 		providerType,
 	) // ignore log error
+	s.closeReplaced(replaced, providerType)
+}
+
+// closeReplaced closes the decorator of a provider that was replaced or
+// unregistered, so that its workers and queue handlers stop. Closing waits for
+// its workers, so it is done without the lock.
+func (s *Sender) closeReplaced(provider *core.ProviderDecorator, providerType core.ProviderType) {
+	if provider == nil {
+		return
+	}
+	if err := provider.Close(); err != nil {
+		_ = s.logger.Log(core.LevelWarn, "message", "failed to close replaced provider",
+			"type", providerType, "error", err.Error()) // ignore log error
+	}
 }
 
-// UnregisterProvider removes a provider from the sender.
+// UnregisterProvider removes a provider from the sender and closes it, see
+// [core.ProviderDecorator.Close].
 func (s *Sender) UnregisterProvider(providerType core.ProviderType) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if s.closed {
+		s.mu.Unlock()
 		return errors.New("sender is closed, cannot unregister provider")
 	}
 
-	if _, exists := s.providers[providerType]; !exists {
+	provider, exists := s.providers[providerType]
+	if !exists {
+		s.mu.Unlock()
 		return fmt.Errorf("provider type %s not found", providerType)
 	}
 
 	delete(s.providers, providerType)
+	if s.monitor != nil {
+		s.monitor.Unregister(string(providerType))
+	}
+	s.mu.Unlock()
+
 	_ = s.logger.Log(core.LevelInfo, "message", "provider unregistered", "type", providerType) // ignore log error
+	s.closeReplaced(provider, providerType)
 	return nil
 }
 
@@ -128,6 +170,54 @@ func (s *Sender) SendWithResult(
 	return provider.Send(ctx, message, allOpts...)
 }
 
+// SendAsync sends a message asynchronously and returns a [core.SendFuture] that
+// resolves with the final outcome. Use [Sender.SetResultStore] when messages are
+// processed by workers in another process.
+func (s *Sender) SendAsync(
+	ctx context.Context,
+	message core.Message,
+	opts ...core.SendOption,
+) (*core.SendFuture, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, errors.New("sender is closed")
+	}
+
+	providerType := message.ProviderType()
+	provider, exists := s.providers[providerType]
+	if !exists {
+		return nil, fmt.Errorf("no provider registered for type %s", providerType)
+	}
+
+	allOpts := append([]core.SendOption{core.WithSendHTTPClient(s.defaultHTTPClient)}, opts...)
+	return provider.SendAsync(ctx, message, allOpts...)
+}
+
+// Cancel cancels a scheduled or queued async message by its MsgID before it is sent.
+// The message's Callback, if any, receives an error with code core.ErrCodeQueueItemCancelled.
+// It returns an error with code core.ErrCodeQueueItemNotFound if no provider holds the message.
+func (s *Sender) Cancel(ctx context.Context, msgID string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return errors.New("sender is closed")
+	}
+
+	for _, provider := range s.providers {
+		err := provider.Cancel(ctx, msgID)
+		if err == nil {
+			return nil
+		}
+		if core.GetSenderErrorCode(err) != core.ErrCodeQueueItemNotFound {
+			return err
+		}
+	}
+	return core.NewSenderErrorf(core.ErrCodeQueueItemNotFound, "message %s not found", msgID)
+}
+
 // GetProvider retrieves a provider by type.
 func (s *Sender) GetProvider(providerType core.ProviderType) (*core.ProviderDecorator, bool) {
 	s.mu.RLock()
@@ -150,6 +240,17 @@ func (s *Sender) SetRateLimiter(rateLimiter core.RateLimiter) {
 	s.middleware.RateLimiter = rateLimiter
 }
 
+// SetRateLimitWait makes sends wait for the rate limiter instead of failing at
+// once; see [core.RateLimitWait].
+//
+// NOTE: Only providers registered after this call are affected.
+func (s *Sender) SetRateLimitWait(wait *core.RateLimitWait) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middleware.RateLimitWait = wait
+}
+
 // SetRetryPolicy sets the retry policy for the sender.
 //
 // NOTE: The update only affects providers registered *after* the call. See the
@@ -168,6 +269,29 @@ func (s *Sender) SetRetryPolicy(retryPolicy *core.RetryPolicy) error {
 	return nil
 }
 
+// SetRetryBudget caps the retries of every provider at a share of its first
+// attempts; see [core.RetryBudget].
+//
+// NOTE: Only providers registered after this call are affected.
+func (s *Sender) SetRetryBudget(budget *core.RetryBudget) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middleware.RetryBudget = budget
+}
+
+// SetHedgePolicy makes every provider race the slow attempts of the sends that
+// opt in with core.WithSendHedge against a second attempt on another account;
+// see [core.HedgePolicy].
+//
+// NOTE: Only providers registered after this call are affected.
+func (s *Sender) SetHedgePolicy(policy *core.HedgePolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middleware.Hedge = policy
+}
+
 // SetQueue sets the queue for the sender.
 //
 // NOTE: This only influences providers registered **after** the setter is
@@ -180,6 +304,26 @@ func (s *Sender) SetQueue(queue core.Queue) {
 	s.middleware.Queue = queue
 }
 
+// SetDeadLetterQueue sets the queue that receives messages dropped because they expired.
+//
+// NOTE: Only providers registered after this call will use the dead-letter queue.
+func (s *Sender) SetDeadLetterQueue(queue core.Queue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middleware.DeadLetter = queue
+}
+
+// SetResultStore sets the store that keeps the outcome of async sends for [core.SendFuture].
+//
+// NOTE: Only providers registered after this call will use the result store.
+func (s *Sender) SetResultStore(store core.ResultStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middleware.ResultStore = store
+}
+
 // SetCircuitBreaker sets the circuit breaker for the sender.
 //
 // NOTE: Only providers registered after this call will use the circuit breaker.
@@ -200,6 +344,17 @@ func (s *Sender) SetMetrics(metrics core.MetricsCollector) {
 	s.middleware.Metrics = metrics
 }
 
+// SetTracer traces every send, and links async sends to the trace of the call
+// that enqueued them; see [core.Tracer].
+//
+// NOTE: Only providers registered after this call are affected.
+func (s *Sender) SetTracer(tracer core.Tracer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.middleware.Tracer = tracer
+}
+
 // SetDefaultHTTPClient sets the global default HTTP client for all HTTP-based providers.
 // This only affects HTTP/REST providers; SMTP/email providers are not affected.
 func (s *Sender) SetDefaultHTTPClient(client *http.Client) {
@@ -208,10 +363,94 @@ func (s *Sender) SetDefaultHTTPClient(client *http.Client) {
 	s.defaultHTTPClient = client
 }
 
+// StartHealthMonitor probes the accounts of the providers implementing
+// [core.Prober] in the background, with the default HTTP client unless
+// core.WithProbeHTTPClient is given. Accounts whose probe fails are avoided by
+// selection until they recover, and HealthCheck reports the cached results
+// instead of probing. A monitor started before is closed.
+func (s *Sender) StartHealthMonitor(opts ...core.HealthMonitorOption) *core.HealthMonitor {
+	s.mu.Lock()
+	previous := s.monitor
+	opts = append([]core.HealthMonitorOption{core.WithProbeHTTPClient(s.defaultHTTPClient)}, opts...)
+	monitor := core.NewHealthMonitor(opts...)
+	for providerType, provider := range s.providers {
+		if prober, ok := provider.Provider.(core.Prober); ok {
+			monitor.Register(string(providerType), prober)
+		}
+	}
+	monitor.Start()
+	s.monitor = monitor
+	s.mu.Unlock()
+
+	// Closing waits for the running probes, so it is done without the lock
+	if previous != nil {
+		_ = previous.Close()
+	}
+	return monitor
+}
+
+// providerCheck returns the health of provider: the cached probes of the health
+// monitor, probes if it implements core.Prober, or its own health check. Probes
+// and health checks are bounded by core.DefaultProbeTimeout, and the results of
+// probes are reused for healthCacheTTL.
+func (s *Sender) providerCheck(
+	ctx context.Context,
+	monitor *core.HealthMonitor,
+	httpClient *http.Client,
+	providerType core.ProviderType,
+	provider *core.ProviderDecorator,
+) *core.HealthCheck {
+	if monitor != nil {
+		if check, ok := monitor.Health(string(providerType)); ok {
+			return check
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, core.DefaultProbeTimeout)
+	defer cancel()
+	switch p := provider.Provider.(type) {
+	case core.Prober:
+		if check, ok := s.cachedProbe(providerType, provider); ok {
+			return check
+		}
+		check := core.HealthFromProbes(p.Probe(ctx, &core.ProviderSendOptions{HTTPClient: httpClient}))
+		s.cacheProbe(providerType, provider, check)
+		return check
+	case core.HealthChecker:
+		return p.HealthCheck(ctx)
+	}
+	return nil
+}
+
+// cachedProbe returns the health of provider probed less than healthCacheTTL ago.
+func (s *Sender) cachedProbe(providerType core.ProviderType, provider *core.ProviderDecorator) (*core.HealthCheck, bool) {
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	cached, ok := s.probes[providerType]
+	if !ok || cached.provider != provider || time.Since(cached.check.Timestamp) >= healthCacheTTL {
+		return nil, false
+	}
+	return cached.check, true
+}
+
+func (s *Sender) cacheProbe(providerType core.ProviderType, provider *core.ProviderDecorator, check *core.HealthCheck) {
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	if s.probes == nil {
+		s.probes = make(map[core.ProviderType]cachedProbe)
+	}
+	s.probes[providerType] = cachedProbe{provider: provider, check: check}
+}
+
 // HealthCheck performs a health check on the sender and all its components.
+// Providers implementing [core.Prober] are probed concurrently, unless a health
+// monitor already did (see StartHealthMonitor); their results are reused for a
+// few seconds. Sends are not held up while the providers are checked.
 func (s *Sender) HealthCheck(ctx context.Context) *core.SenderHealth {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	providers := maps.Clone(s.providers)
+	metrics, queue := s.middleware.Metrics, s.middleware.Queue
+	monitor, httpClient := s.monitor, s.defaultHTTPClient
+	s.mu.RUnlock()
 
 	health := &core.SenderHealth{
 		Status:    core.HealthStatusHealthy,
@@ -219,20 +458,45 @@ func (s *Sender) HealthCheck(ctx context.Context) *core.SenderHealth {
 		Providers: make(map[core.ProviderType]*core.ProviderHealth),
 	}
 
+	// Probe the providers concurrently, as probes go over the network
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	checks := make(map[core.ProviderType]*core.HealthCheck, len(providers))
+	for providerType, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check := s.providerCheck(ctx, monitor, httpClient, providerType, provider)
+			mu.Lock()
+			defer mu.Unlock()
+			checks[providerType] = check
+		}()
+	}
+	wg.Wait()
+
 	// Check providers
-	for providerType, provider := range s.providers {
+	for providerType, provider := range providers {
 		providerHealth := &core.ProviderHealth{
 			ProviderType: providerType,
 			Status:       core.HealthStatusHealthy,
 			LastCheck:    time.Now(),
 		}
 
-		// Check if the underlying provider implements HealthChecker
-		if healthChecker, ok := provider.Provider.(core.HealthChecker); ok {
-			check := healthChecker.HealthCheck(ctx)
-			if check != nil {
-				providerHealth.Status = check.Status
-				providerHealth.Message = check.Message
+		// Fill the error rate and latency from the metrics, if they keep recent statistics
+		if reporter, ok := metrics.(core.ProviderStatsReporter); ok {
+			if stats, found := reporter.ProviderStats(provider.Name()); found {
+				providerHealth.ErrorRate = stats.ErrorRate
+				providerHealth.Latency = stats.Latency
+			}
+		}
+
+		// Report the probes of the accounts, or the provider's own health check
+		if check := checks[providerType]; check != nil {
+			providerHealth.Status = check.Status
+			providerHealth.Message = check.Message
+			providerHealth.Accounts = check.Checks
+			if !check.Timestamp.IsZero() {
+				providerHealth.LastCheck = check.Timestamp
 			}
 		}
 
@@ -247,15 +511,15 @@ func (s *Sender) HealthCheck(ctx context.Context) *core.SenderHealth {
 	}
 
 	// Check queue health
-	if s.middleware.Queue != nil {
-		if queueHealthChecker, ok := s.middleware.Queue.(core.HealthChecker); ok {
+	if queue != nil {
+		if queueHealthChecker, ok := queue.(core.HealthChecker); ok {
 			health.Queue = queueHealthChecker.HealthCheck(ctx)
 		}
 	}
 
 	// Check metrics health
-	if s.middleware.Metrics != nil {
-		if metricsHealthChecker, ok := s.middleware.Metrics.(core.HealthChecker); ok {
+	if metrics != nil {
+		if metricsHealthChecker, ok := metrics.(core.HealthChecker); ok {
 			health.Metrics = metricsHealthChecker.HealthCheck(ctx)
 		}
 	}
@@ -275,16 +539,25 @@ func closeComponent(closer interface{ Close() error }, errs *[]error, desc strin
 // Close gracefully shuts down the sender and all its components.
 func (s *Sender) Close() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if s.closed {
+		s.mu.Unlock()
 		return nil
 	}
-
 	s.closed = true
+	monitor := s.monitor
+	s.mu.Unlock()
 
 	var errs []error
 
+	// Stop probing before the providers go away. Closing waits for the running
+	// probes, so it is done without the lock.
+	if monitor != nil {
+		closeComponent(monitor, &errs, "health monitor")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Close all providers
 	for providerType, provider := range s.providers {
 		if err := provider.Close(); err != nil {
@@ -296,6 +569,7 @@ func (s *Sender) Close() error {
 	if s.middleware != nil {
 		closeComponent(s.middleware.RateLimiter, &errs, "rate limiter")
 		closeComponent(s.middleware.Queue, &errs, "queue")
+		closeComponent(s.middleware.DeadLetter, &errs, "dead-letter queue")
 		closeComponent(s.middleware.CircuitBreaker, &errs, "circuit breaker")
 	}
 
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	gosender "github.com/shellvon/go-sender"
 	"github.com/shellvon/go-sender/core"
+	"github.com/shellvon/go-sender/metrics"
 	"github.com/shellvon/go-sender/providers/sms"
+	"github.com/shellvon/go-sender/queue"
 )
 
 type FakeProvider struct {
@@ -83,6 +87,29 @@ func TestSender_UnregisterProvider(t *testing.T) {
 	}
 }
 
+func TestSender_ReRegisterProviderClosesReplaced(t *testing.T) {
+	q := queue.NewMemoryQueue[*core.QueueItem](0)
+	s := gosender.NewSender()
+	defer s.Close()
+	s.SetQueue(q)
+	s.RegisterProvider(core.ProviderTypeSMS, &FakeProvider{NameVal: "fake"}, nil)
+	s.RegisterProvider(core.ProviderTypeSMS, &FakeProvider{NameVal: "fake"}, nil)
+
+	var calls atomic.Int32
+	msg := sms.Aliyun().To("***REMOVED***").Content("test").SignName("sign").Build()
+	err := s.Send(context.Background(), msg, core.WithSendAsync(), core.WithSendDelay(time.Hour),
+		core.WithSendCallback(func(*core.SendResult, error) { calls.Add(1) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := q.Purge(context.Background(), "fake"); n != 1 {
+		t.Fatalf("expected one purged item, got %d", n)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected the replaced provider to stop handling evictions, callback ran %d times", n)
+	}
+}
+
 func TestSender_GetProvider(t *testing.T) {
 	s := gosender.NewSender()
 	fake := &FakeProvider{NameVal: "fake"}
@@ -239,6 +266,127 @@ func TestSender_HealthCheck(t *testing.T) {
 	}
 }
 
+func TestSender_HealthCheckReportsRecentStats(t *testing.T) {
+	s := gosender.NewSender()
+	s.SetMetrics(metrics.NewMemoryMetricsCollector())
+	s.RegisterProvider(core.ProviderTypeSMS, &FakeProvider{NameVal: "fake", SendErr: errFake}, nil)
+
+	msg := sms.Aliyun().To("***REMOVED***").Content("test").SignName("sign").Build()
+	_ = s.Send(context.Background(), msg)
+
+	h := s.HealthCheck(context.Background())
+	if ph := h.Providers[core.ProviderTypeSMS]; ph == nil || ph.ErrorRate != 1 {
+		t.Errorf("expected the failed send in the error rate, got %+v", ph)
+	}
+}
+
+// fakeProbedProvider reports the probe results it is given. With probing set,
+// its probes signal probing and wait for release.
+type fakeProbedProvider struct {
+	FakeProvider
+
+	mu      sync.Mutex
+	results []core.ProbeResult
+	probes  int
+
+	probing chan struct{}
+	release chan struct{}
+}
+
+func (f *fakeProbedProvider) Probe(ctx context.Context, _ *core.ProviderSendOptions) []core.ProbeResult {
+	if f.probing != nil {
+		f.probing <- struct{}{}
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.probes++
+	return f.results
+}
+
+func (f *fakeProbedProvider) probeCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.probes
+}
+
+func TestSender_HealthCheckProbesAccounts(t *testing.T) {
+	fp := &fakeProbedProvider{
+		FakeProvider: FakeProvider{NameVal: "fake"},
+		results:      []core.ProbeResult{{Account: "a"}, {Account: "b", Err: errFake}},
+	}
+	s := gosender.NewSender()
+	defer s.Close()
+	s.RegisterProvider(core.ProviderTypeSMS, fp, nil)
+
+	h := s.HealthCheck(context.Background())
+	ph := h.Providers[core.ProviderTypeSMS]
+	if h.Status != core.HealthStatusDegraded || ph.Accounts["b"].Status != core.HealthStatusUnhealthy {
+		t.Fatalf("expected a degraded provider with b unhealthy, got %s: %+v", h.Status, ph)
+	}
+
+	// With a monitor, HealthCheck reports its cached results instead of probing.
+	monitor := s.StartHealthMonitor(core.WithProbeInterval(time.Hour))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := monitor.Health(string(core.ProviderTypeSMS)); ok || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	probes := fp.probeCount()
+	h = s.HealthCheck(context.Background())
+	if h.Status != core.HealthStatusDegraded || fp.probeCount() != probes {
+		t.Errorf("expected the cached result without a new probe, got %s after %d probes", h.Status, fp.probeCount()-probes)
+	}
+}
+
+func TestSender_HealthCheckProbesWithoutLock(t *testing.T) {
+	fp := &fakeProbedProvider{
+		FakeProvider: FakeProvider{NameVal: "slow"},
+		results:      []core.ProbeResult{{Account: "a"}},
+		probing:      make(chan struct{}, 1),
+		release:      make(chan struct{}),
+	}
+	s := gosender.NewSender()
+	defer s.Close()
+	s.RegisterProvider(core.ProviderTypeEmail, fp, nil)
+	s.RegisterProvider(core.ProviderTypeSMS, &FakeProvider{NameVal: "fake"}, nil)
+
+	checked := make(chan *core.SenderHealth)
+	go func() { checked <- s.HealthCheck(context.Background()) }()
+	<-fp.probing
+
+	// A pending write lock must not hold up sends while the probe runs.
+	registered := make(chan struct{})
+	go func() {
+		s.RegisterProvider(core.ProviderTypeWebhook, &FakeProvider{NameVal: "hook"}, nil)
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(time.Second):
+		t.Fatal("RegisterProvider blocked by a running probe")
+	}
+	msg := sms.Aliyun().To("***REMOVED***").Content("test").SignName("sign").Build()
+	if err := s.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send failed during a probe: %v", err)
+	}
+
+	close(fp.release)
+	if h := <-checked; h.Providers[core.ProviderTypeEmail].Status != core.HealthStatusHealthy {
+		t.Errorf("expected the probed provider to be healthy, got %+v", h.Providers[core.ProviderTypeEmail])
+	}
+	// The probe result is reused by the next health check.
+	s.HealthCheck(context.Background())
+	if probes := fp.probeCount(); probes != 1 {
+		t.Errorf("expected the cached probe to be reused, got %d probes", probes)
+	}
+}
+
 type fakeCloser struct {
 	closed *bool
 	err    error
@@ -450,3 +598,40 @@ func TestSender_HookAfterOnError(t *testing.T) {
 		t.Fatalf("afterHook not executed on error")
 	}
 }
+
+func TestSender_Cancel(t *testing.T) {
+	s := gosender.NewSender()
+	defer s.Close()
+	s.RegisterProvider(core.ProviderTypeSMS, &FakeProvider{NameVal: "fake"}, nil)
+
+	msg := sms.Aliyun().To("***REMOVED***").Content("test").SignName("sign").Build()
+	if err := s.Send(context.Background(), msg, core.WithSendAsync(), core.WithSendDelay(time.Hour)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if err := s.Cancel(context.Background(), msg.MsgID()); err != nil {
+		t.Errorf("Cancel failed: %v", err)
+	}
+	if err := s.Cancel(context.Background(), "unknown"); core.GetSenderErrorCode(err) != core.ErrCodeQueueItemNotFound {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestSender_SendAsync(t *testing.T) {
+	s := gosender.NewSender()
+	defer s.Close()
+	s.RegisterProvider(core.ProviderTypeSMS, &FakeProvider{NameVal: "fake"}, nil)
+
+	msg := sms.Aliyun().To("***REMOVED***").Content("test").SignName("sign").Build()
+	future, err := s.SendAsync(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("SendAsync failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err = future.Wait(ctx); err != nil {
+		t.Errorf("Wait returned error: %v", err)
+	}
+	if future.Status() != core.SendStatusSucceeded {
+		t.Errorf("expected succeeded, got %s", future.Status())
+	}
+}